@@ -6,8 +6,10 @@ require (
 	cloud.google.com/go/speech v1.28.0
 	github.com/bwmarrin/discordgo v0.29.0
 	github.com/joho/godotenv v1.5.1
+	github.com/pion/opus v0.0.0-20250705204357-4eb3b46b716c
 	github.com/pion/rtp v1.8.20
 	github.com/pion/webrtc/v3 v3.3.5
+	google.golang.org/protobuf v1.36.6
 )
 
 require (
@@ -41,7 +43,6 @@ require (
 	google.golang.org/genproto/googleapis/api v0.0.0-20250603155806-513f23925822 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20250603155806-513f23925822 // indirect
 	google.golang.org/grpc v1.73.0 // indirect
-	google.golang.org/protobuf v1.36.6 // indirect
 )
 
 replace github.com/pion/opus => github.com/avleen/opus v0.0.0-20250705204357-4eb3b46b716c
@@ -0,0 +1,68 @@
+package audio
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// SSRCActivity describes one audio stream heard in the current session
+type SSRCActivity struct {
+	SSRC   uint32
+	UserID string // Empty until Discord reports who is speaking on the SSRC
+	Active bool   // Recording and transcription have been set up for the stream
+
+	// How long ago any packet arrived from the stream, including silence
+	LastPacketAge time.Duration
+
+	// Packets per second over the last few seconds (MaxPacketRate while speaking continuously)
+	PacketRate float64
+}
+
+// SSRCActivity returns every stream heard in the current session, ordered by SSRC
+func (p *Processor) SSRCActivity() []SSRCActivity {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	now := p.options.Clock.Now()
+	activity := make([]SSRCActivity, 0, len(p.lastReceived))
+	for ssrc, last := range p.lastReceived {
+		_, active := p.transcriptionChans[ssrc]
+		stream := SSRCActivity{
+			SSRC:          ssrc,
+			UserID:        p.ssrcUsers[ssrc],
+			Active:        active,
+			LastPacketAge: now.Sub(last),
+		}
+		if counter, ok := p.packetRates[ssrc]; ok {
+			stream.PacketRate = counter.rate(now)
+		}
+		activity = append(activity, stream)
+	}
+
+	slices.SortFunc(activity, func(a, b SSRCActivity) int {
+		return cmp.Compare(a.SSRC, b.SSRC)
+	})
+	return activity
+}
+
+// Speakers returns the users linked to an SSRC in the current session, each once, ordered by
+// their first SSRC
+func (p *Processor) Speakers() []string {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	ssrcs := make([]uint32, 0, len(p.ssrcUsers))
+	for ssrc := range p.ssrcUsers {
+		ssrcs = append(ssrcs, ssrc)
+	}
+	slices.Sort(ssrcs)
+
+	var users []string
+	for _, ssrc := range ssrcs {
+		if userID := p.ssrcUsers[ssrc]; userID != "" && !slices.Contains(users, userID) {
+			users = append(users, userID)
+		}
+	}
+	return users
+}
@@ -0,0 +1,181 @@
+package audio
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"dnd_dm_assistant_go/internal/clock"
+)
+
+// archivablePattern matches the names of the files sessions write: recordings and speaker maps
+// ("[campaign_]audio_20240102_150405_<speaker>.ogg", "..._speakers.json") and failed
+// transcription audio ("debug_audio_20240102_150405_<ssrc>.ogg" or ".wav")
+var archivablePattern = regexp.MustCompile(`^(?:.+_)?audio_\d{8}_\d{6}_.+\.(?:ogg|wav|json)$`)
+
+// ArchiveResult describes the outcome of archiving old recordings
+type ArchiveResult struct {
+	Path         string // Archive written, or "" if there was nothing to archive
+	Files        int    // Files moved into the archive
+	OriginalSize int64  // Total size of the files moved
+	ArchiveSize  int64
+	Skipped      int // Old enough but still being written by a session
+	RemoveErrors int // Archived but couldn't be deleted
+}
+
+// Reclaimed returns the disk space freed, in bytes
+func (r ArchiveResult) Reclaimed() int64 {
+	return max(r.OriginalSize-r.ArchiveSize, 0)
+}
+
+// ArchiveRecordings zips every session file in the recordings directory last written more
+// than minAge ago into a single dated archive there, then deletes the originals. Files that a
+// session still has open are left alone. If writing the archive fails, nothing is deleted.
+func (m *Manager) ArchiveRecordings(minAge time.Duration) (ArchiveResult, error) {
+	var result ArchiveResult
+	if m.options.Ephemeral {
+		return result, errors.New("recordings aren't kept in ephemeral mode")
+	}
+
+	dir := m.options.Dir
+	if dir == "" {
+		dir = "."
+	}
+	var now time.Time
+	if m.options.Clock != nil {
+		now = m.options.Clock.Now()
+	} else {
+		now = clock.Real{}.Now()
+	}
+
+	open := make(map[string]bool)
+	for _, session := range m.allSessions() {
+		for _, path := range session.openFiles() {
+			open[filepath.Base(path)] = true
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return result, fmt.Errorf("failed to list recordings: %w", err)
+	}
+
+	var files []os.FileInfo
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !archivablePattern.MatchString(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || now.Sub(info.ModTime()) < minAge {
+			continue
+		}
+		if open[entry.Name()] {
+			result.Skipped++
+			continue
+		}
+		files = append(files, info)
+	}
+	if len(files) == 0 {
+		return result, nil
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name() < files[j].Name() })
+
+	result.Path = filepath.Join(dir, "recordings_archive_"+now.Format("20060102_150405")+".zip")
+	size, err := writeArchive(result.Path, dir, files)
+	if err != nil {
+		os.Remove(result.Path)
+		return ArchiveResult{}, err
+	}
+	result.ArchiveSize = size
+
+	for _, info := range files {
+		if err := os.Remove(filepath.Join(dir, info.Name())); err != nil {
+			log.Printf("[AUDIO] ⚠️ Archived %s but couldn't delete it: %v", info.Name(), err)
+			result.RemoveErrors++
+			continue
+		}
+		result.Files++
+		result.OriginalSize += info.Size()
+	}
+
+	if m.debug.Load() {
+		log.Printf("[AUDIO] 📦 Archived %d recordings into %s", result.Files, result.Path)
+	}
+	return result, nil
+}
+
+// writeArchive zips files from dir into a new archive at path, synced to disk so the originals
+// can safely be deleted, and returns its size
+func writeArchive(path, dir string, files []os.FileInfo) (int64, error) {
+	out, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer out.Close()
+
+	archive := zip.NewWriter(out)
+	for _, info := range files {
+		if err := addToArchive(archive, filepath.Join(dir, info.Name()), info); err != nil {
+			return 0, fmt.Errorf("failed to archive %s: %w", info.Name(), err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return 0, fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := out.Sync(); err != nil {
+		return 0, fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	stat, err := out.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("failed to write archive: %w", err)
+	}
+	return stat.Size(), nil
+}
+
+// addToArchive copies one file into the archive, keeping its name and modification time
+func addToArchive(archive *zip.Writer, path string, info os.FileInfo) error {
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Method = zip.Deflate
+
+	writer, err := archive.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(writer, file)
+	return err
+}
+
+// openFiles returns the files the session is still writing: its recordings and speaker map
+func (p *Processor) openFiles() []string {
+	p.mutex.RLock()
+	if !p.isProcessing {
+		p.mutex.RUnlock()
+		return nil
+	}
+	files := make([]string, 0, len(p.oggFilePaths)+1)
+	for _, path := range p.oggFilePaths {
+		files = append(files, path)
+	}
+	sessionStart := p.sessionStart
+	p.mutex.RUnlock()
+
+	return append(files, p.fileStem(sessionStart)+"_speakers.json")
+}
@@ -0,0 +1,122 @@
+package audio
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"dnd_dm_assistant_go/internal/speech"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pion/rtp"
+)
+
+// fakeTranscriber is a speech backend that returns a canned transcript instead of calling an API
+type fakeTranscriber struct {
+	mutex sync.Mutex
+
+	// recognize returns the result for a request; by default every request gets "hello there"
+	recognize func(audio []byte) (*speech.TranscriptionResult, error)
+
+	requests int
+}
+
+// RecognizeAudio records the request and returns the canned result
+func (f *fakeTranscriber) RecognizeAudio(audio []byte) (*speech.TranscriptionResult, error) {
+	f.mutex.Lock()
+	f.requests++
+	recognize := f.recognize
+	f.mutex.Unlock()
+
+	if recognize == nil {
+		return &speech.TranscriptionResult{Transcript: "hello there", Confidence: 0.9, IsFinal: true}, nil
+	}
+	return recognize(audio)
+}
+
+func (f *fakeTranscriber) SetDebug(bool) {}
+
+func (f *fakeTranscriber) Close() error { return nil }
+
+// calls returns the number of requests made so far
+func (f *fakeTranscriber) calls() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.requests
+}
+
+// fakeClock is a clock that only moves when told to
+type fakeClock struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+// advance moves the clock forward
+func (c *fakeClock) advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// newTestSession starts an in-memory session on a fake voice connection. Packets sent on the
+// returned connection's OpusRecv are processed as if they came from Discord.
+func newTestSession(t *testing.T, transcriber speech.Transcriber, opts Options) (*Processor, *discordgo.VoiceConnection) {
+	t.Helper()
+	opts.Ephemeral = true
+	p := New(false, transcriber, opts)
+
+	vc := newTestConnection()
+	if err := p.StartProcessing(vc); err != nil {
+		t.Fatalf("StartProcessing: %v", err)
+	}
+	return p, vc
+}
+
+// newTestConnection returns a voice connection that delivers whatever is sent on its OpusRecv
+func newTestConnection() *discordgo.VoiceConnection {
+	return &discordgo.VoiceConnection{GuildID: "guild", ChannelID: "channel", OpusRecv: make(chan *discordgo.Packet)}
+}
+
+// speechPacket builds a packet of non-silent Opus audio
+func speechPacket(ssrc uint32, sequence uint16) *discordgo.Packet {
+	return &discordgo.Packet{
+		SSRC:      ssrc,
+		Sequence:  sequence,
+		Timestamp: uint32(sequence) * discordFrameSize,
+		Opus:      []byte{0xfc, 0xff, 0xfe, 0x01, 0x02},
+	}
+}
+
+// testPackets builds n consecutive RTP packets of Opus audio for an SSRC
+func testPackets(ssrc uint32, n int) []*rtp.Packet {
+	packets := make([]*rtp.Packet, n)
+	for i := range packets {
+		packets[i] = &rtp.Packet{
+			Header:  rtp.Header{Version: 2, PayloadType: 111, SequenceNumber: uint16(i), Timestamp: uint32(i) * discordFrameSize, SSRC: ssrc},
+			Payload: []byte{0xfc, 0xff, 0xfe},
+		}
+	}
+	return packets
+}
+
+// waitFor polls condition until it's true, failing the test after a few seconds
+func waitFor(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,56 @@
+package audio
+
+import (
+	"errors"
+	"log"
+	"sync"
+)
+
+// ErrNoActiveSSRC is returned when flushing a user who has no audio stream in the session
+var ErrNoActiveSSRC = errors.New("user has no active audio stream")
+
+// FlushUser sends a user's buffered audio for transcription now, without waiting for them to
+// pause. The returned channel is closed once the transcription has been passed to the
+// transcription callback; it's nil if nothing was buffered for the user.
+func (p *Processor) FlushUser(userID string) (<-chan struct{}, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if !p.isProcessing {
+		return nil, errors.New("audio processing is not running")
+	}
+
+	var ssrcs []uint32
+	for ssrc, ssrcUser := range p.ssrcUsers {
+		if _, active := p.transcriptionChans[ssrc]; active && ssrcUser == userID {
+			ssrcs = append(ssrcs, ssrc)
+		}
+	}
+	if len(ssrcs) == 0 {
+		return nil, ErrNoActiveSSRC
+	}
+
+	var wg sync.WaitGroup
+	queued := 0
+	for _, ssrc := range ssrcs {
+		wg.Add(1)
+		if !p.sendAudioBuffer(ssrc, wg.Done) {
+			wg.Done()
+			continue
+		}
+		queued++
+		if p.debug.Load() {
+			log.Printf("[AUDIO] 👤 Flushed buffer for SSRC %d (user %s) on request", ssrc, userID)
+		}
+	}
+	if queued == 0 {
+		return nil, nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	return done, nil
+}
@@ -0,0 +1,25 @@
+package audio
+
+// SetIgnoredUsers replaces the set of users whose audio is never transcribed
+func (p *Processor) SetIgnoredUsers(userIDs []string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.ignoredUsers = make(map[string]bool, len(userIDs))
+	for _, id := range userIDs {
+		p.ignoredUsers[id] = true
+	}
+
+	// Drop anything already buffered for them
+	for ssrc, userID := range p.ssrcUsers {
+		if p.ignoredUsers[userID] {
+			p.audioBuffers[ssrc] = p.audioBuffers[ssrc][:0]
+		}
+	}
+}
+
+// isIgnoredSSRC returns true if the SSRC belongs to an ignored user. The caller must hold the mutex.
+func (p *Processor) isIgnoredSSRC(ssrc uint32) bool {
+	userID, known := p.ssrcUsers[ssrc]
+	return known && p.ignoredUsers[userID]
+}
@@ -0,0 +1,378 @@
+package audio
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"dnd_dm_assistant_go/internal/speech"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Manager runs one Processor per voice connection so the bot can listen in several guilds at once.
+// Discord allows a bot a single voice connection per guild, so sessions are keyed by guild ID.
+type Manager struct {
+	debug         atomic.Bool
+	deafened      atomic.Bool
+	speechService speech.Transcriber
+	options       Options
+
+	// Settings applied to every session, including ones started later
+	ignoredUsers          []string
+	campaign              string
+	userNameResolver      func(guildID, userID string) string
+	transcriptionCallback func(guildID string, ssrc uint32, text string, confidence float64, delayed bool)
+	errorCallback         func(component string, err error)
+	speakerCallback       func(guildID string)
+
+	// Processing sessions keyed by guild ID. Stopped sessions are kept for their stats,
+	// recordings and subtitles until the guild is joined again.
+	sessions map[string]*Processor
+	mutex    sync.RWMutex
+}
+
+// NewManager creates a manager whose sessions share the speech service and options
+func NewManager(debug bool, speechService speech.Transcriber, opts Options) *Manager {
+	manager := &Manager{
+		speechService: speechService,
+		options:       opts,
+		sessions:      make(map[string]*Processor),
+	}
+	manager.debug.Store(debug)
+	return manager
+}
+
+// StartProcessing starts a processing session for the voice connection's guild
+func (m *Manager) StartProcessing(vc *discordgo.VoiceConnection, speechEnabled bool) error {
+	m.mutex.Lock()
+	session, exists := m.sessions[vc.GuildID]
+	if !exists {
+		session = m.newSession(vc.GuildID)
+		m.sessions[vc.GuildID] = session
+	}
+	m.mutex.Unlock()
+
+	session.SetSpeechEnabled(speechEnabled)
+	if err := session.StartProcessing(vc); err != nil {
+		return fmt.Errorf("guild %s: %w", vc.GuildID, err)
+	}
+
+	if m.debug.Load() {
+		log.Printf("[AUDIO] Active sessions: %d", len(m.ActiveGuilds()))
+	}
+	return nil
+}
+
+// newSession creates a processor for a guild with the manager's current settings.
+// The caller must hold the mutex.
+func (m *Manager) newSession(guildID string) *Processor {
+	session := New(m.debug.Load(), m.speechService, m.options)
+	session.SetDeafened(m.deafened.Load())
+	session.SetIgnoredUsers(m.ignoredUsers)
+	session.SetCampaign(m.campaign)
+
+	// The callbacks are looked up on each call so later changes reach existing sessions
+	session.SetUserNameResolver(func(userID string) string {
+		m.mutex.RLock()
+		resolver := m.userNameResolver
+		m.mutex.RUnlock()
+
+		if resolver == nil {
+			return ""
+		}
+		return resolver(guildID, userID)
+	})
+	session.SetTranscriptionCallback(func(ssrc uint32, text string, confidence float64, delayed bool) {
+		m.mutex.RLock()
+		callback := m.transcriptionCallback
+		m.mutex.RUnlock()
+
+		if callback != nil {
+			callback(guildID, ssrc, text, confidence, delayed)
+		}
+	})
+	session.SetErrorCallback(func(component string, err error) {
+		m.mutex.RLock()
+		callback := m.errorCallback
+		m.mutex.RUnlock()
+
+		if callback != nil {
+			callback(component, fmt.Errorf("guild %s: %w", guildID, err))
+		}
+	})
+	session.SetSpeakerCallback(func(ssrc uint32, userID string) {
+		m.mutex.RLock()
+		callback := m.speakerCallback
+		m.mutex.RUnlock()
+
+		if callback != nil {
+			callback(guildID)
+		}
+	})
+
+	return session
+}
+
+// StopProcessing stops the processing session in a guild, if there is one
+func (m *Manager) StopProcessing(guildID string) {
+	if session, ok := m.Session(guildID); ok {
+		session.StopProcessing()
+	}
+}
+
+// WaitForTranscriptions waits, up to timeout, for a guild's stopped session to deliver the
+// transcriptions still queued when it stopped, and reports whether they all were
+func (m *Manager) WaitForTranscriptions(guildID string, timeout time.Duration) bool {
+	session, ok := m.Session(guildID)
+	return !ok || session.WaitForTranscriptions(timeout)
+}
+
+// StopAll stops every processing session
+func (m *Manager) StopAll() {
+	for _, session := range m.allSessions() {
+		session.StopProcessing()
+	}
+}
+
+// Session returns the processing session for a guild, which may be stopped
+func (m *Manager) Session(guildID string) (*Processor, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	session, ok := m.sessions[guildID]
+	return session, ok
+}
+
+// allSessions returns every session, active or stopped
+func (m *Manager) allSessions() []*Processor {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	sessions := make([]*Processor, 0, len(m.sessions))
+	for _, session := range m.sessions {
+		sessions = append(sessions, session)
+	}
+	return sessions
+}
+
+// ActiveGuilds returns the guilds with an active processing session, sorted by ID
+func (m *Manager) ActiveGuilds() []string {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	var guilds []string
+	for guildID, session := range m.sessions {
+		if session.IsProcessing() {
+			guilds = append(guilds, guildID)
+		}
+	}
+	slices.Sort(guilds)
+	return guilds
+}
+
+// IsProcessing returns whether audio processing is active in any guild
+func (m *Manager) IsProcessing() bool {
+	return len(m.ActiveGuilds()) > 0
+}
+
+// IsProcessingGuild returns whether audio processing is active in the guild
+func (m *Manager) IsProcessingGuild(guildID string) bool {
+	session, ok := m.Session(guildID)
+	return ok && session.IsProcessing()
+}
+
+// SetDebug enables or disables debug logging for every session
+func (m *Manager) SetDebug(debug bool) {
+	m.debug.Store(debug)
+	for _, session := range m.allSessions() {
+		session.SetDebug(debug)
+	}
+}
+
+// SetDeafened stops or resumes capturing audio in every session
+func (m *Manager) SetDeafened(deafened bool) {
+	m.deafened.Store(deafened)
+	for _, session := range m.allSessions() {
+		session.SetDeafened(deafened)
+	}
+}
+
+// IsDeafened returns whether audio capture is paused by SetDeafened
+func (m *Manager) IsDeafened() bool {
+	return m.deafened.Load()
+}
+
+// SetIgnoredUsers replaces the users whose audio is never transcribed in every session
+func (m *Manager) SetIgnoredUsers(userIDs []string) {
+	m.mutex.Lock()
+	m.ignoredUsers = slices.Clone(userIDs)
+	m.mutex.Unlock()
+
+	for _, session := range m.allSessions() {
+		session.SetIgnoredUsers(userIDs)
+	}
+}
+
+// SetCampaign sets the campaign name used to prefix the files of every session
+func (m *Manager) SetCampaign(name string) {
+	m.mutex.Lock()
+	m.campaign = name
+	m.mutex.Unlock()
+
+	for _, session := range m.allSessions() {
+		session.SetCampaign(name)
+	}
+}
+
+// SetUserNameResolver sets the function used to turn Discord user IDs into display names
+func (m *Manager) SetUserNameResolver(resolver func(guildID, userID string) string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.userNameResolver = resolver
+}
+
+// SetTranscriptionCallback sets the callback function for transcription results from any session.
+// Delayed results were spooled to disk when a transcription queue was full.
+func (m *Manager) SetTranscriptionCallback(callback func(guildID string, ssrc uint32, text string, confidence float64, delayed bool)) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.transcriptionCallback = callback
+}
+
+// SetSpeakerCallback sets the callback function for the speakers heard in a guild's session changing
+func (m *Manager) SetSpeakerCallback(callback func(guildID string)) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.speakerCallback = callback
+}
+
+// SetErrorCallback sets the callback function for recording and transcription failures from any session
+func (m *Manager) SetErrorCallback(callback func(component string, err error)) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.errorCallback = callback
+}
+
+// UserForSSRC returns the Discord user ID speaking on an SSRC in a guild's session, if known
+func (m *Manager) UserForSSRC(guildID string, ssrc uint32) (string, bool) {
+	session, ok := m.Session(guildID)
+	if !ok {
+		return "", false
+	}
+	return session.UserForSSRC(ssrc)
+}
+
+// SetSSRCUser records which user is speaking on an SSRC in a guild's session
+func (m *Manager) SetSSRCUser(guildID string, ssrc uint32, userID string) error {
+	session, ok := m.Session(guildID)
+	if !ok || !session.IsProcessing() {
+		return fmt.Errorf("no active voice session in guild %s", guildID)
+	}
+	session.SetSSRCUser(ssrc, userID)
+	return nil
+}
+
+// FlushUser sends a user's buffered audio in a guild's session for transcription now
+func (m *Manager) FlushUser(guildID, userID string) (<-chan struct{}, error) {
+	session, ok := m.Session(guildID)
+	if !ok || !session.IsProcessing() {
+		return nil, fmt.Errorf("no active voice session in guild %s", guildID)
+	}
+	return session.FlushUser(userID)
+}
+
+// TestTranscription records and transcribes a user's audio in a guild's session, as a
+// microphone test
+func (m *Manager) TestTranscription(guildID, userID string, duration time.Duration) (*speech.TranscriptionResult, int, error) {
+	session, ok := m.Session(guildID)
+	if !ok || !session.IsProcessing() {
+		return nil, 0, fmt.Errorf("no active voice session in guild %s", guildID)
+	}
+	return session.TestTranscription(userID, duration)
+}
+
+// GetStats returns the session and cumulative counters summed across all guilds
+func (m *Manager) GetStats() (session Stats, cumulative Stats) {
+	for _, p := range m.allSessions() {
+		s, c := p.GetStats()
+		session = session.add(s)
+		cumulative = cumulative.add(c)
+	}
+	return session, cumulative
+}
+
+// ResetStats clears the counters of every session
+func (m *Manager) ResetStats() {
+	for _, session := range m.allSessions() {
+		session.ResetStats()
+	}
+}
+
+// PacketLoss returns the packets lost for each SSRC in the current sessions of all guilds
+func (m *Manager) PacketLoss() map[uint32]int64 {
+	loss := make(map[uint32]int64)
+	for _, session := range m.allSessions() {
+		for ssrc, count := range session.PacketLoss() {
+			loss[ssrc] += count
+		}
+	}
+	return loss
+}
+
+// RecordingsForUser returns the user's recordings from the current or last session of every guild
+func (m *Manager) RecordingsForUser(userID string) []Recording {
+	var recordings []Recording
+	for _, session := range m.allSessions() {
+		recordings = append(recordings, session.RecordingsForUser(userID)...)
+	}
+	return recordings
+}
+
+// LastFailure returns the most recent failed transcription in any guild, if there has been one
+func (m *Manager) LastFailure() (TranscriptionFailure, bool) {
+	var latest TranscriptionFailure
+	found := false
+	for _, session := range m.allSessions() {
+		if failure, ok := session.LastFailure(); ok && (!found || failure.Time.After(latest.Time)) {
+			latest = failure
+			found = true
+		}
+	}
+	return latest, found
+}
+
+// ExportTurns returns the speaking turns of the current or last session of every guild that
+// transcribed anything, sorted by guild ID
+func (m *Manager) ExportTurns() []TurnTranscript {
+	var transcripts []TurnTranscript
+	for _, session := range m.allSessions() {
+		if transcript, ok := session.ExportTurns(); ok {
+			transcripts = append(transcripts, transcript)
+		}
+	}
+	slices.SortFunc(transcripts, func(a, b TurnTranscript) int {
+		return strings.Compare(a.GuildID, b.GuildID)
+	})
+	return transcripts
+}
+
+// ExportSubtitles renders the subtitles of the current or last session of every guild
+func (m *Manager) ExportSubtitles(format string) ([]SubtitleFile, error) {
+	if err := checkSubtitleFormat(format); err != nil {
+		return nil, err
+	}
+
+	var files []SubtitleFile
+	for _, session := range m.allSessions() {
+		sessionFiles, err := session.ExportSubtitles(format)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, sessionFiles...)
+	}
+	return files, nil
+}
@@ -0,0 +1,85 @@
+package audio
+
+import (
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// guildConnection returns a fake voice connection in a guild
+func guildConnection(guildID string) *discordgo.VoiceConnection {
+	return &discordgo.VoiceConnection{GuildID: guildID, ChannelID: "table", OpusRecv: make(chan *discordgo.Packet)}
+}
+
+func TestSessionsStartAndStopIndependently(t *testing.T) {
+	clock := newFakeClock()
+	m := NewManager(false, &fakeTranscriber{}, Options{Ephemeral: true, Clock: clock})
+	defer m.StopAll()
+
+	var mutex sync.Mutex
+	delivered := make(map[string][]string)
+	m.SetTranscriptionCallback(func(guildID string, ssrc uint32, text string, confidence float64, delayed bool) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		delivered[guildID] = append(delivered[guildID], text)
+	})
+
+	first, second := guildConnection("guild1"), guildConnection("guild2")
+	if err := m.StartProcessing(first, true); err != nil {
+		t.Fatalf("starting guild1: %v", err)
+	}
+	if err := m.StartProcessing(second, true); err != nil {
+		t.Fatalf("starting guild2: %v", err)
+	}
+	if got := m.ActiveGuilds(); !slices.Equal(got, []string{"guild1", "guild2"}) {
+		t.Fatalf("active guilds = %v, want both", got)
+	}
+	if err := m.StartProcessing(first, true); err == nil {
+		t.Error("starting a running session again should fail")
+	}
+
+	// Stopping one guild leaves the other listening
+	m.StopProcessing("guild2")
+	if m.IsProcessingGuild("guild2") || !m.IsProcessingGuild("guild1") {
+		t.Fatalf("after stopping guild2: guild1 processing = %v, guild2 processing = %v",
+			m.IsProcessingGuild("guild1"), m.IsProcessingGuild("guild2"))
+	}
+	for sequence := uint16(1); sequence <= 20; sequence++ {
+		first.OpusRecv <- speechPacket(1, sequence)
+	}
+
+	// And the stopped one can start again without touching the first
+	if err := m.StartProcessing(second, true); err != nil {
+		t.Fatalf("restarting guild2: %v", err)
+	}
+
+	// Keep the clock moving until guild1's pause is noticed and transcribed
+	waitFor(t, "guild1's transcription", func() bool {
+		clock.advance(silenceThreshold)
+		mutex.Lock()
+		defer mutex.Unlock()
+		return len(delivered["guild1"]) > 0
+	})
+	m.StopProcessing("guild1")
+	if got := m.ActiveGuilds(); !slices.Equal(got, []string{"guild2"}) {
+		t.Errorf("active guilds = %v, want guild2", got)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(delivered["guild1"]) != 1 || len(delivered["guild2"]) != 0 {
+		t.Errorf("delivered %v, want one transcription for guild1 only", delivered)
+	}
+
+	// Each session keeps its own stats
+	firstSession, _ := m.Session("guild1")
+	secondSession, _ := m.Session("guild2")
+	if session, _ := firstSession.GetStats(); session.PacketsReceived != 20 {
+		t.Errorf("guild1 received %d packets, want 20", session.PacketsReceived)
+	}
+	if session, _ := secondSession.GetStats(); session.PacketsReceived != 0 {
+		t.Errorf("guild2 received %d packets, want 0", session.PacketsReceived)
+	}
+}
@@ -0,0 +1,80 @@
+package audio
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"dnd_dm_assistant_go/internal/speech"
+
+	"github.com/pion/rtp"
+)
+
+// ErrNothingCaptured is returned by a microphone test that heard nothing from the user
+var ErrNothingCaptured = errors.New("no audio received from the user")
+
+// TestTranscription records the user's audio for the given duration and transcribes it on
+// its own, outside the normal buffers, to check the whole capture and transcription path.
+// It returns the result and how many packets were captured.
+func (p *Processor) TestTranscription(userID string, duration time.Duration) (*speech.TranscriptionResult, int, error) {
+	p.mutex.RLock()
+	processing, canTranscribe := p.isProcessing, p.canTranscribe()
+	p.mutex.RUnlock()
+	if !processing {
+		return nil, 0, errors.New("audio processing is not running")
+	}
+	if !canTranscribe {
+		return nil, 0, errors.New("speech-to-text is disabled")
+	}
+
+	p.testMutex.Lock()
+	if p.testCaptures == nil {
+		p.testCaptures = make(map[string][]*rtp.Packet)
+	}
+	if _, running := p.testCaptures[userID]; running {
+		p.testMutex.Unlock()
+		return nil, 0, errors.New("a test is already running for this user")
+	}
+	p.testCaptures[userID] = []*rtp.Packet{}
+	p.testMutex.Unlock()
+
+	time.Sleep(duration)
+
+	p.testMutex.Lock()
+	packets := p.testCaptures[userID]
+	delete(p.testCaptures, userID)
+	p.testMutex.Unlock()
+
+	if len(packets) == 0 {
+		return nil, 0, ErrNothingCaptured
+	}
+
+	ssrc := packets[0].SSRC
+	data, err := p.encodeTranscriptionAudio(ssrc, packets)
+	if err != nil {
+		return nil, len(packets), fmt.Errorf("failed to prepare the audio: %w", err)
+	}
+	result, err := p.speechService.RecognizeAudio(data)
+	if err != nil {
+		return nil, len(packets), err
+	}
+	return result, len(packets), nil
+}
+
+// captureTestPacket adds a packet to the microphone test of the user speaking on its SSRC,
+// if one is running. The caller must hold the mutex.
+func (p *Processor) captureTestPacket(packet *rtp.Packet) {
+	p.testMutex.Lock()
+	defer p.testMutex.Unlock()
+	if len(p.testCaptures) == 0 {
+		return
+	}
+
+	userID, ok := p.ssrcUsers[packet.SSRC]
+	if !ok {
+		return
+	}
+	if packets, running := p.testCaptures[userID]; running {
+		p.testCaptures[userID] = append(packets, packet)
+	}
+}
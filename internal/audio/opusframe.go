@@ -0,0 +1,156 @@
+package audio
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// Longest single Opus frame allowed by RFC 6716
+	maxOpusFrameBytes = 1275
+
+	// Longest audio a single Opus packet can carry
+	maxOpusPacketDuration = 120 * time.Millisecond
+)
+
+// checkOpusPacket applies the packet structure rules from RFC 6716 section 3.4 to an Opus
+// payload, so obviously corrupt packets can be dropped before they reach a recording.
+// It only checks framing; it doesn't decode the audio.
+func checkOpusPacket(payload []byte) error {
+	if len(payload) == 0 {
+		return errors.New("empty packet")
+	}
+
+	toc := payload[0]
+	frameDuration := opusFrameDuration(toc >> 3)
+	data := payload[1:]
+
+	switch toc & 0x3 {
+	case 0: // One frame
+		if len(data) > maxOpusFrameBytes {
+			return fmt.Errorf("frame of %d bytes exceeds %d", len(data), maxOpusFrameBytes)
+		}
+
+	case 1: // Two frames of equal size
+		if len(data)%2 != 0 {
+			return fmt.Errorf("odd length %d for two equal frames", len(data))
+		}
+		if len(data)/2 > maxOpusFrameBytes {
+			return fmt.Errorf("frames of %d bytes exceed %d", len(data)/2, maxOpusFrameBytes)
+		}
+
+	case 2: // Two frames of different sizes
+		first, n, err := opusFrameLength(data)
+		if err != nil {
+			return err
+		}
+		data = data[n:]
+		if first > len(data) {
+			return fmt.Errorf("first frame of %d bytes overruns the %d remaining", first, len(data))
+		}
+		if len(data)-first > maxOpusFrameBytes {
+			return fmt.Errorf("second frame of %d bytes exceeds %d", len(data)-first, maxOpusFrameBytes)
+		}
+
+	case 3: // Any number of frames
+		return checkOpusFrames(data, frameDuration)
+	}
+
+	return nil
+}
+
+// checkOpusFrames checks a code 3 packet, whose payload after the TOC byte starts with a
+// frame count byte and is followed by optional padding and frame lengths
+func checkOpusFrames(data []byte, frameDuration time.Duration) error {
+	if len(data) == 0 {
+		return errors.New("missing frame count")
+	}
+
+	header := data[0]
+	data = data[1:]
+	vbr := header&0x80 != 0
+	padded := header&0x40 != 0
+	count := int(header & 0x3f)
+
+	if count == 0 {
+		return errors.New("zero frames")
+	}
+	if time.Duration(count)*frameDuration > maxOpusPacketDuration {
+		return fmt.Errorf("%d frames of %v exceed %v", count, frameDuration, maxOpusPacketDuration)
+	}
+
+	// Padding length is a run of bytes, each 255 adding 254 and continuing
+	padding := 0
+	for padded {
+		if len(data) == 0 {
+			return errors.New("truncated padding length")
+		}
+		b := data[0]
+		data = data[1:]
+		if b == 255 {
+			padding += 254
+		} else {
+			padding += int(b)
+			padded = false
+		}
+	}
+
+	if !vbr {
+		remaining := len(data) - padding
+		if remaining < 0 || remaining%count != 0 {
+			return fmt.Errorf("%d bytes can't be split into %d equal frames", remaining, count)
+		}
+		if remaining/count > maxOpusFrameBytes {
+			return fmt.Errorf("frames of %d bytes exceed %d", remaining/count, maxOpusFrameBytes)
+		}
+		return nil
+	}
+
+	// Every frame but the last has its length coded up front
+	total := 0
+	for range count - 1 {
+		length, n, err := opusFrameLength(data)
+		if err != nil {
+			return err
+		}
+		data = data[n:]
+		total += length
+	}
+
+	last := len(data) - padding - total
+	if last < 0 {
+		return fmt.Errorf("frame lengths total %d bytes but only %d remain", total, len(data)-padding)
+	}
+	if last > maxOpusFrameBytes {
+		return fmt.Errorf("last frame of %d bytes exceeds %d", last, maxOpusFrameBytes)
+	}
+	return nil
+}
+
+// opusFrameLength decodes a one or two byte frame length, returning the length and the
+// number of bytes it took
+func opusFrameLength(data []byte) (int, int, error) {
+	if len(data) == 0 {
+		return 0, 0, errors.New("truncated frame length")
+	}
+	if data[0] < 252 {
+		return int(data[0]), 1, nil
+	}
+	if len(data) < 2 {
+		return 0, 0, errors.New("truncated frame length")
+	}
+	return int(data[1])*4 + int(data[0]), 2, nil
+}
+
+// opusFrameDuration returns the frame duration for a TOC configuration number
+func opusFrameDuration(config byte) time.Duration {
+	switch {
+	case config < 12: // SILK: 10, 20, 40, 60ms
+		return []time.Duration{10, 20, 40, 60}[config%4] * time.Millisecond
+	case config < 16: // Hybrid: 10, 20ms
+		return []time.Duration{10, 20}[config%2] * time.Millisecond
+	default: // CELT: 2.5, 5, 10, 20ms
+		return []time.Duration{2500, 5000, 10000, 20000}[config%4] * time.Microsecond
+	}
+}
@@ -0,0 +1,82 @@
+package audio
+
+import "sync"
+
+// transcriptionOrder delivers each SSRC's transcription results in the order their batches were
+// queued. Batches are transcribed concurrently across workers (e.g. a stopped session's worker
+// still draining while a new session's worker starts), so results can finish out of order.
+type transcriptionOrder struct {
+	mutex sync.Mutex
+
+	// Next sequence number to assign and to deliver, per SSRC
+	nextQueued    map[uint32]uint64
+	nextDelivered map[uint32]uint64
+
+	// Finished results waiting for earlier ones, per SSRC and sequence number
+	pending map[uint32]map[uint64]func()
+
+	// SSRCs whose results are being delivered by some goroutine right now
+	delivering map[uint32]bool
+}
+
+// newTranscriptionOrder creates an empty ordering tracker
+func newTranscriptionOrder() *transcriptionOrder {
+	return &transcriptionOrder{
+		nextQueued:    make(map[uint32]uint64),
+		nextDelivered: make(map[uint32]uint64),
+		pending:       make(map[uint32]map[uint64]func()),
+		delivering:    make(map[uint32]bool),
+	}
+}
+
+// enqueue assigns the next sequence number to a batch and passes it to send. The number is
+// used up only if send reports the batch was queued, so dropped batches leave no gap.
+func (o *transcriptionOrder) enqueue(ssrc uint32, send func(seq uint64) bool) bool {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	seq := o.nextQueued[ssrc]
+	if !send(seq) {
+		return false
+	}
+	o.nextQueued[ssrc] = seq + 1
+	return true
+}
+
+// complete records that a batch finished and runs deliver once every earlier batch for the
+// SSRC has been delivered. Failed batches must complete too (with a nil deliver) so later
+// results aren't held back. Deliveries for one SSRC never run concurrently.
+func (o *transcriptionOrder) complete(ssrc uint32, seq uint64, deliver func()) {
+	if deliver == nil {
+		deliver = func() {}
+	}
+
+	o.mutex.Lock()
+	if o.pending[ssrc] == nil {
+		o.pending[ssrc] = make(map[uint64]func())
+	}
+	o.pending[ssrc][seq] = deliver
+
+	// Another goroutine is already delivering this SSRC's results and will pick this one up
+	if o.delivering[ssrc] {
+		o.mutex.Unlock()
+		return
+	}
+	o.delivering[ssrc] = true
+
+	for {
+		next, ready := o.pending[ssrc][o.nextDelivered[ssrc]]
+		if !ready {
+			o.delivering[ssrc] = false
+			o.mutex.Unlock()
+			return
+		}
+		delete(o.pending[ssrc], o.nextDelivered[ssrc])
+		o.nextDelivered[ssrc]++
+
+		// Callbacks can be slow (e.g. waiting on Claude), so don't block other SSRCs on them
+		o.mutex.Unlock()
+		next()
+		o.mutex.Lock()
+	}
+}
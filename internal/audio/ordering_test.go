@@ -0,0 +1,155 @@
+package audio
+
+import (
+	"slices"
+	"sync"
+	"testing"
+
+	"dnd_dm_assistant_go/internal/speech"
+)
+
+// queueBatches enqueues n batches for an SSRC and returns their sequence numbers
+func queueBatches(t *testing.T, o *transcriptionOrder, ssrc uint32, n int) []uint64 {
+	t.Helper()
+	var seqs []uint64
+	for range n {
+		o.enqueue(ssrc, func(seq uint64) bool {
+			seqs = append(seqs, seq)
+			return true
+		})
+	}
+	return seqs
+}
+
+func TestOrderDeliversInQueueOrder(t *testing.T) {
+	o := newTranscriptionOrder()
+	seqs := queueBatches(t, o, 1, 5)
+
+	// Finish them newest first
+	var delivered []uint64
+	for _, seq := range slices.Backward(seqs) {
+		o.complete(1, seq, func() { delivered = append(delivered, seq) })
+	}
+
+	if !slices.Equal(delivered, seqs) {
+		t.Errorf("delivered %v, want %v", delivered, seqs)
+	}
+}
+
+func TestOrderDeliversConcurrentCompletionsInOrder(t *testing.T) {
+	o := newTranscriptionOrder()
+	seqs := queueBatches(t, o, 1, 50)
+
+	var mutex sync.Mutex
+	var delivered []uint64
+	var workers sync.WaitGroup
+	for _, seq := range seqs {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			o.complete(1, seq, func() {
+				mutex.Lock()
+				defer mutex.Unlock()
+				delivered = append(delivered, seq)
+			})
+		}()
+	}
+	workers.Wait()
+
+	if !slices.Equal(delivered, seqs) {
+		t.Errorf("delivered %v, want %v", delivered, seqs)
+	}
+}
+
+func TestOrderFailedBatchDoesntHoldBackLaterOnes(t *testing.T) {
+	o := newTranscriptionOrder()
+	seqs := queueBatches(t, o, 1, 3)
+
+	var delivered []uint64
+	o.complete(1, seqs[2], func() { delivered = append(delivered, seqs[2]) })
+	o.complete(1, seqs[0], func() { delivered = append(delivered, seqs[0]) })
+	if !slices.Equal(delivered, []uint64{seqs[0]}) {
+		t.Fatalf("delivered %v before the middle batch finished, want only the first", delivered)
+	}
+
+	// The middle batch failed; the last result can go out now
+	o.complete(1, seqs[1], nil)
+	if !slices.Equal(delivered, []uint64{seqs[0], seqs[2]}) {
+		t.Errorf("delivered %v, want the first and last", delivered)
+	}
+}
+
+func TestOrderDroppedBatchLeavesNoGap(t *testing.T) {
+	o := newTranscriptionOrder()
+	first := queueBatches(t, o, 1, 1)
+	o.enqueue(1, func(uint64) bool { return false })
+	second := queueBatches(t, o, 1, 1)
+
+	var delivered []uint64
+	o.complete(1, first[0], func() { delivered = append(delivered, first[0]) })
+	o.complete(1, second[0], func() { delivered = append(delivered, second[0]) })
+	if len(delivered) != 2 {
+		t.Errorf("delivered %v, want both queued batches", delivered)
+	}
+}
+
+func TestOrderSSRCsAreIndependent(t *testing.T) {
+	o := newTranscriptionOrder()
+	alice := queueBatches(t, o, 1, 2)
+	bob := queueBatches(t, o, 2, 1)
+
+	// Alice's first batch is still being transcribed; Bob's result mustn't wait for it
+	delivered := false
+	o.complete(1, alice[1], func() {})
+	o.complete(2, bob[0], func() { delivered = true })
+	if !delivered {
+		t.Error("one SSRC's result waited for another's")
+	}
+}
+
+func TestTranscriptionsDeliveredInOrderAcrossWorkers(t *testing.T) {
+	release := make(chan struct{})
+	fake := &fakeTranscriber{}
+	fake.recognize = func([]byte) (*speech.TranscriptionResult, error) {
+		if fake.calls() == 1 {
+			<-release
+			return &speech.TranscriptionResult{Transcript: "first"}, nil
+		}
+		return &speech.TranscriptionResult{Transcript: "second"}, nil
+	}
+	p, vc := newTestSession(t, fake, Options{Clock: newFakeClock()})
+	deliveries := make(chan string, 2)
+	p.SetTranscriptionCallback(func(ssrc uint32, text string, confidence float64, delayed bool) {
+		deliveries <- text
+	})
+
+	// The first session's worker is left transcribing the first utterance
+	for sequence := uint16(1); sequence <= 20; sequence++ {
+		vc.OpusRecv <- speechPacket(1, sequence)
+	}
+	p.StopProcessing()
+	waitFor(t, "the first batch to be taken", func() bool { return fake.calls() == 1 })
+
+	// A new session's worker finishes the next utterance first
+	vc = newTestConnection()
+	if err := p.StartProcessing(vc); err != nil {
+		t.Fatalf("restarting: %v", err)
+	}
+	for sequence := uint16(21); sequence <= 40; sequence++ {
+		vc.OpusRecv <- speechPacket(1, sequence)
+	}
+	p.StopProcessing()
+	waitFor(t, "the second batch to be transcribed", func() bool { return fake.calls() == 2 })
+	select {
+	case text := <-deliveries:
+		t.Fatalf("%q was delivered before the first utterance", text)
+	default:
+	}
+
+	close(release)
+	for _, want := range []string{"first", "second"} {
+		if got := <-deliveries; got != want {
+			t.Errorf("delivered %q, want %q", got, want)
+		}
+	}
+}
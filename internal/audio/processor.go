@@ -5,27 +5,111 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"dnd_dm_assistant_go/internal/clock"
 	"dnd_dm_assistant_go/internal/speech"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/pion/opus"
 	"github.com/pion/rtp"
 	"github.com/pion/webrtc/v3/pkg/media/oggwriter"
 )
 
+// Options holds optional audio processing settings
+type Options struct {
+	// Insert silence frames for dropped packets to keep the OGG timeline consistent
+	FillPacketGaps bool
+
+	// Never write audio to disk; transcription happens entirely in memory
+	Ephemeral bool
+
+	// Directory for recordings, speaker maps and debug files (defaults to the working directory)
+	Dir string
+
+	// Source of the current time (defaults to the system clock)
+	Clock clock.Clock
+
+	// Audio from before the start of an utterance to include in its transcription batch (0 = none).
+	// Only frames held back by the voice gate come before an utterance, so this needs VoiceGateDB.
+	LeadIn time.Duration
+
+	// Treat frames quieter than this level (dBFS, e.g. -50) as silence (0 = disabled). Only
+	// frames the decoder understands are measured; see belowVoiceGate.
+	VoiceGateDB float64
+
+	// Transcribe a buffer once it's this old even if the speaker hasn't paused (0 = wait for silence)
+	MaxBufferAge time.Duration
+
+	// Still write recordings for users whose audio is ignored for transcription
+	RecordIgnoredUsers bool
+
+	// Sample rate and channel count declared in recording headers (0 = Discord's 48kHz stereo).
+	// A single channel makes players downmix Discord's stereo Opus to mono when decoding.
+	RecordingSampleRate uint32
+	RecordingChannels   uint16
+
+	// Drop packets whose Opus framing is invalid instead of writing them to recordings
+	ValidateOpus bool
+
+	// Split buffers longer than this at pauses and transcribe the pieces in parallel, at most
+	// SegmentConcurrency at a time (0 = always transcribe a buffer in one request)
+	SegmentLength      time.Duration
+	SegmentConcurrency int
+
+	// Save batches that don't fit in a full transcription queue here and transcribe them later,
+	// keeping at most SpoolMaxBytes on disk (empty = drop them). Each guild gets a subdirectory.
+	SpoolDir      string
+	SpoolMaxBytes int64
+
+	// Send the speech backend 16kHz mono PCM instead of the original 48kHz stereo Opus when
+	// a batch can be decoded. Recordings keep the original audio.
+	ResampleTranscription bool
+
+	// Discard buffers with fewer packets than this instead of transcribing them; a cough or a
+	// single syllable is almost never worth a request (0 = transcribe everything)
+	MinPackets int
+
+	// Drop transcriptions with fewer words than this. Empty or whitespace-only ones are always dropped.
+	MinWords int
+}
+
 // New creates a new audio processor
-func New(debug bool, speechService *speech.Service) *Processor {
+func New(debug bool, speechService speech.Transcriber, opts Options) *Processor {
+	if opts.Clock == nil {
+		opts.Clock = clock.Real{}
+	}
+
 	processor := &Processor{
-		debug:              debug,
 		speechService:      speechService,
+		options:            opts,
+		speechEnabled:      true,
 		isProcessing:       false,
 		oggFiles:           make(map[uint32]*oggwriter.OggWriter),
 		audioBuffers:       make(map[uint32][]*rtp.Packet),
-		transcriptionChans: make(map[uint32]chan []*rtp.Packet),
+		preBuffers:         make(map[uint32][]*rtp.Packet),
+		decoders:           make(map[uint32]*opus.Decoder),
+		bufferStarts:       make(map[uint32]time.Time),
+		transcriptionChans: make(map[uint32]chan audioBatch),
+		workers:            &sync.WaitGroup{},
+		stoppedWorkers:     &sync.WaitGroup{},
 		oggFilePaths:       make(map[uint32]string),
 		lastPacketTime:     make(map[uint32]time.Time),
+		lastReceived:       make(map[uint32]time.Time),
+		packetRates:        make(map[uint32]*packetCounter),
+		lastSequence:       make(map[uint32]uint16),
+		lastTimestamp:      make(map[uint32]uint32),
+		packetsLost:        make(map[uint32]int64),
+		ssrcUsers:          make(map[uint32]string),
+		subtitleCues:       make(map[uint32][]Cue),
+		order:              newTranscriptionOrder(),
+		spoolInFlight:      make(map[uint32]bool),
+		spoolFailures:      make(map[string]int),
+		spoolWrites:        make(chan spoolWrite, spoolWriteQueueSize),
 		// Initialize debug counters
 		packetsReceived:   0,
 		silenceDetections: 0,
@@ -33,6 +117,8 @@ func New(debug bool, speechService *speech.Service) *Processor {
 		totalBytesWritten: 0,
 	}
 
+	processor.debug.Store(debug)
+
 	if debug {
 		log.Printf("[AUDIO] Created new audio processor")
 		if speechService != nil {
@@ -54,32 +140,66 @@ const (
 
 	// Audio processing constants
 	opusPacketDurationMs = 20              // Each Opus packet is typically 20ms
-	silenceThreshold     = 2 * time.Second // Send to Google after 2 seconds of silence
+	silenceThreshold     = 2 * time.Second // Transcribe after 2 seconds of silence
 
 	// Discord audio format
 	discordSampleRate = 48000
 	discordChannels   = 2
 	discordFrameSize  = 960 // 20ms at 48kHz
+
+	// Sequence numbers further ahead than this are treated as late (reordered) packets
+	maxSequenceJump = 1 << 15
+
+	// Never insert more than this many silence frames for a single gap (1 second)
+	maxGapFillPackets = 50
 )
 
 // Processor handles audio processing from Discord voice channels
 type Processor struct {
-	debug         bool
-	speechService *speech.Service
+	debug         atomic.Bool
+	speechService speech.Transcriber
+	speechEnabled bool        // Whether transcription is enabled for the current guild
+	deafened      atomic.Bool // Incoming audio is ignored while deafened
+	options       Options
 	isProcessing  bool
-	mutex         sync.RWMutex
+	stopped       chan struct{}  // Closed by StopProcessing to end the session's goroutines
+	loops         sync.WaitGroup // The session's packet loop, silence detector and spool worker
+	lifecycle     sync.Mutex     // Serializes StartProcessing and StopProcessing
+
+	// Guards the fields below. The packet loop and silence detector hold it while they
+	// touch the per-SSRC buffers and counters, so readers may take it to see them.
+	mutex sync.RWMutex
 
 	// Voice connection
 	voiceConnection *discordgo.VoiceConnection
 
+	// Connection onSpeakingUpdate is registered on. discordgo can't remove voice handlers, and
+	// keeps the connection across restarts and channel moves, so it's registered only once.
+	speakingConnection *discordgo.VoiceConnection
+
 	// OGG files for each user (keyed by SSRC) - persistent storage
 	oggFiles map[uint32]*oggwriter.OggWriter
 
 	// Raw audio packet buffers for each SSRC (for transcription)
 	audioBuffers map[uint32][]*rtp.Packet
 
+	// Most recent packets from each SSRC that weren't buffered (e.g. quiet frames below the voice
+	// gate), replayed as the lead-in of the next batch
+	preBuffers map[uint32][]*rtp.Packet
+
+	// Opus decoders for each SSRC, used by the voice gate
+	decoders map[uint32]*opus.Decoder
+
+	// When each SSRC's buffered utterance started, for aligning transcriptions to the session
+	bufferStarts map[uint32]time.Time
+
 	// Channels for sending audio to transcription goroutines
-	transcriptionChans map[uint32]chan []*rtp.Packet
+	transcriptionChans map[uint32]chan audioBatch
+
+	// The current session's transcription workers, and those of the last stopped session,
+	// which finish the batches still queued after it stopped
+	workers        *sync.WaitGroup
+	stoppedWorkers *sync.WaitGroup
 
 	// File paths for each SSRC's OGG file
 	oggFilePaths map[uint32]string
@@ -87,14 +207,129 @@ type Processor struct {
 	// Last packet time for each user (keyed by SSRC) - for silence detection
 	lastPacketTime map[uint32]time.Time
 
+	// Last time any packet arrived from each SSRC, including silence and quiet frames
+	lastReceived map[uint32]time.Time
+
+	// Recent packets from each SSRC, for the live packet rate
+	packetRates map[uint32]*packetCounter
+
+	// Packets captured for running microphone tests, keyed by user ID
+	testCaptures map[string][]*rtp.Packet
+	testMutex    sync.Mutex
+
+	// Last RTP sequence number and timestamp for each SSRC - for gap detection
+	lastSequence  map[uint32]uint16
+	lastTimestamp map[uint32]uint32
+
+	// Packets detected as lost for each SSRC
+	packetsLost map[uint32]int64
+
+	// Discord user ID for each SSRC, learned from speaking updates
+	ssrcUsers map[uint32]string
+
+	// Users whose audio is never buffered for transcription
+	ignoredUsers map[string]bool
+
+	// Turns user IDs into display names for recording filenames
+	userNameResolver func(userID string) string
+
+	// Campaign name that prefixes the files written for the session
+	campaign string
+
+	// When the current session started, used to name the session's speaker map
+	sessionStart time.Time
+
+	// Recording paths from the last finished session, keyed by user ID
+	lastSessionRecordings map[string][]string
+
+	// Timed transcriptions for each SSRC in the current or last session, for subtitle export
+	subtitleCues map[uint32][]Cue
+
+	// Utterances from every SSRC in the current or last session, for transcript export,
+	// and where that session took place
+	turns            []turn
+	sessionGuildID   string
+	sessionChannelID string
+
 	// Callback for transcription results
-	transcriptionCallback func(ssrc uint32, text string, confidence float64)
+	transcriptionCallback func(ssrc uint32, text string, confidence float64, delayed bool)
+
+	// Callback for recording and transcription failures
+	errorCallback func(component string, err error)
+
+	// Callback for an SSRC being linked to a different user
+	speakerCallback func(ssrc uint32, userID string)
+
+	// Most recent audio that failed to transcribe, kept across sessions
+	lastFailure *TranscriptionFailure
 
-	// Debug counters
+	// Keeps each SSRC's transcription results in the order the audio was spoken.
+	// It spans sessions, since a stopped session's worker may still be finishing.
+	order *transcriptionOrder
+
+	// SSRCs with a spooled batch being transcribed, and how often each spool file has failed.
+	// Like order, these span sessions.
+	spoolInFlight map[uint32]bool
+	spoolFailures map[string]int
+
+	// Batches spooled on the packet path, written to disk by the spool worker
+	spoolWrites chan spoolWrite
+
+	// Debug counters for the current session
 	packetsReceived   int64
 	silenceDetections int64
 	audioSegments     int64
 	totalBytesWritten int64
+	packetsReordered  int64
+	packetsMalformed  int64
+	framesGated       int64
+	buffersTooShort   int64
+	transcriptsEmpty  int64
+
+	// Counters accumulated from previous sessions since the last reset
+	previousSessions Stats
+}
+
+// audioBatch is one utterance's packets, sent to a transcription worker
+type audioBatch struct {
+	packets []*rtp.Packet
+	start   time.Time // When the first packet (including lead-in) was captured
+	seq     uint64    // Position among the SSRC's batches, for delivering results in order
+	delayed bool      // Spooled to disk and transcribed late, possibly after later speech
+
+	// The spool file the batch was read from, removed once the batch is transcribed
+	spoolPath string
+
+	// Called once the batch's result has been delivered (or it failed), if set
+	delivered func()
+}
+
+// Stats holds audio processing counters
+type Stats struct {
+	PacketsReceived   int64
+	SilenceDetections int64
+	AudioSegments     int64
+	TotalBytesWritten int64
+	PacketsLost       int64
+	PacketsReordered  int64
+	PacketsMalformed  int64
+	BuffersTooShort   int64 // Buffers discarded for having fewer than MinPackets packets
+	TranscriptsEmpty  int64 // Transcriptions dropped for being blank or shorter than MinWords
+}
+
+// add returns the sum of two sets of counters
+func (s Stats) add(other Stats) Stats {
+	return Stats{
+		PacketsReceived:   s.PacketsReceived + other.PacketsReceived,
+		SilenceDetections: s.SilenceDetections + other.SilenceDetections,
+		AudioSegments:     s.AudioSegments + other.AudioSegments,
+		TotalBytesWritten: s.TotalBytesWritten + other.TotalBytesWritten,
+		PacketsLost:       s.PacketsLost + other.PacketsLost,
+		PacketsReordered:  s.PacketsReordered + other.PacketsReordered,
+		PacketsMalformed:  s.PacketsMalformed + other.PacketsMalformed,
+		BuffersTooShort:   s.BuffersTooShort + other.BuffersTooShort,
+		TranscriptsEmpty:  s.TranscriptsEmpty + other.TranscriptsEmpty,
+	}
 }
 
 // IsProcessing returns whether audio processing is active
@@ -104,8 +339,172 @@ func (p *Processor) IsProcessing() bool {
 	return p.isProcessing
 }
 
+// SetDebug enables or disables debug logging
+func (p *Processor) SetDebug(debug bool) {
+	p.debug.Store(debug)
+}
+
+// SetDeafened stops or resumes capturing audio without leaving the voice channel.
+// Speech buffered before deafening is discarded either way, so nothing stale is replayed on resume.
+func (p *Processor) SetDeafened(deafened bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.deafened.Store(deafened)
+
+	for ssrc := range p.audioBuffers {
+		p.audioBuffers[ssrc] = p.audioBuffers[ssrc][:0]
+	}
+	p.preBuffers = make(map[uint32][]*rtp.Packet)
+
+	// Sequence numbers jump while deafened; that isn't packet loss
+	p.lastSequence = make(map[uint32]uint16)
+	p.lastTimestamp = make(map[uint32]uint32)
+}
+
+// IsDeafened returns whether audio capture is paused by SetDeafened
+func (p *Processor) IsDeafened() bool {
+	return p.deafened.Load()
+}
+
+// SetSpeechEnabled enables or disables transcription without stopping audio capture
+func (p *Processor) SetSpeechEnabled(enabled bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.speechEnabled = enabled
+}
+
+// GuildID returns the guild of the active voice connection, or "" if not processing
+func (p *Processor) GuildID() string {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	if p.voiceConnection == nil {
+		return ""
+	}
+	return p.voiceConnection.GuildID
+}
+
+// ChannelID returns the channel of the active voice connection, or "" if not processing
+func (p *Processor) ChannelID() string {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	if p.voiceConnection == nil {
+		return ""
+	}
+	return p.voiceConnection.ChannelID
+}
+
+// GetStats returns the counters for the current session and cumulatively across all
+// sessions since the bot started or the stats were last reset
+func (p *Processor) GetStats() (session Stats, cumulative Stats) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	session = p.sessionStats()
+	return session, p.previousSessions.add(session)
+}
+
+// ResetStats clears both the current session and cumulative counters
+func (p *Processor) ResetStats() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.previousSessions = Stats{}
+	p.packetsReceived = 0
+	p.silenceDetections = 0
+	p.audioSegments = 0
+	p.totalBytesWritten = 0
+	p.packetsReordered = 0
+	p.packetsMalformed = 0
+	p.buffersTooShort = 0
+	p.transcriptsEmpty = 0
+	p.packetsLost = make(map[uint32]int64)
+}
+
+// PacketLoss returns the number of packets detected as lost for each SSRC in the current session
+func (p *Processor) PacketLoss() map[uint32]int64 {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	loss := make(map[uint32]int64, len(p.packetsLost))
+	for ssrc, count := range p.packetsLost {
+		loss[ssrc] = count
+	}
+	return loss
+}
+
+// sessionStats returns the counters for the current session
+func (p *Processor) sessionStats() Stats {
+	var lost int64
+	for _, count := range p.packetsLost {
+		lost += count
+	}
+
+	return Stats{
+		PacketsReceived:   p.packetsReceived,
+		SilenceDetections: p.silenceDetections,
+		AudioSegments:     p.audioSegments,
+		TotalBytesWritten: p.totalBytesWritten,
+		PacketsLost:       lost,
+		PacketsReordered:  p.packetsReordered,
+		PacketsMalformed:  p.packetsMalformed,
+		BuffersTooShort:   p.buffersTooShort,
+		TranscriptsEmpty:  p.transcriptsEmpty,
+	}
+}
+
+// UserForSSRC returns the Discord user ID speaking on the given SSRC, if known
+func (p *Processor) UserForSSRC(ssrc uint32) (string, bool) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	userID, ok := p.ssrcUsers[ssrc]
+	return userID, ok
+}
+
+// onSpeakingUpdate records which Discord user is behind each SSRC
+func (p *Processor) onSpeakingUpdate(vc *discordgo.VoiceConnection, vs *discordgo.VoiceSpeakingUpdate) {
+	p.SetSSRCUser(uint32(vs.SSRC), vs.UserID)
+}
+
+// SetSSRCUser records which user is speaking on an SSRC for the rest of the session. Discord
+// normally reports this; setting it by hand fills in SSRCs whose speaking update was missed.
+func (p *Processor) SetSSRCUser(ssrc uint32, userID string) {
+	p.mutex.Lock()
+	changed := p.ssrcUsers[ssrc] != userID
+	p.ssrcUsers[ssrc] = userID
+	_, hasFile := p.oggFilePaths[ssrc]
+	p.mutex.Unlock()
+
+	if !changed {
+		return
+	}
+
+	if p.debug.Load() {
+		log.Printf("[AUDIO] 👤 SSRC %d belongs to user %s", ssrc, userID)
+	}
+
+	p.mutex.RLock()
+	callback := p.speakerCallback
+	p.mutex.RUnlock()
+	if callback != nil {
+		callback(ssrc, userID)
+	}
+
+	// The recording may already exist under the SSRC; record who it belongs to
+	if hasFile {
+		p.writeSpeakerMap()
+	}
+}
+
+// canTranscribe returns whether buffered audio should be sent for transcription
+func (p *Processor) canTranscribe() bool {
+	return p.speechService != nil && p.speechEnabled
+}
+
 // StartProcessing starts processing audio from the voice connection
 func (p *Processor) StartProcessing(vc *discordgo.VoiceConnection) error {
+	p.lifecycle.Lock()
+	defer p.lifecycle.Unlock()
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
 
@@ -115,50 +514,103 @@ func (p *Processor) StartProcessing(vc *discordgo.VoiceConnection) error {
 
 	p.voiceConnection = vc
 	p.isProcessing = true
+	p.stopped = make(chan struct{})
 
-	// Reset debug counters
+	// Carry the previous session's counters into the cumulative totals, then reset
+	p.previousSessions = p.previousSessions.add(p.sessionStats())
 	p.packetsReceived = 0
 	p.silenceDetections = 0
 	p.audioSegments = 0
 	p.totalBytesWritten = 0
+	p.packetsReordered = 0
+	p.packetsMalformed = 0
+	p.framesGated = 0
+	p.buffersTooShort = 0
+	p.transcriptsEmpty = 0
 
 	// Initialize maps
 	p.oggFiles = make(map[uint32]*oggwriter.OggWriter)
 	p.audioBuffers = make(map[uint32][]*rtp.Packet)
-	p.transcriptionChans = make(map[uint32]chan []*rtp.Packet)
+	p.preBuffers = make(map[uint32][]*rtp.Packet)
+	p.decoders = make(map[uint32]*opus.Decoder)
+	p.bufferStarts = make(map[uint32]time.Time)
+	p.transcriptionChans = make(map[uint32]chan audioBatch)
+	p.workers = &sync.WaitGroup{}
 	p.oggFilePaths = make(map[uint32]string)
 	p.lastPacketTime = make(map[uint32]time.Time)
+	p.lastReceived = make(map[uint32]time.Time)
+	p.packetRates = make(map[uint32]*packetCounter)
+	p.lastSequence = make(map[uint32]uint16)
+	p.lastTimestamp = make(map[uint32]uint32)
+	p.packetsLost = make(map[uint32]int64)
+	p.subtitleCues = make(map[uint32][]Cue)
+	p.turns = nil
+	p.ssrcUsers = make(map[uint32]string)
+	p.sessionStart = p.options.Clock.Now()
+	p.sessionGuildID = vc.GuildID
+	p.sessionChannelID = vc.ChannelID
+
+	// Learn SSRC to user mappings as people start speaking
+	if vc != p.speakingConnection {
+		vc.AddHandler(p.onSpeakingUpdate)
+		p.speakingConnection = vc
+	}
 
-	log.Printf("[AUDIO] ✅ Starting audio capture with OGG files per user")
-	if p.debug {
+	if p.options.Ephemeral {
+		log.Printf("[AUDIO] ✅ Starting in-memory audio capture (no files will be written)")
+	} else {
+		log.Printf("[AUDIO] ✅ Starting audio capture with OGG files per user")
+	}
+	if p.debug.Load() {
 		log.Printf("[AUDIO] Voice connection guild: %s, channel: %s", vc.GuildID, vc.ChannelID)
 		log.Printf("[AUDIO] Audio format: %dHz, %d channels, %dms packets",
 			discordSampleRate, discordChannels, opusPacketDurationMs)
 	}
 
 	// Start processing audio packets in a goroutine
-	go p.processAudioPackets()
+	p.loops.Add(1)
+	go p.processAudioPackets(vc, p.stopped)
 
 	// Start background silence detector
-	go p.silenceDetector()
+	p.loops.Add(1)
+	go p.silenceDetector(p.stopped)
+
+	// Pick up batches spooled because a transcription queue was full, including earlier sessions'
+	if p.spoolEnabled() {
+		p.loops.Add(1)
+		go p.spoolWorker(p.stopped)
+	}
 
 	return nil
 }
 
 // StopProcessing stops audio processing
 func (p *Processor) StopProcessing() {
-	p.mutex.Lock()
-	defer p.mutex.Unlock()
+	p.lifecycle.Lock()
+	defer p.lifecycle.Unlock()
 
+	p.mutex.Lock()
 	if !p.isProcessing {
+		p.mutex.Unlock()
 		return
 	}
-
 	p.isProcessing = false
 	p.voiceConnection = nil
+	close(p.stopped)
+	p.mutex.Unlock()
+
+	// The loops may be part way through a packet or a flush; once they've exited nothing
+	// else sends on the transcription channels, so they can be closed safely
+	p.loops.Wait()
+
+	// Batches spooled by the final flush below are written once the session is unlocked
+	defer p.writeQueuedSpool()
 
-	// Send any remaining buffered audio to Google before closing
-	if p.speechService != nil {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	// Send any remaining buffered audio for transcription before closing
+	if p.canTranscribe() {
 		for ssrc := range p.audioBuffers {
 			p.flushAudioBuffer(ssrc)
 		}
@@ -175,74 +627,147 @@ func (p *Processor) StopProcessing() {
 			}
 		}
 	}
-	// Close all transcription channels
+	// Close all transcription channels; the workers finish what's queued, then exit
 	for ssrc, ch := range p.transcriptionChans {
 		close(ch)
 		log.Printf("[AUDIO] 📁 Closed transcription channel for SSRC %d", ssrc)
 	}
+	p.stoppedWorkers = p.workers
 
 	p.oggFiles = make(map[uint32]*oggwriter.OggWriter)
+	p.rememberSessionRecordings()
 
 	// Clear other maps
 	p.audioBuffers = make(map[uint32][]*rtp.Packet)
-	p.transcriptionChans = make(map[uint32]chan []*rtp.Packet)
+	p.preBuffers = make(map[uint32][]*rtp.Packet)
+	p.bufferStarts = make(map[uint32]time.Time)
+	p.transcriptionChans = make(map[uint32]chan audioBatch)
 	p.oggFilePaths = make(map[uint32]string)
 	p.lastPacketTime = make(map[uint32]time.Time)
+	p.lastSequence = make(map[uint32]uint16)
+	p.lastTimestamp = make(map[uint32]uint32)
 
 	log.Printf("[AUDIO] ⏹️ Stopped audio processing")
-	if p.debug {
+	if p.debug.Load() {
 		log.Printf("[AUDIO] Final stats: %d packets, %d silence detections, %d audio segments",
 			p.packetsReceived, p.silenceDetections, p.audioSegments)
 		log.Printf("[AUDIO] Total bytes written: %d", p.totalBytesWritten)
+		if p.options.VoiceGateDB != 0 {
+			log.Printf("[AUDIO] Frames below voice gate (%.0f dB): %d", p.options.VoiceGateDB, p.framesGated)
+		}
+		log.Printf("[AUDIO] Packet loss: %d lost, %d reordered, %d malformed",
+			p.sessionStats().PacketsLost, p.packetsReordered, p.packetsMalformed)
 	}
 }
 
-// processAudioPacket processes a single audio packet
+// processAudioPacket processes a single audio packet. The session is locked while the packet
+// is handled, except while the recording and worker for a new SSRC are set up.
 func (p *Processor) processAudioPacket(packet *discordgo.Packet) {
-	if packet == nil || len(packet.Opus) == 0 {
+	if packet == nil || len(packet.Opus) == 0 || p.deafened.Load() {
+		return
+	}
+
+	p.mutex.Lock()
+	accepted, ok := p.acceptPacket(packet)
+	p.mutex.Unlock()
+	if !ok {
+		return
+	}
+
+	// Set up recording and transcription for new SSRCs (users)
+	if !accepted.started && !p.startSSRC(packet.SSRC) {
 		return
 	}
 
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.recordPacket(packet, accepted)
+}
+
+// acceptedPacket is what acceptPacket learned about a packet that should be recorded
+type acceptedPacket struct {
+	missing           uint16 // Packets lost just before this one
+	previousSequence  uint16
+	previousTimestamp uint32
+	ignored           bool // From an ignored user; recorded but never transcribed
+	started           bool // The SSRC's recording and transcription worker exist
+}
+
+// acceptPacket updates the counters and sequence tracking for a packet and reports whether it
+// should be recorded: late, malformed and silence packets aren't. The caller must hold the mutex.
+func (p *Processor) acceptPacket(packet *discordgo.Packet) (acceptedPacket, bool) {
 	// Update counters
 	p.packetsReceived++
+	now := p.options.Clock.Now()
+	p.lastReceived[packet.SSRC] = now
+	counter, ok := p.packetRates[packet.SSRC]
+	if !ok {
+		counter = &packetCounter{}
+		p.packetRates[packet.SSRC] = counter
+	}
+	counter.add(now)
+
+	// Track sequence numbers for every packet, including silence, so gaps are real losses.
+	// Late packets are dropped; writing them would move the OGG timeline backwards.
+	missing, late := p.checkSequence(packet)
+	if late {
+		return acceptedPacket{}, false
+	}
+	accepted := acceptedPacket{
+		missing:           missing,
+		previousSequence:  p.lastSequence[packet.SSRC],
+		previousTimestamp: p.lastTimestamp[packet.SSRC],
+	}
+	p.lastSequence[packet.SSRC] = packet.Sequence
+	p.lastTimestamp[packet.SSRC] = packet.Timestamp
+
+	// Corrupt payloads would be written as garbage and break the recording and transcription
+	if p.options.ValidateOpus {
+		if err := checkOpusPacket(packet.Opus); err != nil {
+			p.packetsMalformed++
+			if p.debug.Load() {
+				log.Printf("[AUDIO] ⚠️ Dropping malformed Opus packet %d for SSRC %d: %v", packet.Sequence, packet.SSRC, err)
+			}
+			return acceptedPacket{}, false
+		}
+	}
 
 	// Check for Discord silence detection packets
 	isSilence := p.isSilencePacket(packet)
 	if isSilence {
 		p.handleSilenceDetection()
 		// Skip saving silence packets to OGG files
-		return
+		return acceptedPacket{}, false
 	}
-	// Get or create OGG writer for this SSRC (user)
-	oggFile, exists := p.oggFiles[packet.SSRC]
 
-	if !exists {
-		var err error
-
-		// Create filename for this SSRC
-		timestamp := time.Now().Format("20060102_150405")
-		filename := fmt.Sprintf("audio_%s_%d.ogg", timestamp, packet.SSRC)
+	// Ignored users are recorded only if configured, and never transcribed
+	accepted.ignored = p.isIgnoredSSRC(packet.SSRC)
+	if accepted.ignored && !p.options.RecordIgnoredUsers {
+		return acceptedPacket{}, false
+	}
 
-		// Create OGG writer for persistent file
-		oggFile, err = oggwriter.New(filename, discordSampleRate, discordChannels)
-		if err != nil {
-			log.Printf("[AUDIO] ⚠️ Failed to create OGG file for SSRC %d: %v", packet.SSRC, err)
-			return
-		}
+	_, accepted.started = p.transcriptionChans[packet.SSRC]
+	return accepted, true
+}
 
-		p.oggFiles[packet.SSRC] = oggFile
-		p.oggFilePaths[packet.SSRC] = filename
-		p.audioBuffers[packet.SSRC] = make([]*rtp.Packet, 0)
+// recordPacket writes an accepted packet to its SSRC's recording and transcription buffer.
+// The caller must hold the mutex.
+func (p *Processor) recordPacket(packet *discordgo.Packet, accepted acceptedPacket) {
+	// The OGG writer is nil in ephemeral mode
+	oggFile := p.oggFiles[packet.SSRC]
 
-		// Create transcription channel and start goroutine
-		p.transcriptionChans[packet.SSRC] = make(chan []*rtp.Packet, 10)
-		go p.transcriptionWorker(packet.SSRC, p.transcriptionChans[packet.SSRC])
+	// Quiet frames are still recorded but count as silence for transcription
+	gated := p.belowVoiceGate(packet)
 
-		log.Printf("[AUDIO] 📁 Created OGG file %s for SSRC %d", filename, packet.SSRC)
+	// Update last packet time for this SSRC
+	if !gated {
+		p.lastPacketTime[packet.SSRC] = p.options.Clock.Now()
 	}
 
-	// Update last packet time for this SSRC
-	p.lastPacketTime[packet.SSRC] = time.Now()
+	// Fill any gap left by dropped packets before writing this one
+	if accepted.missing > 0 && p.options.FillPacketGaps {
+		p.fillPacketGap(oggFile, packet.SSRC, accepted.previousSequence, accepted.previousTimestamp, accepted.missing)
+	}
 
 	// Create RTP packet from Discord packet
 	rtpPacket := &rtp.Packet{
@@ -259,21 +784,170 @@ func (p *Processor) processAudioPacket(packet *discordgo.Packet) {
 		Payload: packet.Opus,
 	}
 	// Write RTP packet to persistent OGG file
-	err := oggFile.WriteRTP(rtpPacket)
-	if err != nil {
-		log.Printf("[AUDIO] ⚠️ Failed to write RTP packet to OGG file for SSRC %d: %v", packet.SSRC, err)
-	} else {
-		p.totalBytesWritten += int64(len(packet.Opus))
+	if oggFile != nil {
+		err := oggFile.WriteRTP(rtpPacket)
+		if err != nil {
+			log.Printf("[AUDIO] ⚠️ Failed to write RTP packet to OGG file for SSRC %d: %v", packet.SSRC, err)
+		} else {
+			p.totalBytesWritten += int64(len(packet.Opus))
+		}
 	}
 
-	// Add packet to buffer for transcription
-	p.audioBuffers[packet.SSRC] = append(p.audioBuffers[packet.SSRC], rtpPacket)
+	// Add packet to buffer for transcription; quiet frames before an utterance are its lead-in
+	if p.canTranscribe() && !accepted.ignored {
+		if gated {
+			p.addLeadInPacket(rtpPacket)
+		} else {
+			p.bufferPacket(rtpPacket)
+		}
+	}
+	p.captureTestPacket(rtpPacket)
 
 	// Every 50 packets (1 second), log status
-	if p.debug && p.packetsReceived%50 == 0 {
+	if p.debug.Load() && p.packetsReceived%50 == 0 {
 		estimatedDuration := float32(p.packetsReceived) * float32(opusPacketDurationMs) / 1000.0
-		log.Printf("[AUDIO] 📊 Captured: %d packets processed, ~%.1fs total (%d bytes saved)",
-			p.packetsReceived, estimatedDuration, p.totalBytesWritten)
+		log.Printf("[AUDIO] 📊 Captured: %d packets processed, ~%.1fs total (%d bytes saved, %d frames gated)",
+			p.packetsReceived, estimatedDuration, p.totalBytesWritten, p.framesGated)
+	}
+}
+
+// bufferPacket adds a speech packet to its SSRC's transcription buffer. The first packet of
+// an utterance is preceded by the lead-in packets so the start of the first word isn't clipped.
+func (p *Processor) bufferPacket(packet *rtp.Packet) {
+	ssrc := packet.SSRC
+
+	if len(p.audioBuffers[ssrc]) == 0 {
+		p.audioBuffers[ssrc] = append(p.audioBuffers[ssrc], p.preBuffers[ssrc]...)
+		leadIn := time.Duration(len(p.preBuffers[ssrc])*opusPacketDurationMs) * time.Millisecond
+		p.bufferStarts[ssrc] = p.options.Clock.Now().Add(-leadIn)
+		delete(p.preBuffers, ssrc)
+	}
+	p.audioBuffers[ssrc] = append(p.audioBuffers[ssrc], packet)
+}
+
+// addLeadInPacket keeps a packet that wasn't buffered as possible lead-in for the SSRC's next
+// utterance, up to the configured lead-in length. Nothing is kept while an utterance is being
+// buffered, since flushing it clears the lead-in anyway.
+func (p *Processor) addLeadInPacket(packet *rtp.Packet) {
+	ssrc := packet.SSRC
+
+	leadInPackets := int(p.options.LeadIn / (opusPacketDurationMs * time.Millisecond))
+	if leadInPackets <= 0 || len(p.audioBuffers[ssrc]) > 0 {
+		return
+	}
+
+	preBuffer := append(p.preBuffers[ssrc], packet)
+	if len(preBuffer) > leadInPackets {
+		preBuffer = preBuffer[len(preBuffer)-leadInPackets:]
+	}
+	p.preBuffers[ssrc] = preBuffer
+}
+
+// recordingFormat returns the sample rate and channel count to declare in recordings
+func (p *Processor) recordingFormat() (uint32, uint16) {
+	sampleRate, channels := uint32(discordSampleRate), uint16(discordChannels)
+	if p.options.RecordingSampleRate != 0 {
+		sampleRate = p.options.RecordingSampleRate
+	}
+	if p.options.RecordingChannels != 0 {
+		channels = p.options.RecordingChannels
+	}
+	return sampleRate, channels
+}
+
+// startSSRC creates the OGG file, buffer and transcription worker for a new SSRC.
+// It returns false if the SSRC couldn't be set up. The mutex must not be held: naming the
+// file may look up the speaker's name.
+func (p *Processor) startSSRC(ssrc uint32) bool {
+	if !p.options.Ephemeral {
+		// Create filename for this SSRC, named after the speaker if known
+		filename := p.recordingFileName(ssrc)
+
+		// Create OGG writer for persistent file
+		sampleRate, channels := p.recordingFormat()
+		oggFile, err := oggwriter.New(filename, sampleRate, channels)
+		if err != nil {
+			log.Printf("[AUDIO] ⚠️ Failed to create OGG file for SSRC %d: %v", ssrc, err)
+			p.reportError(ComponentAudio, fmt.Errorf("creating recording for SSRC %d: %w", ssrc, err))
+			return false
+		}
+
+		p.mutex.Lock()
+		p.oggFiles[ssrc] = oggFile
+		p.oggFilePaths[ssrc] = filename
+		p.mutex.Unlock()
+		log.Printf("[AUDIO] 📁 Created OGG file %s for SSRC %d", filename, ssrc)
+
+		p.writeSpeakerMap()
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.audioBuffers[ssrc] = make([]*rtp.Packet, 0)
+
+	// Create transcription channel and start goroutine
+	p.transcriptionChans[ssrc] = make(chan audioBatch, 10)
+	p.workers.Add(1)
+	go p.transcriptionWorker(ssrc, p.transcriptionChans[ssrc], p.workers)
+
+	return true
+}
+
+// checkSequence compares the packet's sequence number with the last one seen for its SSRC.
+// It returns the number of packets missing before this one and whether the packet arrived late.
+func (p *Processor) checkSequence(packet *discordgo.Packet) (missing uint16, late bool) {
+	last, seen := p.lastSequence[packet.SSRC]
+	if !seen {
+		return 0, false
+	}
+
+	// uint16 arithmetic handles sequence number wraparound
+	delta := packet.Sequence - last
+	switch {
+	case delta == 1:
+		return 0, false
+	case delta == 0 || delta >= maxSequenceJump:
+		p.packetsReordered++
+		if p.debug.Load() {
+			log.Printf("[AUDIO] ⚠️ Late or duplicate packet for SSRC %d: sequence %d after %d",
+				packet.SSRC, packet.Sequence, last)
+		}
+		return 0, true
+	default:
+		missing = delta - 1
+		p.packetsLost[packet.SSRC] += int64(missing)
+		log.Printf("[AUDIO] ⚠️ Detected %d lost packets for SSRC %d (sequence %d -> %d)",
+			missing, packet.SSRC, last, packet.Sequence)
+		return missing, false
+	}
+}
+
+// fillPacketGap writes silence frames in place of missing packets so the OGG timeline stays consistent
+func (p *Processor) fillPacketGap(oggFile *oggwriter.OggWriter, ssrc uint32, lastSequence uint16, lastTimestamp uint32, missing uint16) {
+	count := min(int(missing), maxGapFillPackets)
+
+	for i := 1; i <= count; i++ {
+		silence := &rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				PayloadType:    111, // Opus payload type
+				SequenceNumber: lastSequence + uint16(i),
+				Timestamp:      lastTimestamp + uint32(i*discordFrameSize),
+				SSRC:           ssrc,
+			},
+			Payload: []byte{discordSilenceMarker1, discordSilenceMarker2, discordSilenceMarker3},
+		}
+
+		if oggFile != nil {
+			if err := oggFile.WriteRTP(silence); err != nil {
+				log.Printf("[AUDIO] ⚠️ Failed to write gap silence for SSRC %d: %v", ssrc, err)
+				return
+			}
+		}
+		if p.canTranscribe() {
+			p.audioBuffers[ssrc] = append(p.audioBuffers[ssrc], silence)
+		}
 	}
 }
 
@@ -290,36 +964,98 @@ func (p *Processor) handleSilenceDetection() {
 	p.silenceDetections++
 }
 
-// writeDebugFile writes the OGG buffer to disk for manual testing
-func (p *Processor) writeDebugFile(ssrc uint32, data []byte) {
-	if len(data) == 0 {
-		return
+// writeDebugFile writes the transcription audio (OGG, or WAV if resampled) to disk for manual testing
+func (p *Processor) writeDebugFile(ssrc uint32, data []byte) string {
+	if len(data) == 0 || p.options.Ephemeral {
+		return ""
 	}
 
 	// Create filename with timestamp and SSRC
-	timestamp := time.Now().Format("20060102_150405")
-	filename := fmt.Sprintf("debug_audio_%s_%d.ogg", timestamp, ssrc)
+	timestamp := p.options.Clock.Now().Format("20060102_150405")
+	ext := ".ogg"
+	if bytes.HasPrefix(data, []byte("RIFF")) {
+		ext = ".wav"
+	}
+	filename := filepath.Join(p.options.Dir, fmt.Sprintf("debug_audio_%s_%d%s", timestamp, ssrc, ext))
 
 	if err := os.WriteFile(filename, data, 0644); err != nil {
-		if p.debug {
+		if p.debug.Load() {
 			log.Printf("[AUDIO] ⚠️ Failed to write debug file %s: %v", filename, err)
 		}
-	} else {
-		if p.debug {
-			log.Printf("[AUDIO] 📁 Wrote debug file %s (%d bytes)", filename, len(data))
-		}
+		return ""
+	}
+
+	if p.debug.Load() {
+		log.Printf("[AUDIO] 📁 Wrote debug file %s (%d bytes)", filename, len(data))
 	}
+	return filename
+}
+
+// TranscriptionFailure describes audio the speech backend couldn't transcribe
+type TranscriptionFailure struct {
+	GuildID string
+	SSRC    uint32
+	UserID  string // "" if the speaker is unknown
+	Time    time.Time
+	Err     string
+	Path    string // Debug file holding the audio, "" if it wasn't saved
 }
 
-// flushAudioBuffer sends the accumulated audio packets to transcription worker
+// recordFailure remembers the latest failed transcription so it can be fetched later
+func (p *Processor) recordFailure(ssrc uint32, err error, path string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.lastFailure = &TranscriptionFailure{
+		GuildID: p.sessionGuildID,
+		SSRC:    ssrc,
+		UserID:  p.ssrcUsers[ssrc],
+		Time:    p.options.Clock.Now(),
+		Err:     err.Error(),
+		Path:    path,
+	}
+}
+
+// LastFailure returns the most recent failed transcription, if there has been one
+func (p *Processor) LastFailure() (TranscriptionFailure, bool) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	if p.lastFailure == nil {
+		return TranscriptionFailure{}, false
+	}
+	return *p.lastFailure, true
+}
+
+// flushAudioBuffer sends the accumulated audio packets to transcription worker.
+// The caller must hold the mutex.
 func (p *Processor) flushAudioBuffer(ssrc uint32) {
-	if p.speechService == nil {
-		return
+	p.sendAudioBuffer(ssrc, nil)
+}
+
+// sendAudioBuffer sends the accumulated audio packets to the transcription worker and reports
+// whether they were queued there; only then is delivered called, once the result is delivered.
+// The caller must hold the mutex.
+func (p *Processor) sendAudioBuffer(ssrc uint32, delivered func()) bool {
+	if !p.canTranscribe() {
+		return false
 	}
 
 	buffer, exists := p.audioBuffers[ssrc]
 	if !exists || len(buffer) == 0 {
-		return
+		return false
+	}
+
+	// Very short bursts are almost certainly noise
+	if len(buffer) < p.options.MinPackets {
+		p.buffersTooShort++
+		if p.debug.Load() {
+			log.Printf("[AUDIO] 🔇 Discarding %d packets for SSRC %d, fewer than the minimum of %d", len(buffer), ssrc, p.options.MinPackets)
+		}
+		p.audioBuffers[ssrc] = p.audioBuffers[ssrc][:0]
+		delete(p.preBuffers, ssrc)
+		p.lastPacketTime[ssrc] = p.options.Clock.Now()
+		return false
 	}
 
 	// Send copy of buffer to transcription worker
@@ -327,82 +1063,115 @@ func (p *Processor) flushAudioBuffer(ssrc uint32) {
 	copy(packetsCopy, buffer)
 
 	// Send to transcription channel (non-blocking)
-	select {
-	case p.transcriptionChans[ssrc] <- packetsCopy:
-		if p.debug {
+	queued := p.order.enqueue(ssrc, func(seq uint64) bool {
+		select {
+		case p.transcriptionChans[ssrc] <- audioBatch{packets: packetsCopy, start: p.bufferStarts[ssrc], seq: seq, delivered: delivered}:
+			return true
+		default:
+			return false
+		}
+	})
+	if queued {
+		p.audioSegments++
+		if p.debug.Load() {
 			log.Printf("[AUDIO] 🔍 Sent %d packets to transcription worker for SSRC %d", len(packetsCopy), ssrc)
 		}
-	default:
-		if p.debug {
-			log.Printf("[AUDIO] ⚠️ Transcription channel full for SSRC %d, dropping buffer", ssrc)
+	} else if p.spoolEnabled() {
+		// Transcribe it late rather than lose it
+		if !p.spoolBatch(ssrc, audioBatch{packets: packetsCopy, start: p.bufferStarts[ssrc]}) {
+			log.Printf("[AUDIO] ⚠️ Transcription channel and spool queue full for SSRC %d, dropping buffer", ssrc)
+		} else if p.debug.Load() {
+			log.Printf("[AUDIO] 💾 Transcription channel full for SSRC %d, spooling %d packets", ssrc, len(packetsCopy))
 		}
+	} else if p.debug.Load() {
+		log.Printf("[AUDIO] ⚠️ Transcription channel full for SSRC %d, dropping buffer", ssrc)
 	}
 
-	// Clear the buffer
+	// Clear the buffer, and any lead-in gathered since it started, which would repeat its audio
 	p.audioBuffers[ssrc] = p.audioBuffers[ssrc][:0]
+	delete(p.preBuffers, ssrc)
 
 	// Update last packet time to prevent immediate re-sending
-	p.lastPacketTime[ssrc] = time.Now()
+	p.lastPacketTime[ssrc] = p.options.Clock.Now()
+	return queued
 }
 
-// processAudioPackets processes incoming audio packets
-func (p *Processor) processAudioPackets() {
-	if p.voiceConnection == nil {
-		log.Printf("[AUDIO] ❌ No voice connection available")
-		return
-	}
+// processAudioPackets processes incoming audio packets until the session is stopped. The
+// connection may outlive the session (e.g. when moving channels), so the loop exits as soon
+// as stopped is closed rather than leaving a second reader on OpusRecv.
+func (p *Processor) processAudioPackets(vc *discordgo.VoiceConnection, stopped <-chan struct{}) {
+	defer p.loops.Done()
 
 	log.Printf("[AUDIO] 🎧 Started listening for Discord audio packets...")
-	if p.debug {
-		log.Printf("[AUDIO] Voice connection ready: %v", p.voiceConnection.Ready)
-		log.Printf("[AUDIO] OpusRecv channel: %p", p.voiceConnection.OpusRecv)
+	if p.debug.Load() {
+		log.Printf("[AUDIO] Voice connection ready: %v", vc.Ready)
+		log.Printf("[AUDIO] OpusRecv channel: %p", vc.OpusRecv)
 	}
 
 	// Listen for packets from Discord's OpusRecv channel
-	for packet := range p.voiceConnection.OpusRecv {
-		if !p.isProcessing {
+	for {
+		select {
+		case <-stopped:
 			log.Printf("[AUDIO] 🛑 Audio processing stopped, exiting packet loop")
 			return
-		}
-
-		if packet != nil {
-			p.processAudioPacket(packet)
+		case packet, ok := <-vc.OpusRecv:
+			if !ok {
+				log.Printf("[AUDIO] 🎧 Finished processing audio packets")
+				return
+			}
+			if packet != nil {
+				p.processAudioPacket(packet)
+			}
 		}
 	}
-
-	log.Printf("[AUDIO] 🎧 Finished processing audio packets")
 }
 
 // silenceDetector runs in background checking for silence every 100ms
-func (p *Processor) silenceDetector() {
+func (p *Processor) silenceDetector(stopped <-chan struct{}) {
+	defer p.loops.Done()
+
 	ticker := time.NewTicker(100 * time.Millisecond)
 	defer ticker.Stop()
 
 	log.Printf("[AUDIO] 🔍 Started background silence detector (checking every 100ms)")
 
-	for range ticker.C {
-		if !p.isProcessing {
+	for {
+		select {
+		case <-stopped:
 			log.Printf("[AUDIO] 🔍 Background silence detector stopped")
 			return
+		case <-ticker.C:
+			p.checkAllForSilence()
 		}
-		p.checkAllForSilence()
 	}
 }
 
 // checkAllForSilence checks all SSRCs for silence and sends buffers if needed
 func (p *Processor) checkAllForSilence() {
-	if p.speechService == nil {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if !p.canTranscribe() {
 		return
 	}
 
-	now := time.Now()
+	now := p.options.Clock.Now()
 
 	// Check each SSRC for silence
 	for ssrc, lastTime := range p.lastPacketTime {
+		if p.bufferTooOld(ssrc, now) {
+			if p.debug.Load() {
+				log.Printf("[AUDIO] ⏱️ Buffer for SSRC %d is older than %v, sending %d packets to transcription",
+					ssrc, p.options.MaxBufferAge, len(p.audioBuffers[ssrc]))
+			}
+			p.flushAudioBuffer(ssrc)
+			continue
+		}
+
 		if now.Sub(lastTime) > silenceThreshold {
 			// Check if this SSRC has buffered audio to send
 			if buffer, exists := p.audioBuffers[ssrc]; exists && len(buffer) > 0 {
-				if p.debug {
+				if p.debug.Load() {
 					log.Printf("[AUDIO] 🔍 Detected silence for SSRC %d (%.2fs), sending %d packets to transcription",
 						ssrc, now.Sub(lastTime).Seconds(), len(buffer))
 				}
@@ -412,73 +1181,208 @@ func (p *Processor) checkAllForSilence() {
 	}
 }
 
-// transcriptionWorker processes audio packets for transcription in a separate goroutine
-func (p *Processor) transcriptionWorker(ssrc uint32, packets chan []*rtp.Packet) {
-	for packetBatch := range packets {
-		if !p.isProcessing {
-			return
+// bufferTooOld reports whether an SSRC's buffered utterance has been growing for longer than
+// the maximum buffer age, measured from its first packet (including any lead-in)
+func (p *Processor) bufferTooOld(ssrc uint32, now time.Time) bool {
+	if p.options.MaxBufferAge <= 0 || len(p.audioBuffers[ssrc]) == 0 {
+		return false
+	}
+	return now.Sub(p.bufferStarts[ssrc]) >= p.options.MaxBufferAge
+}
+
+// WaitForTranscriptions waits, up to timeout, for the last stopped session's transcription
+// workers to finish the batches still queued when it stopped and deliver their results. It
+// reports whether they all finished.
+func (p *Processor) WaitForTranscriptions(timeout time.Duration) bool {
+	p.mutex.RLock()
+	workers := p.stoppedWorkers
+	p.mutex.RUnlock()
+
+	done := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// transcriptionWorker processes audio packets for transcription in a separate goroutine.
+// It keeps going until the channel is closed so the batches flushed on stop aren't lost.
+func (p *Processor) transcriptionWorker(ssrc uint32, batches chan audioBatch, workers *sync.WaitGroup) {
+	defer workers.Done()
+
+	for batch := range batches {
+		p.runBatch(ssrc, batch)
+	}
+}
+
+// runBatch transcribes a batch and delivers its result once the SSRC's earlier batches have been
+func (p *Processor) runBatch(ssrc uint32, batch audioBatch) {
+	deliver, ok := p.transcribeBatch(ssrc, batch)
+	if batch.delivered != nil || batch.spoolPath != "" {
+		result := deliver
+		deliver = func() {
+			if result != nil {
+				result()
+			}
+			if batch.spoolPath != "" {
+				p.finishSpooledBatch(ssrc, batch.spoolPath, ok)
+			}
+			if batch.delivered != nil {
+				batch.delivered()
+			}
 		}
+	}
+	p.order.complete(ssrc, batch.seq, deliver)
+}
 
-		// Create a new OGG buffer with headers for each batch
-		buffer := &bytes.Buffer{}
-		oggWriter, err := oggwriter.NewWith(buffer, discordSampleRate, discordChannels)
+// transcribeBatch transcribes one batch and returns the function that delivers its result,
+// or nil if there's nothing to deliver. ok is false if the batch couldn't be transcribed.
+func (p *Processor) transcribeBatch(ssrc uint32, batch audioBatch) (deliver func(), ok bool) {
+	// Long buffers may be split so the pieces can be transcribed in parallel
+	segments := p.splitBatch(batch.packets)
+	audio := make([][]byte, len(segments))
+	offsets := make([]time.Duration, len(segments))
+	for i, segment := range segments {
+		data, err := p.encodeTranscriptionAudio(ssrc, segment)
 		if err != nil {
-			if p.debug {
+			if p.debug.Load() {
 				log.Printf("[AUDIO] ⚠️ Failed to create transcription OGG writer for SSRC %d: %v", ssrc, err)
 			}
-			continue
+			p.reportError(ComponentAudio, fmt.Errorf("preparing audio for SSRC %d: %w", ssrc, err))
+			return nil, false
 		}
+		audio[i] = data
+		offsets[i] = rtpElapsed(segments[0][0].Timestamp, segment[0].Timestamp)
+	}
 
-		// Write all packets to the fresh OGG buffer
-		for _, packet := range packetBatch {
-			err := oggWriter.WriteRTP(packet)
-			if err != nil {
-				if p.debug {
-					log.Printf("[AUDIO] ⚠️ Failed to write packet to transcription buffer for SSRC %d: %v", ssrc, err)
-				}
-			}
+	// Send to the speech backend for transcription
+	result, failed, err := p.recognizeSegments(audio, offsets)
+	if err != nil {
+		if p.debug.Load() {
+			log.Printf("[AUDIO] ⚠️ Failed to transcribe audio for SSRC %d: %v", ssrc, err)
 		}
+		p.reportError(ComponentSpeech, fmt.Errorf("transcribing SSRC %d: %w", ssrc, err))
 
-		// Close the OGG writer to finalize the stream
-		oggWriter.Close()
+		// Write the failed buffer to disk for manual testing
+		path := p.writeDebugFile(ssrc, audio[failed])
+		p.recordFailure(ssrc, err, path)
+		return nil, false
+	}
+	if result == nil {
+		return nil, true
+	}
 
-		// Send to Google for transcription
-		result, err := p.speechService.RecognizeAudio(buffer.Bytes())
-		if err != nil {
-			if p.debug {
-				log.Printf("[AUDIO] ⚠️ Failed to transcribe audio for SSRC %d: %v", ssrc, err)
-			}
+	// Blank results would reach Claude as empty lines
+	if words := len(strings.Fields(result.Transcript)); words == 0 || words < p.options.MinWords {
+		p.mutex.Lock()
+		p.transcriptsEmpty++
+		p.mutex.Unlock()
+		if p.debug.Load() {
+			log.Printf("[AUDIO] 🔇 Dropping transcription for SSRC %d with %d words: %q", ssrc, words, result.Transcript)
+		}
+		return nil, true
+	}
 
-			// Write the failed buffer to disk for manual testing
-			p.writeDebugFile(ssrc, buffer.Bytes())
-		} else {
-			// Print the transcription result to stdout
-			if result != nil {
-				fmt.Printf("[TRANSCRIPTION] SSRC %d [FINAL]: %s (confidence: %.2f)\n",
-					ssrc, result.Transcript, result.Confidence)
+	return func() {
+		// Print the transcription result to stdout
+		fmt.Printf("[TRANSCRIPTION] SSRC %d [FINAL]: %s (confidence: %.2f)\n",
+			ssrc, result.Transcript, result.Confidence)
 
-				// Also log to internal logging if debug is enabled
-				if p.debug {
-					log.Printf("[AUDIO] 📝 Transcription for SSRC %d [FINAL]: %s (confidence: %.2f)",
-						ssrc, result.Transcript, result.Confidence)
-				}
+		// Also log to internal logging if debug is enabled
+		if p.debug.Load() {
+			log.Printf("[AUDIO] 📝 Transcription for SSRC %d [FINAL]: %s (confidence: %.2f)",
+				ssrc, result.Transcript, result.Confidence)
+		}
 
-				// Call transcription callback if set
-				p.mutex.RLock()
-				callback := p.transcriptionCallback
-				p.mutex.RUnlock()
+		p.addSubtitleCues(ssrc, batch, result)
+		p.addTurn(ssrc, batch, result)
 
-				if callback != nil {
-					callback(ssrc, result.Transcript, float64(result.Confidence))
-				}
+		// Call transcription callback if set
+		p.mutex.RLock()
+		callback := p.transcriptionCallback
+		p.mutex.RUnlock()
+
+		if callback != nil {
+			callback(ssrc, result.Transcript, float64(result.Confidence), batch.delayed)
+		}
+	}, true
+}
+
+// encodeTranscriptionAudio writes packets into a fresh OGG stream for the speech backend,
+// or a 16kHz mono WAV file if resampling is on and every packet decodes
+func (p *Processor) encodeTranscriptionAudio(ssrc uint32, packets []*rtp.Packet) ([]byte, error) {
+	if p.options.ResampleTranscription {
+		if wav, ok := encodeResampledAudio(packets); ok {
+			return wav, nil
+		}
+		if p.debug.Load() {
+			log.Printf("[AUDIO] ⚠️ Can't decode audio for SSRC %d to resample it, sending Opus instead", ssrc)
+		}
+	}
+
+	buffer := &bytes.Buffer{}
+	oggWriter, err := oggwriter.NewWith(buffer, discordSampleRate, discordChannels)
+	if err != nil {
+		return nil, err
+	}
+
+	// Write all packets to the fresh OGG buffer
+	for _, packet := range packets {
+		err := oggWriter.WriteRTP(packet)
+		if err != nil {
+			if p.debug.Load() {
+				log.Printf("[AUDIO] ⚠️ Failed to write packet to transcription buffer for SSRC %d: %v", ssrc, err)
 			}
 		}
 	}
+
+	// Close the OGG writer to finalize the stream
+	oggWriter.Close()
+	return buffer.Bytes(), nil
 }
 
-// SetTranscriptionCallback sets the callback function for transcription results
-func (p *Processor) SetTranscriptionCallback(callback func(ssrc uint32, text string, confidence float64)) {
+// SetTranscriptionCallback sets the callback function for transcription results. Delayed results
+// come from batches spooled to disk and may arrive after later speech.
+func (p *Processor) SetTranscriptionCallback(callback func(ssrc uint32, text string, confidence float64, delayed bool)) {
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
 	p.transcriptionCallback = callback
 }
+
+// Components reported to the error callback
+const (
+	ComponentAudio  = "audio"
+	ComponentSpeech = "speech"
+)
+
+// SetErrorCallback sets the callback function for recording and transcription failures
+func (p *Processor) SetErrorCallback(callback func(component string, err error)) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.errorCallback = callback
+}
+
+// SetSpeakerCallback sets the callback function for an SSRC being linked to a different user
+func (p *Processor) SetSpeakerCallback(callback func(ssrc uint32, userID string)) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.speakerCallback = callback
+}
+
+// reportError passes a failure to the error callback, if set
+func (p *Processor) reportError(component string, err error) {
+	p.mutex.RLock()
+	callback := p.errorCallback
+	p.mutex.RUnlock()
+
+	if callback != nil {
+		callback(component, err)
+	}
+}
@@ -0,0 +1,231 @@
+package audio
+
+import (
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"dnd_dm_assistant_go/internal/speech"
+)
+
+func TestPacketsAndReadersDontRace(t *testing.T) {
+	clock := newFakeClock()
+	p, vc := newTestSession(t, &fakeTranscriber{}, Options{Clock: clock, LeadIn: 100 * time.Millisecond})
+	p.SetSSRCUser(1, "alice")
+	p.SetSSRCUser(2, "bob")
+
+	done := make(chan struct{})
+	var readers sync.WaitGroup
+	readers.Add(1)
+	go func() {
+		defer readers.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			p.SSRCActivity()
+			p.PacketLoss()
+			p.GetStats()
+			p.SetIgnoredUsers([]string{"carol"})
+			p.FlushUser("alice")
+			clock.advance(silenceThreshold)
+			p.checkAllForSilence()
+		}
+	}()
+
+	for sequence := uint16(1); sequence <= 200; sequence++ {
+		// Skip a packet now and then so there's loss to count
+		if sequence%17 == 0 {
+			continue
+		}
+		vc.OpusRecv <- speechPacket(1, sequence)
+		vc.OpusRecv <- speechPacket(2, sequence)
+	}
+	close(done)
+	readers.Wait()
+	p.StopProcessing()
+
+	if session, _ := p.GetStats(); session.PacketsReceived == 0 {
+		t.Fatal("no packets were processed")
+	}
+}
+
+// bufferedPackets returns how many packets are waiting in an SSRC's audio buffer
+func bufferedPackets(p *Processor, ssrc uint32) int {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return len(p.audioBuffers[ssrc])
+}
+
+func TestSilenceFlushesJustPastThreshold(t *testing.T) {
+	clock := newFakeClock()
+	fake := &fakeTranscriber{}
+	p, vc := newTestSession(t, fake, Options{Clock: clock})
+	defer p.StopProcessing()
+
+	for sequence := uint16(1); sequence <= 20; sequence++ {
+		vc.OpusRecv <- speechPacket(1, sequence)
+	}
+	waitFor(t, "the packets to be buffered", func() bool { return bufferedPackets(p, 1) == 20 })
+
+	clock.advance(silenceThreshold - time.Millisecond)
+	p.checkAllForSilence()
+	clock.advance(time.Millisecond)
+	p.checkAllForSilence()
+	if n := bufferedPackets(p, 1); n != 20 {
+		t.Fatalf("%d packets left after exactly the silence threshold, want the buffer kept", n)
+	}
+
+	clock.advance(time.Millisecond)
+	p.checkAllForSilence()
+	if n := bufferedPackets(p, 1); n != 0 {
+		t.Fatalf("%d packets left just past the silence threshold, want the buffer flushed", n)
+	}
+	waitFor(t, "the transcription", func() bool { return fake.calls() == 1 })
+}
+
+func TestSpeechResetsSilenceTimer(t *testing.T) {
+	clock := newFakeClock()
+	p, vc := newTestSession(t, &fakeTranscriber{}, Options{Clock: clock})
+	defer p.StopProcessing()
+
+	vc.OpusRecv <- speechPacket(1, 1)
+	clock.advance(silenceThreshold)
+	vc.OpusRecv <- speechPacket(1, 2)
+	waitFor(t, "the packets to be buffered", func() bool { return bufferedPackets(p, 1) == 2 })
+
+	// The pause is measured from the latest packet, not the first
+	clock.advance(time.Second)
+	p.checkAllForSilence()
+	if n := bufferedPackets(p, 1); n != 2 {
+		t.Fatalf("%d packets left a second after the last packet, want the buffer kept", n)
+	}
+}
+
+func TestStopWaitsForSilenceDetector(t *testing.T) {
+	for range 10 {
+		clock := newFakeClock()
+		p, vc := newTestSession(t, &fakeTranscriber{}, Options{Clock: clock})
+
+		for sequence := uint16(1); sequence <= 20; sequence++ {
+			vc.OpusRecv <- speechPacket(1, sequence)
+		}
+
+		// Every check from now on flushes; stopping mustn't close the queue under the detector
+		clock.advance(time.Hour)
+		time.Sleep(150 * time.Millisecond)
+		p.StopProcessing()
+
+		if p.IsProcessing() {
+			t.Fatal("still processing after stop")
+		}
+	}
+}
+
+func TestStopProcessingTwice(t *testing.T) {
+	p, _ := newTestSession(t, &fakeTranscriber{}, Options{})
+	p.StopProcessing()
+	p.StopProcessing()
+
+	if err := p.StartProcessing(newTestConnection()); err != nil {
+		t.Fatalf("restarting after stop: %v", err)
+	}
+	p.StopProcessing()
+}
+
+func TestLeadInIsAudioFromBeforeTheUtterance(t *testing.T) {
+	p := New(false, &fakeTranscriber{}, Options{Ephemeral: true, LeadIn: 3 * opusPacketDurationMs * time.Millisecond, Clock: newFakeClock()})
+	queue := make(chan audioBatch, 10)
+	p.transcriptionChans[1] = queue
+
+	packets := testPackets(1, 20)
+	quiet := func(from, to int) {
+		for _, packet := range packets[from : to+1] {
+			p.addLeadInPacket(packet)
+		}
+	}
+	speech := func(from, to int) {
+		for _, packet := range packets[from : to+1] {
+			p.bufferPacket(packet)
+		}
+	}
+	flushed := func() []uint16 {
+		t.Helper()
+		if !p.sendAudioBuffer(1, nil) {
+			t.Fatal("buffer wasn't queued")
+		}
+		var sequences []uint16
+		for _, packet := range (<-queue).packets {
+			sequences = append(sequences, packet.SequenceNumber)
+		}
+		return sequences
+	}
+
+	// The lead-in is the quiet frames just before speech, up to its length
+	quiet(0, 4)
+	speech(5, 7)
+	if got, want := flushed(), []uint16{2, 3, 4, 5, 6, 7}; !slices.Equal(got, want) {
+		t.Errorf("first utterance = %v, want %v", got, want)
+	}
+
+	// Speech straight after a flush has no lead-in: the previous utterance's tail isn't replayed
+	speech(8, 9)
+	if got, want := flushed(), []uint16{8, 9}; !slices.Equal(got, want) {
+		t.Errorf("second utterance = %v, want %v", got, want)
+	}
+
+	quiet(10, 10)
+	speech(11, 11)
+	if got, want := flushed(), []uint16{10, 11}; !slices.Equal(got, want) {
+		t.Errorf("third utterance = %v, want %v", got, want)
+	}
+}
+
+func TestRestartRegistersSpeakingHandlerOnce(t *testing.T) {
+	p, vc := newTestSession(t, &fakeTranscriber{}, Options{})
+	p.StopProcessing()
+	if err := p.StartProcessing(vc); err != nil {
+		t.Fatalf("restarting: %v", err)
+	}
+	defer p.StopProcessing()
+
+	// discordgo doesn't expose its handlers, so count them through reflection
+	handlers := reflect.ValueOf(vc).Elem().FieldByName("voiceSpeakingUpdateHandlers")
+	if handlers.Len() != 1 {
+		t.Errorf("%d speaking handlers registered after a restart, want 1", handlers.Len())
+	}
+}
+
+func TestWaitForTranscriptionsAfterStop(t *testing.T) {
+	release := make(chan struct{})
+	fake := &fakeTranscriber{recognize: func([]byte) (*speech.TranscriptionResult, error) {
+		<-release
+		return &speech.TranscriptionResult{Transcript: "the last words"}, nil
+	}}
+	p, vc := newTestSession(t, fake, Options{})
+	var delivered []string
+	p.SetTranscriptionCallback(func(ssrc uint32, text string, confidence float64, delayed bool) {
+		delivered = append(delivered, text)
+	})
+
+	for sequence := uint16(1); sequence <= 20; sequence++ {
+		vc.OpusRecv <- speechPacket(1, sequence)
+	}
+	// Stopping flushes the buffer to the worker, which is still waiting on the backend
+	p.StopProcessing()
+
+	if p.WaitForTranscriptions(50 * time.Millisecond) {
+		t.Fatal("WaitForTranscriptions returned before the transcription was delivered")
+	}
+	close(release)
+	if !p.WaitForTranscriptions(5 * time.Second) {
+		t.Fatal("timed out waiting for the transcription")
+	}
+	if len(delivered) != 1 || delivered[0] != "the last words" {
+		t.Errorf("delivered %q, want the last words", delivered)
+	}
+}
@@ -0,0 +1,41 @@
+package audio
+
+import "time"
+
+// Packet rates are measured over this many of the most recent whole seconds
+const rateWindowSeconds = 5
+
+// MaxPacketRate is the packets per second of a stream that is speaking continuously (one
+// Opus packet every 20ms)
+const MaxPacketRate = 1000 / opusPacketDurationMs
+
+// packetCounter counts a stream's packets in one-second buckets, for a sliding-window rate.
+// There's one bucket more than the window for the second that's still filling up.
+type packetCounter struct {
+	counts  [rateWindowSeconds + 1]int64
+	seconds [rateWindowSeconds + 1]int64 // Unix second each bucket is counting
+}
+
+// add counts a packet received at now
+func (c *packetCounter) add(now time.Time) {
+	second := now.Unix()
+	i := second % int64(len(c.seconds))
+	if c.seconds[i] != second {
+		c.seconds[i] = second
+		c.counts[i] = 0
+	}
+	c.counts[i]++
+}
+
+// rate returns the packets per second over the window ending at now. The current second is
+// still filling up, so the window is the previous rateWindowSeconds whole seconds.
+func (c *packetCounter) rate(now time.Time) float64 {
+	current := now.Unix()
+	var total int64
+	for i, second := range c.seconds {
+		if second < current && second >= current-rateWindowSeconds {
+			total += c.counts[i]
+		}
+	}
+	return float64(total) / rateWindowSeconds
+}
@@ -0,0 +1,108 @@
+package audio
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/pion/opus"
+	"github.com/pion/rtp"
+)
+
+// Sample rate of the mono PCM sent to the speech backend when resampling is on, the rate
+// both Google and Whisper recognize natively
+const transcriptionSampleRate = 16000
+
+// encodeResampledAudio decodes packets to PCM and returns them as a 16kHz mono 16-bit WAV file.
+// The Go Opus decoder only handles mono 20ms SILK wideband frames, while most Discord clients
+// send stereo CELT, so ok is false if any packet is in another mode or fails to decode and the
+// caller should send the original Opus instead.
+func encodeResampledAudio(packets []*rtp.Packet) (wav []byte, ok bool) {
+	decoder := opus.NewDecoder()
+	pcm := make([]float32, 0, len(packets)*decodedFrameSamples)
+	frame := make([]float32, decodedFrameSamples)
+	for _, packet := range packets {
+		if !silkWideband(packet.Payload) {
+			return nil, false
+		}
+		if _, _, err := decoder.DecodeFloat32(packet.Payload, frame); err != nil {
+			return nil, false
+		}
+		pcm = append(pcm, frame...)
+	}
+
+	return encodeWAV(resampleLinear(pcm, discordSampleRate, transcriptionSampleRate), transcriptionSampleRate), true
+}
+
+// silkWideband reports whether an Opus packet is a single mono 20ms SILK wideband frame, the only
+// kind the decoder turns into correct PCM. It feeds every other mode to the SILK decoder and
+// returns noise rather than an error.
+func silkWideband(payload []byte) bool {
+	const (
+		silkWideband20ms = 9    // TOC configuration number
+		stereoAndCode    = 0x07 // Stereo flag and frame count code
+	)
+	return len(payload) > 1 && payload[0]>>3 == silkWideband20ms && payload[0]&stereoAndCode == 0
+}
+
+// resampleLinear converts mono samples between rates by linear interpolation. Downsampling
+// first averages each run of input samples per output sample, a cheap low-pass that keeps
+// high frequencies from aliasing into the speech band.
+func resampleLinear(samples []float32, fromRate, toRate int) []float32 {
+	if fromRate == toRate || len(samples) == 0 {
+		return samples
+	}
+
+	outLen := int(int64(len(samples)) * int64(toRate) / int64(fromRate))
+	out := make([]float32, outLen)
+	step := float64(fromRate) / float64(toRate)
+	for i := range out {
+		pos := float64(i) * step
+		if step > 1 {
+			// Average the input samples this output sample covers
+			start, end := int(pos), min(int(pos+step), len(samples))
+			var sum float32
+			for _, s := range samples[start:end] {
+				sum += s
+			}
+			out[i] = sum / float32(end-start)
+			continue
+		}
+
+		index := int(pos)
+		frac := float32(pos - float64(index))
+		next := min(index+1, len(samples)-1)
+		out[i] = samples[index]*(1-frac) + samples[next]*frac
+	}
+	return out
+}
+
+// encodeWAV writes mono float samples as a 16-bit PCM WAV file
+func encodeWAV(samples []float32, sampleRate int) []byte {
+	const (
+		bitsPerSample = 16
+		blockAlign    = bitsPerSample / 8 // One channel
+		headerSize    = 44
+	)
+	dataSize := len(samples) * blockAlign
+
+	le := binary.LittleEndian
+	wav := make([]byte, 0, headerSize+dataSize)
+	wav = append(wav, "RIFF"...)
+	wav = le.AppendUint32(wav, uint32(headerSize-8+dataSize))
+	wav = append(wav, "WAVEfmt "...)
+	wav = le.AppendUint32(wav, 16) // fmt chunk size
+	wav = le.AppendUint16(wav, 1)  // PCM
+	wav = le.AppendUint16(wav, 1)  // Channels
+	wav = le.AppendUint32(wav, uint32(sampleRate))
+	wav = le.AppendUint32(wav, uint32(sampleRate*blockAlign)) // Byte rate
+	wav = le.AppendUint16(wav, blockAlign)
+	wav = le.AppendUint16(wav, bitsPerSample)
+	wav = append(wav, "data"...)
+	wav = le.AppendUint32(wav, uint32(dataSize))
+
+	for _, s := range samples {
+		clamped := max(-1, min(1, float64(s)))
+		wav = le.AppendUint16(wav, uint16(int16(math.Round(clamped*math.MaxInt16))))
+	}
+	return wav
+}
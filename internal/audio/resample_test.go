@@ -0,0 +1,157 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/pion/rtp"
+)
+
+func TestResampleLinearLength(t *testing.T) {
+	tests := []struct {
+		samples, fromRate, toRate, want int
+	}{
+		{decodedFrameSamples, discordSampleRate, transcriptionSampleRate, 320}, // One 20ms frame
+		{50 * decodedFrameSamples, discordSampleRate, transcriptionSampleRate, 16000},
+		{1000, discordSampleRate, transcriptionSampleRate, 333},
+		{320, transcriptionSampleRate, discordSampleRate, 960},
+		{960, discordSampleRate, discordSampleRate, 960},
+		{0, discordSampleRate, transcriptionSampleRate, 0},
+	}
+	for _, tt := range tests {
+		got := resampleLinear(make([]float32, tt.samples), tt.fromRate, tt.toRate)
+		if len(got) != tt.want {
+			t.Errorf("resampling %d samples from %dHz to %dHz gave %d, want %d", tt.samples, tt.fromRate, tt.toRate, len(got), tt.want)
+		}
+	}
+}
+
+func TestResampleLinearKeepsLevel(t *testing.T) {
+	samples := make([]float32, decodedFrameSamples)
+	for i := range samples {
+		samples[i] = 0.5
+	}
+	for _, rates := range [][2]int{{discordSampleRate, transcriptionSampleRate}, {transcriptionSampleRate, discordSampleRate}} {
+		for i, s := range resampleLinear(samples, rates[0], rates[1]) {
+			if math.Abs(float64(s)-0.5) > 1e-6 {
+				t.Fatalf("%d to %dHz: sample %d = %v, want 0.5", rates[0], rates[1], i, s)
+			}
+		}
+	}
+}
+
+func TestEncodeWAVHeader(t *testing.T) {
+	samples := []float32{0, 1, -1, 2, 0.5}
+	wav := encodeWAV(samples, transcriptionSampleRate)
+
+	if len(wav) != 44+2*len(samples) {
+		t.Fatalf("WAV is %d bytes, want %d", len(wav), 44+2*len(samples))
+	}
+	le := binary.LittleEndian
+	checks := []struct {
+		name string
+		got  any
+		want any
+	}{
+		{"RIFF tag", string(wav[0:4]), "RIFF"},
+		{"RIFF size", le.Uint32(wav[4:8]), uint32(36 + 2*len(samples))},
+		{"WAVE tag", string(wav[8:16]), "WAVEfmt "},
+		{"fmt size", le.Uint32(wav[16:20]), uint32(16)},
+		{"format", le.Uint16(wav[20:22]), uint16(1)},
+		{"channels", le.Uint16(wav[22:24]), uint16(1)},
+		{"sample rate", le.Uint32(wav[24:28]), uint32(transcriptionSampleRate)},
+		{"byte rate", le.Uint32(wav[28:32]), uint32(2 * transcriptionSampleRate)},
+		{"block align", le.Uint16(wav[32:34]), uint16(2)},
+		{"bits per sample", le.Uint16(wav[34:36]), uint16(16)},
+		{"data tag", string(wav[36:40]), "data"},
+		{"data size", le.Uint32(wav[40:44]), uint32(2 * len(samples))},
+	}
+	for _, c := range checks {
+		if c.got != c.want {
+			t.Errorf("%s = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+
+	// Out-of-range samples are clamped rather than wrapping around
+	want := []int16{0, math.MaxInt16, -math.MaxInt16, math.MaxInt16, 16384}
+	for i, w := range want {
+		if got := int16(le.Uint16(wav[44+2*i:])); got != w {
+			t.Errorf("sample %d = %d, want %d", i, got, w)
+		}
+	}
+}
+
+// silkFrame is a mono 20ms SILK wideband Opus frame recorded by libopus
+var silkFrame = []byte{0x48, 0x83, 0xca, 0xde, 0x8a, 0xe5, 0x67, 0xd5, 0x1c, 0xac, 0xa2, 0x54, 0xfa, 0xff, 0xbf}
+
+// silkPackets builds n consecutive RTP packets of SILK audio for an SSRC
+func silkPackets(ssrc uint32, n int) []*rtp.Packet {
+	packets := testPackets(ssrc, n)
+	for _, packet := range packets {
+		packet.Payload = silkFrame
+	}
+	return packets
+}
+
+func TestEncodeResampledAudioSampleCount(t *testing.T) {
+	const frames = 25 // Half a second
+	wav, ok := encodeResampledAudio(silkPackets(1, frames))
+	if !ok {
+		t.Fatal("SILK wideband packets didn't decode")
+	}
+
+	wantSamples := frames * decodedFrameSamples * transcriptionSampleRate / discordSampleRate
+	if got := binary.LittleEndian.Uint32(wav[40:44]); got != uint32(2*wantSamples) {
+		t.Errorf("data size = %d bytes, want %d (%d samples)", got, 2*wantSamples, wantSamples)
+	}
+	if len(wav) != 44+2*wantSamples {
+		t.Errorf("WAV is %d bytes, want %d", len(wav), 44+2*wantSamples)
+	}
+	if got := binary.LittleEndian.Uint32(wav[24:28]); got != transcriptionSampleRate {
+		t.Errorf("sample rate = %d, want %d", got, transcriptionSampleRate)
+	}
+}
+
+func TestEncodeResampledAudioRejectsOtherModes(t *testing.T) {
+	tests := []struct {
+		name string
+		toc  byte
+	}{
+		{"stereo CELT", 0xfc},
+		{"mono CELT", 0xf8},
+		{"stereo SILK", 0x4c},
+		{"hybrid", 0x78},
+		{"two frames", 0x49},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			packets := silkPackets(1, 5)
+			packets[3].Payload = append([]byte{tt.toc}, silkFrame[1:]...)
+			if _, ok := encodeResampledAudio(packets); ok {
+				t.Errorf("a %#x packet was resampled instead of sent as Opus", tt.toc)
+			}
+		})
+	}
+}
+
+func TestEncodeTranscriptionAudioFallsBackToOpus(t *testing.T) {
+	p := New(false, &fakeTranscriber{}, Options{Ephemeral: true, ResampleTranscription: true})
+
+	data, err := p.encodeTranscriptionAudio(1, testPackets(1, 5))
+	if err != nil {
+		t.Fatalf("encodeTranscriptionAudio: %v", err)
+	}
+	if !bytes.HasPrefix(data, []byte("OggS")) {
+		t.Errorf("stereo CELT audio was sent as %q, want an OGG stream", data[:min(4, len(data))])
+	}
+
+	data, err = p.encodeTranscriptionAudio(1, silkPackets(1, 5))
+	if err != nil {
+		t.Fatalf("encodeTranscriptionAudio: %v", err)
+	}
+	if !bytes.HasPrefix(data, []byte("RIFF")) {
+		t.Errorf("SILK audio was sent as %q, want a WAV file", data[:min(4, len(data))])
+	}
+}
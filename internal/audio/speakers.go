@@ -0,0 +1,176 @@
+package audio
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// maxFileNameLength caps the length of a username used in a filename
+const maxFileNameLength = 32
+
+// speakerMapEntry describes one recording in a session's speaker map file
+type speakerMapEntry struct {
+	SSRC   uint32 `json:"ssrc"`
+	File   string `json:"file"`
+	UserID string `json:"user_id,omitempty"`
+	Name   string `json:"name,omitempty"`
+}
+
+// Recording is an OGG recording of one speaker
+type Recording struct {
+	Path       string
+	InProgress bool // The file is still being written by the current session
+}
+
+// RecordingsForUser returns the user's recordings from the current session, or from the
+// last session if the bot isn't currently recording
+func (p *Processor) RecordingsForUser(userID string) []Recording {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	if !p.isProcessing {
+		recordings := make([]Recording, 0, len(p.lastSessionRecordings[userID]))
+		for _, path := range p.lastSessionRecordings[userID] {
+			recordings = append(recordings, Recording{Path: path})
+		}
+		return recordings
+	}
+
+	var recordings []Recording
+	for ssrc, path := range p.oggFilePaths {
+		if p.ssrcUsers[ssrc] == userID {
+			recordings = append(recordings, Recording{Path: path, InProgress: true})
+		}
+	}
+	return recordings
+}
+
+// rememberSessionRecordings keeps the finished session's recordings by user so they can be
+// fetched after the bot leaves. The caller must hold the mutex.
+func (p *Processor) rememberSessionRecordings() {
+	p.lastSessionRecordings = make(map[string][]string)
+	for ssrc, path := range p.oggFilePaths {
+		if userID, ok := p.ssrcUsers[ssrc]; ok {
+			p.lastSessionRecordings[userID] = append(p.lastSessionRecordings[userID], path)
+		}
+	}
+}
+
+// SetUserNameResolver sets the function used to turn Discord user IDs into display names
+func (p *Processor) SetUserNameResolver(resolver func(userID string) string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.userNameResolver = resolver
+}
+
+// SetCampaign sets the campaign name used to prefix the session's files ("" for none)
+func (p *Processor) SetCampaign(name string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.campaign = name
+}
+
+// fileStem returns the start of the name of every file written for a session, e.g.
+// "audio_20240102_150405", prefixed with the campaign name if there is one
+func (p *Processor) fileStem(t time.Time) string {
+	p.mutex.RLock()
+	campaign := sanitizeFileName(p.campaign)
+	p.mutex.RUnlock()
+
+	stem := "audio_" + t.Format("20060102_150405")
+	if campaign != "" {
+		stem = campaign + "_" + stem
+	}
+	return stem
+}
+
+// recordingFileName returns the OGG filename for an SSRC, using the speaker's name if it's known
+func (p *Processor) recordingFileName(ssrc uint32) string {
+	stem := p.fileStem(p.options.Clock.Now())
+
+	name := sanitizeFileName(p.resolveSSRCName(ssrc))
+	if name == "" {
+		return filepath.Join(p.options.Dir, fmt.Sprintf("%s_%d.ogg", stem, ssrc))
+	}
+
+	// Two speakers can share a display name; keep their files apart
+	filename := filepath.Join(p.options.Dir, fmt.Sprintf("%s_%s.ogg", stem, name))
+	if _, err := os.Stat(filename); err == nil {
+		filename = filepath.Join(p.options.Dir, fmt.Sprintf("%s_%s_%d.ogg", stem, name, ssrc))
+	}
+	return filename
+}
+
+// resolveSSRCName returns the display name of the user on an SSRC, or "" if unknown
+func (p *Processor) resolveSSRCName(ssrc uint32) string {
+	p.mutex.RLock()
+	userID, known := p.ssrcUsers[ssrc]
+	resolver := p.userNameResolver
+	p.mutex.RUnlock()
+
+	if !known || resolver == nil {
+		return ""
+	}
+	return resolver(userID)
+}
+
+// writeSpeakerMap records which user each of the session's recordings belongs to.
+// Names often resolve after a file is created, so the map is rewritten as they do.
+func (p *Processor) writeSpeakerMap() {
+	if p.options.Ephemeral {
+		return
+	}
+
+	p.mutex.RLock()
+	entries := make([]speakerMapEntry, 0, len(p.oggFilePaths))
+	for ssrc, file := range p.oggFilePaths {
+		entries = append(entries, speakerMapEntry{SSRC: ssrc, File: filepath.Base(file), UserID: p.ssrcUsers[ssrc]})
+	}
+	sessionStart := p.sessionStart
+	p.mutex.RUnlock()
+	filename := filepath.Join(p.options.Dir, p.fileStem(sessionStart)+"_speakers.json")
+
+	if len(entries) == 0 {
+		return
+	}
+
+	for i := range entries {
+		entries[i].Name = p.resolveSSRCName(entries[i].SSRC)
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		log.Printf("[AUDIO] ⚠️ Failed to encode speaker map: %v", err)
+		return
+	}
+
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		log.Printf("[AUDIO] ⚠️ Failed to write speaker map %s: %v", filename, err)
+	} else if p.debug.Load() {
+		log.Printf("[AUDIO] 📁 Wrote speaker map %s (%d recordings)", filename, len(entries))
+	}
+}
+
+// sanitizeFileName reduces a name to characters that are safe in filenames on all platforms
+func sanitizeFileName(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			b.WriteRune(r)
+		case r == ' ' || r == '.':
+			b.WriteRune('_')
+		}
+	}
+
+	sanitized := strings.Trim(b.String(), "_-")
+	if len(sanitized) > maxFileNameLength {
+		sanitized = sanitized[:maxFileNameLength]
+	}
+	return sanitized
+}
@@ -0,0 +1,137 @@
+package audio
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"dnd_dm_assistant_go/internal/speech"
+
+	speechpb "cloud.google.com/go/speech/apiv1p1beta1/speechpb"
+	"github.com/pion/rtp"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// Gaps between packets at least this long count as pauses a buffer can be split at
+const minSplitPause = 200 * time.Millisecond
+
+// rtpElapsed converts the difference between two RTP timestamps into a duration
+func rtpElapsed(from, to uint32) time.Duration {
+	return time.Duration(to-from) * time.Second / discordSampleRate
+}
+
+// splitBatch splits a long buffer into segments of at most SegmentLength so they can be
+// transcribed in parallel. Cuts are made at the last pause in each segment, or mid-speech
+// if the speaker never paused. Short buffers, or all of them when splitting is off, are
+// returned as a single segment.
+func (p *Processor) splitBatch(packets []*rtp.Packet) [][]*rtp.Packet {
+	maxLength := p.options.SegmentLength
+	if maxLength <= 0 || len(packets) < 2 || rtpElapsed(packets[0].Timestamp, packets[len(packets)-1].Timestamp) <= maxLength {
+		return [][]*rtp.Packet{packets}
+	}
+
+	var segments [][]*rtp.Packet
+	start, lastPause := 0, -1
+	for i := 1; i < len(packets); i++ {
+		if rtpElapsed(packets[i-1].Timestamp, packets[i].Timestamp) >= minSplitPause {
+			lastPause = i
+		}
+		if rtpElapsed(packets[start].Timestamp, packets[i].Timestamp) < maxLength {
+			continue
+		}
+
+		cut := i
+		if lastPause > start {
+			cut = lastPause
+		}
+		segments = append(segments, packets[start:cut])
+		start, lastPause = cut, -1
+	}
+	return append(segments, packets[start:])
+}
+
+// recognizeSegments transcribes each segment's audio, at most SegmentConcurrency at a time,
+// and joins the results in order. Word offsets are shifted by each segment's offset so
+// they stay relative to the start of the whole buffer. On failure it returns the index of
+// the first segment that failed.
+func (p *Processor) recognizeSegments(audio [][]byte, offsets []time.Duration) (*speech.TranscriptionResult, int, error) {
+	if len(audio) == 1 {
+		result, err := p.speechService.RecognizeAudio(audio[0])
+		return result, 0, err
+	}
+
+	results := make([]*speech.TranscriptionResult, len(audio))
+	errs := make([]error, len(audio))
+
+	slots := make(chan struct{}, max(p.options.SegmentConcurrency, 1))
+	var wg sync.WaitGroup
+	for i := range audio {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			results[i], errs[i] = p.speechService.RecognizeAudio(audio[i])
+		}()
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, i, err
+		}
+	}
+
+	if p.debug.Load() {
+		log.Printf("[AUDIO] 🔍 Transcribed %d segments in parallel", len(audio))
+	}
+	return joinResults(results, offsets), 0, nil
+}
+
+// joinResults combines segment transcriptions into one, in order. The confidence is the
+// average over segments that produced text. Word timings are kept only if every segment
+// with text has them, so subtitles never mix timed and untimed words.
+func joinResults(results []*speech.TranscriptionResult, offsets []time.Duration) *speech.TranscriptionResult {
+	joined := &speech.TranscriptionResult{IsFinal: true}
+	var texts []string
+	var confidence float32
+	timed := true
+
+	for i, result := range results {
+		if result == nil || strings.TrimSpace(result.Transcript) == "" {
+			continue
+		}
+		texts = append(texts, strings.TrimSpace(result.Transcript))
+		confidence += result.Confidence
+		if joined.Language == "" {
+			joined.Language = result.Language
+		}
+
+		if len(result.WordDetails) == 0 {
+			timed = false
+		}
+		for _, word := range result.WordDetails {
+			if word.GetStartTime() == nil || word.GetEndTime() == nil {
+				timed = false
+				break
+			}
+			joined.WordDetails = append(joined.WordDetails, &speechpb.WordInfo{
+				Word:       word.GetWord(),
+				Confidence: word.GetConfidence(),
+				StartTime:  durationpb.New(offsets[i] + word.GetStartTime().AsDuration()),
+				EndTime:    durationpb.New(offsets[i] + word.GetEndTime().AsDuration()),
+			})
+		}
+	}
+
+	if len(texts) == 0 {
+		return nil
+	}
+	joined.Transcript = strings.Join(texts, " ")
+	joined.Confidence = confidence / float32(len(texts))
+	if !timed {
+		joined.WordDetails = nil
+	}
+	return joined
+}
@@ -0,0 +1,273 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/pion/rtp"
+)
+
+const (
+	// How often the spool is checked for batches that can be transcribed now
+	spoolPollInterval = time.Second
+
+	// Times a spooled batch is tried before it's deleted untranscribed
+	maxSpoolAttempts = 3
+
+	// Batches waiting for the spool worker to write them; more are dropped
+	spoolWriteQueueSize = 16
+)
+
+// spooledBatch is a transcription batch saved to disk because its SSRC's queue was full
+type spooledBatch struct {
+	SSRC    uint32
+	Start   time.Time
+	Packets [][]byte // Marshaled RTP packets
+}
+
+// spoolWrite is a batch waiting to be written to the spool, named when it was spooled
+type spoolWrite struct {
+	dir   string
+	name  string
+	ssrc  uint32
+	batch audioBatch
+}
+
+// spoolEnabled reports whether full transcription queues spill to disk
+func (p *Processor) spoolEnabled() bool {
+	return p.options.SpoolDir != "" && !p.options.Ephemeral
+}
+
+// sessionSpoolDir returns the spool directory for the guild of the current session. The caller
+// must hold the mutex.
+func (p *Processor) sessionSpoolDir() string {
+	return filepath.Join(p.options.SpoolDir, p.sessionGuildID)
+}
+
+// spoolBatch queues a batch that didn't fit in the transcription queue for the spool worker to
+// write, so the packet path doesn't wait on the disk. It reports whether the batch was queued.
+// The caller must hold the mutex.
+func (p *Processor) spoolBatch(ssrc uint32, batch audioBatch) bool {
+	write := spoolWrite{
+		dir:   p.sessionSpoolDir(),
+		name:  fmt.Sprintf("spool_%d_%d.gob", p.options.Clock.Now().UnixNano(), ssrc),
+		ssrc:  ssrc,
+		batch: batch,
+	}
+	select {
+	case p.spoolWrites <- write:
+		return true
+	default:
+		return false
+	}
+}
+
+// writeSpooledBatch saves a queued batch to disk, unless the spool is full
+func (p *Processor) writeSpooledBatch(write spoolWrite) error {
+	spooled := spooledBatch{SSRC: write.ssrc, Start: write.batch.start, Packets: make([][]byte, 0, len(write.batch.packets))}
+	for _, packet := range write.batch.packets {
+		data, err := packet.Marshal()
+		if err != nil {
+			return fmt.Errorf("encoding packet: %w", err)
+		}
+		spooled.Packets = append(spooled.Packets, data)
+	}
+
+	var buffer bytes.Buffer
+	if err := gob.NewEncoder(&buffer).Encode(spooled); err != nil {
+		return fmt.Errorf("encoding batch: %w", err)
+	}
+
+	if err := os.MkdirAll(write.dir, 0755); err != nil {
+		return err
+	}
+	if size := spoolSize(write.dir); size+int64(buffer.Len()) > p.options.SpoolMaxBytes {
+		return fmt.Errorf("spool is full (%d of %d bytes used)", size, p.options.SpoolMaxBytes)
+	}
+	return os.WriteFile(filepath.Join(write.dir, write.name), buffer.Bytes(), 0644)
+}
+
+// writeQueuedSpool writes every batch waiting in the spool queue. Only the spool worker, or
+// StopProcessing once the worker has exited, calls it, so the spool size check can't race.
+func (p *Processor) writeQueuedSpool() {
+	for {
+		select {
+		case write := <-p.spoolWrites:
+			p.writeSpool(write)
+		default:
+			return
+		}
+	}
+}
+
+// writeSpool writes a queued batch, logging it if it had to be dropped
+func (p *Processor) writeSpool(write spoolWrite) {
+	if err := p.writeSpooledBatch(write); err != nil {
+		log.Printf("[AUDIO] ⚠️ Couldn't spool %d packets for SSRC %d, dropping them: %v", len(write.batch.packets), write.ssrc, err)
+	} else if p.debug.Load() {
+		log.Printf("[AUDIO] 💾 Spooled %d packets for SSRC %d to disk", len(write.batch.packets), write.ssrc)
+	}
+}
+
+// spoolSize returns the total size of the spooled batches in a directory
+func spoolSize(dir string) int64 {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0
+	}
+
+	var size int64
+	for _, entry := range entries {
+		if info, err := entry.Info(); err == nil {
+			size += info.Size()
+		}
+	}
+	return size
+}
+
+// spoolWorker writes queued batches to the spool and transcribes spooled batches in the
+// background while processing is running
+func (p *Processor) spoolWorker(stopped <-chan struct{}) {
+	defer p.loops.Done()
+
+	ticker := time.NewTicker(spoolPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopped:
+			p.writeQueuedSpool()
+			return
+		case write := <-p.spoolWrites:
+			p.writeSpool(write)
+		case <-ticker.C:
+			p.drainSpool()
+		}
+	}
+}
+
+// drainSpool hands each SSRC's oldest spooled batch to transcription, once its queue is empty
+func (p *Processor) drainSpool() {
+	p.mutex.RLock()
+	dir := p.sessionSpoolDir()
+	canTranscribe := p.canTranscribe()
+	p.mutex.RUnlock()
+
+	if !canTranscribe {
+		return
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "spool_*.gob"))
+	sort.Strings(files)
+
+	// Keep each SSRC's batches in order: only its oldest may go, and only once the last is done
+	seen := make(map[uint32]bool)
+	for _, path := range files {
+		spooled, err := readSpooledBatch(path)
+		if err != nil {
+			log.Printf("[AUDIO] ⚠️ Discarding unreadable spooled batch %s: %v", path, err)
+			os.Remove(path)
+			continue
+		}
+		if seen[spooled.SSRC] {
+			continue
+		}
+		seen[spooled.SSRC] = true
+
+		batch := audioBatch{start: spooled.Start, delayed: true, spoolPath: path}
+		for _, data := range spooled.Packets {
+			packet := &rtp.Packet{}
+			if err := packet.Unmarshal(data); err != nil {
+				continue
+			}
+			batch.packets = append(batch.packets, packet)
+		}
+
+		if p.queueSpooledBatch(spooled.SSRC, batch) && p.debug.Load() {
+			log.Printf("[AUDIO] 📤 Transcribing spooled batch of %d packets for SSRC %d", len(batch.packets), spooled.SSRC)
+		}
+	}
+}
+
+// queueSpooledBatch sends a spooled batch to its SSRC's transcription worker, sequenced after
+// the batches already queued, unless live speech is waiting there or another spooled batch of the
+// SSRC is still in flight. SSRCs outside the current session have no worker, so their batches are
+// transcribed in the background instead. It reports whether the batch was taken.
+func (p *Processor) queueSpooledBatch(ssrc uint32, batch audioBatch) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.spoolInFlight[ssrc] {
+		return false
+	}
+
+	queue, active := p.transcriptionChans[ssrc]
+	if active && len(queue) > 0 {
+		return false
+	}
+
+	queued := p.order.enqueue(ssrc, func(seq uint64) bool {
+		batch.seq = seq
+		if !active {
+			return true
+		}
+		select {
+		case queue <- batch:
+			return true
+		default:
+			return false
+		}
+	})
+	if !queued {
+		return false
+	}
+
+	p.spoolInFlight[ssrc] = true
+	if !active {
+		go p.runBatch(ssrc, batch)
+	}
+	return true
+}
+
+// finishSpooledBatch removes a spooled batch's file once it has been transcribed. A failed batch
+// is left to be retried, up to maxSpoolAttempts times.
+func (p *Processor) finishSpooledBatch(ssrc uint32, path string, ok bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	delete(p.spoolInFlight, ssrc)
+	if !ok {
+		p.spoolFailures[path]++
+		if p.spoolFailures[path] < maxSpoolAttempts {
+			log.Printf("[AUDIO] ⚠️ Spooled batch %s failed to transcribe, will retry (%d of %d attempts)",
+				path, p.spoolFailures[path], maxSpoolAttempts)
+			return
+		}
+		log.Printf("[AUDIO] ⚠️ Giving up on spooled batch %s after %d attempts", path, maxSpoolAttempts)
+	}
+
+	delete(p.spoolFailures, path)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		log.Printf("[AUDIO] ⚠️ Couldn't remove spooled batch %s: %v", path, err)
+	}
+}
+
+// readSpooledBatch loads a spooled batch from disk
+func readSpooledBatch(path string) (spooledBatch, error) {
+	var spooled spooledBatch
+
+	file, err := os.Open(path)
+	if err != nil {
+		return spooled, err
+	}
+	defer file.Close()
+
+	err = gob.NewDecoder(file).Decode(&spooled)
+	return spooled, err
+}
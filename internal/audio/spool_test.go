@@ -0,0 +1,182 @@
+package audio
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"dnd_dm_assistant_go/internal/speech"
+)
+
+// newSpoolProcessor returns a processor spooling to a temporary directory, outside any session
+func newSpoolProcessor(t *testing.T, transcriber speech.Transcriber) *Processor {
+	t.Helper()
+	p := New(false, transcriber, Options{
+		Ephemeral:     true,
+		SpoolDir:      t.TempDir(),
+		SpoolMaxBytes: 1 << 20,
+		Clock:         newFakeClock(),
+	})
+	p.sessionGuildID = "guild"
+	return p
+}
+
+// spool queues a batch as the packet path does and writes it as the spool worker would
+func spool(t *testing.T, p *Processor, ssrc uint32, batch audioBatch) {
+	t.Helper()
+	p.mutex.Lock()
+	queued := p.spoolBatch(ssrc, batch)
+	p.mutex.Unlock()
+	if !queued {
+		t.Fatal("spool queue is full")
+	}
+	p.writeQueuedSpool()
+}
+
+// spoolFiles returns the batches waiting in the processor's spool
+func spoolFiles(t *testing.T, p *Processor) []string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(p.sessionSpoolDir(), "spool_*.gob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+// spoolInFlight reports whether a spooled batch of the SSRC is being transcribed
+func spoolInFlight(p *Processor, ssrc uint32) bool {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return p.spoolInFlight[ssrc]
+}
+
+type delivery struct {
+	text    string
+	delayed bool
+}
+
+func TestSpooledBatchKeptUntilTranscribed(t *testing.T) {
+	fake := &fakeTranscriber{}
+	fake.recognize = func([]byte) (*speech.TranscriptionResult, error) {
+		if fake.calls() == 1 {
+			return nil, errors.New("backend unavailable")
+		}
+		return &speech.TranscriptionResult{Transcript: "from the spool"}, nil
+	}
+	p := newSpoolProcessor(t, fake)
+	deliveries := make(chan delivery, 1)
+	p.SetTranscriptionCallback(func(ssrc uint32, text string, confidence float64, delayed bool) {
+		deliveries <- delivery{text, delayed}
+	})
+
+	spool(t, p, 1, audioBatch{packets: testPackets(1, 10)})
+
+	// The first attempt fails; the file must survive for a retry
+	p.drainSpool()
+	waitFor(t, "the failed attempt", func() bool { return !spoolInFlight(p, 1) })
+	if files := spoolFiles(t, p); len(files) != 1 {
+		t.Fatalf("spool has %d files after a failed attempt, want 1", len(files))
+	}
+
+	p.drainSpool()
+	got := <-deliveries
+	if got.text != "from the spool" || !got.delayed {
+		t.Errorf("delivered %+v, want the spooled transcript marked delayed", got)
+	}
+	waitFor(t, "the spool file to be removed", func() bool { return len(spoolFiles(t, p)) == 0 })
+}
+
+func TestSpooledBatchGivenUpAfterMaxAttempts(t *testing.T) {
+	fake := &fakeTranscriber{recognize: func([]byte) (*speech.TranscriptionResult, error) {
+		return nil, errors.New("backend unavailable")
+	}}
+	p := newSpoolProcessor(t, fake)
+
+	spool(t, p, 1, audioBatch{packets: testPackets(1, 10)})
+
+	for attempt := 1; attempt <= maxSpoolAttempts; attempt++ {
+		p.drainSpool()
+		waitFor(t, "the attempt to finish", func() bool { return fake.calls() == attempt && !spoolInFlight(p, 1) })
+	}
+	waitFor(t, "the spool file to be removed", func() bool { return len(spoolFiles(t, p)) == 0 })
+}
+
+func TestSpooledBatchQueuedBehindLiveSpeech(t *testing.T) {
+	release := make(chan struct{})
+	fake := &fakeTranscriber{}
+	fake.recognize = func([]byte) (*speech.TranscriptionResult, error) {
+		if fake.calls() == 1 {
+			<-release
+			return &speech.TranscriptionResult{Transcript: "live speech"}, nil
+		}
+		return &speech.TranscriptionResult{Transcript: "from the spool"}, nil
+	}
+	p := newSpoolProcessor(t, fake)
+	deliveries := make(chan delivery, 2)
+	p.SetTranscriptionCallback(func(ssrc uint32, text string, confidence float64, delayed bool) {
+		deliveries <- delivery{text, delayed}
+	})
+	if !p.startSSRC(1) {
+		t.Fatal("startSSRC failed")
+	}
+	defer func() {
+		p.mutex.Lock()
+		close(p.transcriptionChans[1])
+		p.mutex.Unlock()
+	}()
+
+	p.mutex.Lock()
+	p.audioBuffers[1] = testPackets(1, 10)
+	queued := p.sendAudioBuffer(1, nil)
+	p.spoolBatch(1, audioBatch{packets: testPackets(1, 10)})
+	p.mutex.Unlock()
+	if !queued {
+		t.Fatal("live buffer wasn't queued")
+	}
+	p.writeQueuedSpool()
+
+	// Once the worker has taken the live batch the queue is empty and the spooled one can follow
+	waitFor(t, "the live batch to be taken", func() bool { return fake.calls() == 1 })
+	p.drainSpool()
+	if !spoolInFlight(p, 1) {
+		t.Fatal("spooled batch wasn't queued")
+	}
+
+	close(release)
+	want := []delivery{{"live speech", false}, {"from the spool", true}}
+	for _, w := range want {
+		if got := <-deliveries; got != w {
+			t.Errorf("delivered %+v, want %+v", got, w)
+		}
+	}
+	waitFor(t, "the spool file to be removed", func() bool { return len(spoolFiles(t, p)) == 0 })
+}
+
+func TestSpoolBatchWrittenOutsideTheLock(t *testing.T) {
+	p := newSpoolProcessor(t, &fakeTranscriber{})
+	clock := p.options.Clock.(*fakeClock)
+
+	p.mutex.Lock()
+	for range spoolWriteQueueSize {
+		if !p.spoolBatch(1, audioBatch{packets: testPackets(1, 10)}) {
+			t.Fatal("spool queue filled early")
+		}
+		clock.advance(time.Millisecond) // Spool files are named by time
+	}
+	full := !p.spoolBatch(1, audioBatch{packets: testPackets(1, 10)})
+	written := len(spoolFiles(t, p))
+	p.mutex.Unlock()
+
+	if !full {
+		t.Error("a batch was queued past the spool queue's size")
+	}
+	if written != 0 {
+		t.Errorf("%d batches were written while the session was locked", written)
+	}
+
+	p.writeQueuedSpool()
+	if files := spoolFiles(t, p); len(files) != spoolWriteQueueSize {
+		t.Errorf("spool has %d files, want %d", len(files), spoolWriteQueueSize)
+	}
+}
@@ -0,0 +1,170 @@
+package audio
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"dnd_dm_assistant_go/internal/speech"
+)
+
+// Subtitle formats supported by ExportSubtitles
+const (
+	SubtitleFormatVTT = "vtt"
+	SubtitleFormatSRT = "srt"
+)
+
+const (
+	// Split long utterances into cues of at most this many words
+	maxCueWords = 10
+
+	// Start a new cue when the speaker pauses for this long between words
+	cuePauseThreshold = time.Second
+)
+
+// Cue is one timed line of a subtitle track, relative to the session start
+type Cue struct {
+	Start time.Duration
+	End   time.Duration
+	Text  string
+}
+
+// SubtitleFile is an exported subtitle track for one speaker
+type SubtitleFile struct {
+	Name string
+	Data []byte
+}
+
+// addSubtitleCues records a transcription's cues, using word time offsets when the backend
+// provides them and the whole utterance otherwise
+func (p *Processor) addSubtitleCues(ssrc uint32, batch audioBatch, result *speech.TranscriptionResult) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	// Batches with no start time predate tracking; there's nothing to align them to
+	if batch.start.IsZero() || p.subtitleCues == nil {
+		return
+	}
+	offset := batch.start.Sub(p.sessionStart)
+
+	var cues []Cue
+	var words []string
+	var cueStart, lastEnd time.Duration
+	for _, word := range result.WordDetails {
+		if word.GetStartTime() == nil || word.GetEndTime() == nil {
+			// Missing offsets: fall back to a single cue for the utterance
+			cues = nil
+			words = nil
+			break
+		}
+
+		start := word.GetStartTime().AsDuration()
+		end := word.GetEndTime().AsDuration()
+		if len(words) > 0 && (len(words) >= maxCueWords || start-lastEnd >= cuePauseThreshold) {
+			cues = append(cues, Cue{Start: offset + cueStart, End: offset + lastEnd, Text: strings.Join(words, " ")})
+			words = nil
+		}
+		if len(words) == 0 {
+			cueStart = start
+		}
+		words = append(words, word.GetWord())
+		lastEnd = end
+	}
+	if len(words) > 0 {
+		cues = append(cues, Cue{Start: offset + cueStart, End: offset + lastEnd, Text: strings.Join(words, " ")})
+	}
+
+	if len(cues) == 0 {
+		duration := time.Duration(len(batch.packets)*opusPacketDurationMs) * time.Millisecond
+		cues = []Cue{{Start: offset, End: offset + duration, Text: result.Transcript}}
+	}
+
+	p.subtitleCues[ssrc] = append(p.subtitleCues[ssrc], cues...)
+}
+
+// checkSubtitleFormat returns an error if subtitles can't be rendered in the format
+func checkSubtitleFormat(format string) error {
+	if format != SubtitleFormatVTT && format != SubtitleFormatSRT {
+		return fmt.Errorf("unknown subtitle format %q (use %s or %s)", format, SubtitleFormatVTT, SubtitleFormatSRT)
+	}
+	return nil
+}
+
+// ExportSubtitles returns a subtitle file per speaker for the current or last session
+func (p *Processor) ExportSubtitles(format string) ([]SubtitleFile, error) {
+	if err := checkSubtitleFormat(format); err != nil {
+		return nil, err
+	}
+
+	p.mutex.RLock()
+	tracks := make(map[uint32][]Cue, len(p.subtitleCues))
+	for ssrc, cues := range p.subtitleCues {
+		tracks[ssrc] = append([]Cue(nil), cues...)
+	}
+	sessionStart := p.sessionStart
+	p.mutex.RUnlock()
+	stem := p.fileStem(sessionStart)
+
+	ssrcs := make([]uint32, 0, len(tracks))
+	for ssrc := range tracks {
+		ssrcs = append(ssrcs, ssrc)
+	}
+	slices.Sort(ssrcs)
+
+	files := make([]SubtitleFile, 0, len(tracks))
+	for _, ssrc := range ssrcs {
+		cues := tracks[ssrc]
+
+		// Two speakers can share a display name, so the SSRC keeps their files apart
+		name := fmt.Sprintf("%d", ssrc)
+		if speaker := sanitizeFileName(p.resolveSSRCName(ssrc)); speaker != "" {
+			name = fmt.Sprintf("%s_%d", speaker, ssrc)
+		}
+
+		var data string
+		if format == SubtitleFormatVTT {
+			data = formatWebVTT(cues)
+		} else {
+			data = formatSRT(cues)
+		}
+
+		files = append(files, SubtitleFile{
+			Name: fmt.Sprintf("%s_%s.%s", stem, name, format),
+			Data: []byte(data),
+		})
+	}
+
+	return files, nil
+}
+
+// formatWebVTT renders cues as a WebVTT file
+func formatWebVTT(cues []Cue) string {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n\n")
+	for _, cue := range cues {
+		fmt.Fprintf(&b, "%s --> %s\n%s\n\n", formatCueTime(cue.Start, "."), formatCueTime(cue.End, "."), cue.Text)
+	}
+	return b.String()
+}
+
+// formatSRT renders cues as a SubRip file
+func formatSRT(cues []Cue) string {
+	var b strings.Builder
+	for i, cue := range cues {
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n", i+1, formatCueTime(cue.Start, ","), formatCueTime(cue.End, ","), cue.Text)
+	}
+	return b.String()
+}
+
+// formatCueTime formats a duration as HH:MM:SS.mmm, with the given millisecond separator
+func formatCueTime(d time.Duration, separator string) string {
+	if d < 0 {
+		d = 0
+	}
+	hours := d / time.Hour
+	minutes := (d % time.Hour) / time.Minute
+	seconds := (d % time.Minute) / time.Second
+	millis := (d % time.Second) / time.Millisecond
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", hours, minutes, seconds, separator, millis)
+}
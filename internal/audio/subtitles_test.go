@@ -0,0 +1,43 @@
+package audio
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExportSubtitlesSameDisplayName(t *testing.T) {
+	p := New(false, nil, Options{Ephemeral: true, Clock: newFakeClock()})
+	p.sessionStart = time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	p.SetSSRCUser(1, "alice-1")
+	p.SetSSRCUser(2, "alice-2")
+	p.SetSSRCUser(3, "bob")
+	p.SetUserNameResolver(func(userID string) string {
+		if userID == "bob" {
+			return "Bob"
+		}
+		return "Alice"
+	})
+	for ssrc := range uint32(4) {
+		p.subtitleCues[ssrc+1] = []Cue{{Start: 0, End: time.Second, Text: "hello"}}
+	}
+
+	files, err := p.ExportSubtitles(SubtitleFormatSRT)
+	if err != nil {
+		t.Fatalf("ExportSubtitles: %v", err)
+	}
+
+	want := []string{
+		"audio_20240102_150405_Alice_1.srt",
+		"audio_20240102_150405_Alice_2.srt",
+		"audio_20240102_150405_Bob_3.srt",
+		"audio_20240102_150405_4.srt",
+	}
+	if len(files) != len(want) {
+		t.Fatalf("got %d files, want %d", len(files), len(want))
+	}
+	for i, file := range files {
+		if file.Name != want[i] {
+			t.Errorf("file %d = %s, want %s", i, file.Name, want[i])
+		}
+	}
+}
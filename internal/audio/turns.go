@@ -0,0 +1,147 @@
+package audio
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"dnd_dm_assistant_go/internal/speech"
+)
+
+// turn is one transcribed utterance, timed relative to the session start
+type turn struct {
+	ssrc       uint32
+	start      time.Duration
+	end        time.Duration
+	text       string
+	confidence float64
+}
+
+// SpeakingTurn is one utterance in an exported transcript
+type SpeakingTurn struct {
+	Speaker    string    `json:"speaker"`
+	UserID     string    `json:"user_id,omitempty"`
+	SSRC       uint32    `json:"ssrc"`
+	Text       string    `json:"text"`
+	Confidence float64   `json:"confidence"`
+	StartTime  time.Time `json:"start_time"`
+	EndTime    time.Time `json:"end_time"`
+}
+
+// TurnTranscript is a session's utterances in speaking order, for analysis tooling
+type TurnTranscript struct {
+	Campaign  string         `json:"campaign,omitempty"`
+	GuildID   string         `json:"guild_id"`
+	ChannelID string         `json:"channel_id"`
+	StartTime time.Time      `json:"start_time"`
+	Turns     []SpeakingTurn `json:"turns"`
+}
+
+// FileName returns a name for the transcript's JSON file, prefixed with the campaign if there is one
+func (t TurnTranscript) FileName() string {
+	name := fmt.Sprintf("turns_%s_%s.json", t.StartTime.Format("20060102_150405"), t.GuildID)
+	if campaign := sanitizeFileName(t.Campaign); campaign != "" {
+		name = campaign + "_" + name
+	}
+	return name
+}
+
+// TextFileName returns a name for the transcript's plain text file, prefixed with the campaign if there is one
+func (t TurnTranscript) TextFileName() string {
+	name := fmt.Sprintf("transcript_%s_%s.txt", t.StartTime.Format("20060102_150405"), t.GuildID)
+	if campaign := sanitizeFileName(t.Campaign); campaign != "" {
+		name = campaign + "_" + name
+	}
+	return name
+}
+
+// Text renders the transcript as plain text, one line per utterance with the time since the
+// session started and the speaker's name
+func (t TurnTranscript) Text() string {
+	var text strings.Builder
+	if t.Campaign != "" {
+		fmt.Fprintf(&text, "Campaign: %s\n", t.Campaign)
+	}
+	fmt.Fprintf(&text, "Session started: %s\n\n", t.StartTime.Format(time.RFC1123))
+	for _, turn := range t.Turns {
+		elapsed := turn.StartTime.Sub(t.StartTime)
+		fmt.Fprintf(&text, "[%s] %s: %s\n", formatElapsed(elapsed), turn.Speaker, turn.Text)
+	}
+	return text.String()
+}
+
+// formatElapsed formats a session offset as H:MM:SS
+func formatElapsed(d time.Duration) string {
+	d = max(d, 0).Round(time.Second)
+	return fmt.Sprintf("%d:%02d:%02d", int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60)
+}
+
+// addTurn records a transcription as a speaking turn, timed by its first and last words when
+// the backend provides word offsets and by the audio it was transcribed from otherwise
+func (p *Processor) addTurn(ssrc uint32, batch audioBatch, result *speech.TranscriptionResult) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	// Batches with no start time predate tracking; there's nothing to align them to
+	if batch.start.IsZero() {
+		return
+	}
+	offset := batch.start.Sub(p.sessionStart)
+
+	start := offset
+	end := offset + time.Duration(len(batch.packets)*opusPacketDurationMs)*time.Millisecond
+	if words := result.WordDetails; len(words) > 0 {
+		first, last := words[0], words[len(words)-1]
+		if first.GetStartTime() != nil && last.GetEndTime() != nil {
+			start = offset + first.GetStartTime().AsDuration()
+			end = offset + last.GetEndTime().AsDuration()
+		}
+	}
+
+	p.turns = append(p.turns, turn{
+		ssrc:       ssrc,
+		start:      start,
+		end:        end,
+		text:       result.Transcript,
+		confidence: float64(result.Confidence),
+	})
+}
+
+// ExportTurns returns the current or last session's utterances from every speaker, ordered
+// by when they started. It returns false if nothing was transcribed.
+func (p *Processor) ExportTurns() (TurnTranscript, bool) {
+	p.mutex.RLock()
+	turns := slices.Clone(p.turns)
+	transcript := TurnTranscript{
+		Campaign:  p.campaign,
+		GuildID:   p.sessionGuildID,
+		ChannelID: p.sessionChannelID,
+		StartTime: p.sessionStart,
+	}
+	p.mutex.RUnlock()
+
+	if len(turns) == 0 {
+		return transcript, false
+	}
+
+	slices.SortStableFunc(turns, func(a, b turn) int {
+		return cmp.Compare(a.start, b.start)
+	})
+
+	transcript.Turns = make([]SpeakingTurn, 0, len(turns))
+	for _, t := range turns {
+		userID, _ := p.UserForSSRC(t.ssrc)
+		transcript.Turns = append(transcript.Turns, SpeakingTurn{
+			Speaker:    p.resolveSSRCName(t.ssrc),
+			UserID:     userID,
+			SSRC:       t.ssrc,
+			Text:       t.text,
+			Confidence: t.confidence,
+			StartTime:  transcript.StartTime.Add(t.start),
+			EndTime:    transcript.StartTime.Add(t.end),
+		})
+	}
+	return transcript, true
+}
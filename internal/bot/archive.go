@@ -0,0 +1,59 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// handleArchiveCommand zips recordings older than the configured (or given) number of days into
+// one archive and deletes the originals, reporting the space reclaimed
+func (b *Bot) handleArchiveCommand(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+	if !b.requireDM(s, m) {
+		return
+	}
+	if !b.config.Persist {
+		s.ChannelMessageSend(m.ChannelID, "❌ Recordings aren't kept in ephemeral mode.")
+		return
+	}
+
+	days := b.config.ArchiveMinAgeDays
+	if len(args) > 0 {
+		parsed, err := strconv.Atoi(args[0])
+		if err != nil || parsed < 0 {
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Usage: `%s %s [days]`", b.config.CommandPrefix, commandArchive))
+			return
+		}
+		days = parsed
+	}
+
+	s.ChannelTyping(m.ChannelID)
+
+	result, err := b.audioManager.ArchiveRecordings(time.Duration(days) * 24 * time.Hour)
+	if err != nil {
+		log.Printf("Error archiving recordings: %v", err)
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Failed to archive recordings, nothing was deleted: %v", err))
+		return
+	}
+
+	var reply string
+	if result.Path == "" {
+		reply = fmt.Sprintf("ℹ️ No recordings older than %d days to archive.", days)
+	} else {
+		log.Printf("Archived %d recordings into %s for %s", result.Files, result.Path, m.Author.Username)
+		reply = fmt.Sprintf("📦 Archived %d files older than %d days (%.1f MB) into `%s` (%.1f MB), reclaiming %.1f MB.",
+			result.Files, days, float64(result.OriginalSize)/(1<<20), filepath.Base(result.Path),
+			float64(result.ArchiveSize)/(1<<20), float64(result.Reclaimed())/(1<<20))
+		if result.RemoveErrors > 0 {
+			reply += fmt.Sprintf("\n⚠️ %d archived files couldn't be deleted; see the log.", result.RemoveErrors)
+		}
+	}
+	if result.Skipped > 0 {
+		reply += fmt.Sprintf("\nℹ️ Skipped %d files still being recorded.", result.Skipped)
+	}
+	s.ChannelMessageSend(m.ChannelID, reply)
+}
@@ -0,0 +1,59 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"time"
+)
+
+// Longest to wait for the ended session's transcriptions before archiving the conversation
+const autoClearDrainTimeout = 30 * time.Second
+
+// autoClearConversation archives and clears a guild's conversation after the bot left because
+// the DM did, so the next session starts fresh
+func (b *Bot) autoClearConversation(guildID string) {
+	if !b.config.AutoClearOnLeave {
+		return
+	}
+	conversation := b.conversation(guildID)
+	if conversation == nil {
+		return
+	}
+
+	// The session's last words may still be being transcribed; they belong in this archive,
+	// not at the start of the next session
+	if !b.audioManager.WaitForTranscriptions(guildID, autoClearDrainTimeout) {
+		log.Printf("[BOT] ⚠️ Transcriptions still pending after %v, archiving the conversation without them", autoClearDrainTimeout)
+	}
+	b.flushPendingTranscriptions(guildID)
+
+	path, cleared, err := conversation.ArchiveAndClear()
+	if err != nil {
+		log.Printf("[BOT] ⚠️ Failed to archive and clear the conversation: %v", err)
+		b.recordError(componentClaude, fmt.Errorf("auto-clear: %w", err))
+		b.notifyDM(guildID, fmt.Sprintf("⚠️ The session ended, but the conversation couldn't be archived and cleared: %v", err))
+		return
+	}
+
+	switch {
+	case !cleared:
+		// Nothing was said this session
+	case path != "":
+		b.notifyDM(guildID, fmt.Sprintf("📦 The session ended: the conversation was archived to `%s` and cleared for next time.", filepath.Base(path)))
+	default:
+		b.notifyDM(guildID, "🧹 The session ended: the conversation was cleared for next time.")
+	}
+}
+
+// notifyDM sends a short notice to a guild's DM in a private message
+func (b *Bot) notifyDM(guildID, message string) {
+	dmChannel, err := b.session.UserChannelCreate(b.config.DMUserFor(guildID))
+	if err != nil {
+		log.Printf("[BOT] ⚠️ Failed to create DM channel with DM: %v", err)
+		return
+	}
+	if _, err := b.session.ChannelMessageSend(dmChannel.ID, message); err != nil {
+		log.Printf("[BOT] ⚠️ Failed to send notice to DM: %v", err)
+	}
+}
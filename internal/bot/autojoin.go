@@ -0,0 +1,79 @@
+package bot
+
+import (
+	"log"
+	"slices"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// playersPresent reports whether auto-join may go ahead in the guild: always when no player role
+// is configured, otherwise only once someone other than the DM with the role is in the target channel
+func (b *Bot) playersPresent(guildID string) bool {
+	if b.config.PlayerRoleID == "" {
+		return true
+	}
+
+	guild, err := b.session.State.Guild(guildID)
+	if err != nil {
+		log.Printf("Error finding guild %s to check for players: %v", guildID, err)
+		return false
+	}
+
+	b.session.State.RLock()
+	var userIDs []string
+	for _, vs := range guild.VoiceStates {
+		if vs.ChannelID == b.config.VoiceChannelFor(guildID) {
+			userIDs = append(userIDs, vs.UserID)
+		}
+	}
+	b.session.State.RUnlock()
+
+	for _, userID := range userIDs {
+		if b.isDMUser(guildID, userID) || userID == b.session.State.User.ID {
+			continue
+		}
+		if b.hasPlayerRole(guildID, userID) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPlayerRole reports whether a guild member has the configured player role
+func (b *Bot) hasPlayerRole(guildID, userID string) bool {
+	member, err := b.session.State.Member(guildID, userID)
+	if err != nil {
+		// Without the guild members intent the cache may not have them yet, so ask Discord
+		member, err = b.session.GuildMember(guildID, userID)
+		if err != nil {
+			log.Printf("Error fetching member %s to check roles: %v", userID, err)
+			return false
+		}
+	}
+	return slices.Contains(member.Roles, b.config.PlayerRoleID)
+}
+
+// onPlayerJoined auto-joins when a player arrives in the target channel where the DM is
+// already waiting, for when auto-join requires a player role
+func (b *Bot) onPlayerJoined(guildID string) {
+	if b.audioManager.IsProcessingGuild(guildID) {
+		return
+	}
+
+	guild, err := b.session.State.Guild(guildID)
+	if err != nil || !b.isDMInTargetChannel(guild) || !b.playersPresent(guildID) {
+		return
+	}
+
+	log.Printf("A player joined the D&D voice channel with the DM, joining...")
+	b.joinVoiceChannel(guildID, b.config.VoiceChannelFor(guildID))
+}
+
+// isPlayerJoin reports whether a voice state change is someone other than the DM entering the target channel
+func (b *Bot) isPlayerJoin(vsu *discordgo.VoiceStateUpdate) bool {
+	if vsu.ChannelID != b.config.VoiceChannelFor(vsu.GuildID) {
+		return false
+	}
+	return vsu.BeforeUpdate == nil || vsu.BeforeUpdate.ChannelID != vsu.ChannelID
+}
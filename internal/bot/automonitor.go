@@ -0,0 +1,53 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// handleAutoMonitorCommand pauses or resumes joining and leaving when the DM enters or leaves
+// the D&D channel, so the DM can test in the channel without the bot barging in. Manual
+// join and leave keep working.
+func (b *Bot) handleAutoMonitorCommand(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+	if !b.requireDM(s, m) {
+		return
+	}
+
+	if len(args) == 0 {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("👀 Auto-join monitoring is %s. Usage: `%s %s on|off`",
+			onOff(b.autoMonitorEnabled()), b.config.CommandPrefix, commandAutoMonitor))
+		return
+	}
+
+	var enabled bool
+	switch strings.ToLower(args[0]) {
+	case "on", "true", "1":
+		enabled = true
+	case "off", "false", "0":
+		enabled = false
+	default:
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Usage: `%s %s on|off`", b.config.CommandPrefix, commandAutoMonitor))
+		return
+	}
+
+	b.autoMonitorPaused.Store(!enabled)
+
+	log.Printf("Auto-join monitoring turned %s by %s", onOff(enabled), m.Author.Username)
+	if enabled {
+		s.ChannelMessageSend(m.ChannelID, "👀 Auto-join monitoring on: the bot joins and leaves with the DM again.")
+		return
+	}
+
+	// A leave scheduled before pausing would still fire otherwise
+	b.cancelAllLeaves()
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("⏸️ Auto-join monitoring off: the bot won't join or leave on its own until `%s %s on`. `%s %s` and `%s %s` still work.",
+		b.config.CommandPrefix, commandAutoMonitor, b.config.CommandPrefix, commandJoin, b.config.CommandPrefix, commandLeave))
+}
+
+// autoMonitorEnabled reports whether the bot follows the DM into and out of the D&D channel
+func (b *Bot) autoMonitorEnabled() bool {
+	return !b.autoMonitorPaused.Load()
+}
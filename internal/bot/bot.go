@@ -1,22 +1,31 @@
 package bot
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"dnd_dm_assistant_go/internal/audio"
 	"dnd_dm_assistant_go/internal/claude"
+	"dnd_dm_assistant_go/internal/clock"
 	"dnd_dm_assistant_go/internal/config"
+	"dnd_dm_assistant_go/internal/dnd"
+	"dnd_dm_assistant_go/internal/paths"
+	"dnd_dm_assistant_go/internal/search"
 	"dnd_dm_assistant_go/internal/speech"
 
 	"github.com/bwmarrin/discordgo"
 )
 
 const (
-	// Startup delay to allow Discord state to stabilize
-	startupDelay = 2 * time.Second
+	// Number of messages per channel kept in state for edit detection
+	messageCacheSize = 100
 
 	// Command names
 	commandJoin   = "join"
@@ -26,16 +35,129 @@ const (
 	commandAsk    = "ask"
 	commandFlush  = "flush"
 	commandClear  = "clear"
+	commandRecap  = "recap"
+	commandStats  = "stats"
+
+	commandHistoryLimit = "historylimit"
+	commandDebug        = "debug"
+	commandGetAudio     = "getaudio"
+	commandRules        = "rules"
+	commandEncounter    = "encounter"
+	commandPin          = "pin"
+	commandPins         = "pins"
+	commandSubtitles    = "subtitles"
+	commandTurns        = "turns"
+	commandCampaign     = "campaign"
+	commandTable        = "table"
+	commandTables       = "tables"
+	commandLastFail     = "lastfail"
+	commandVerbose      = "verbose"
+	commandCheck        = "check"
+	commandDeaf         = "deaf"
+	commandVoice        = "voice"
+	commandLastRequest  = "lastrequest"
+	commandBudget       = "budget"
+	commandIdentify     = "identify"
+	commandUnknown      = "unknown"
+	commandFeatures     = "features"
+	commandTranscript   = "transcript"
+	commandName         = "name"
+	commandPreview      = "preview"
+	commandFlushUser    = "flushuser"
+	commandMeter        = "meter"
+	commandSpeechModel  = "speechmodel"
+	commandFind         = "find"
+	commandAutoMonitor  = "automonitor"
+	commandMicTest      = "test"
+	commandArchive      = "archive"
+	commandTokens       = "tokens"
+	commandChannels     = "channels"
+	commandContinue     = "continue"
+	commandSuggest      = "suggest"
+	commandAutoFlush    = "autoflush"
+	commandNote         = "note"
+	commandPrompts      = "prompts"
+	commandPrompt       = "prompt"
+	commandIgnore       = "ignore"
+	commandRetranscribe = "retranscribe"
+	commandErrors       = "errors"
+	commandUnignore     = "unignore"
+
+	// Marks transcriptions that were spooled to disk and may have been said before earlier lines
+	delayedTranscriptionMarker = "(said earlier) "
+
+	// Largest file the bot will upload (Discord's limit for servers without boosts)
+	discordUploadLimit = 10 << 20
+
+	// Longest auto-flush interval accepted by the autoflush command (1 hour)
+	maxAutoFlushSeconds = 3600
+
+	// Warn when the history limit is raised beyond this multiple of the configured default
+	historyLimitWarnFactor = 2
+
+	// Number of transcriptions shown by recap by default and at most
+	defaultRecapCount = 10
+	maxRecapCount     = 50
 )
 
 // Bot represents the D&D DM Assistant Discord bot
 type Bot struct {
 	config              *config.Config
 	session             *discordgo.Session
-	audioProcessor      *audio.Processor
-	speechService       *speech.Service
-	conversationManager *claude.ConversationManager
-	stopAutoFlush       chan bool
+	audioManager        *audio.Manager
+	speechService       speech.Transcriber
+	claudeService       claude.Backend
+	conversationManager *claude.ConversationManager // Shared conversation; see conversation()
+	searchIndex         *search.Index               // Nil unless EMBEDDINGS_ENABLED
+	transcriptions      *transcriptionDebouncer     // Merges bursts of transcriptions before delivery
+
+	// Each guild's conversation when they're kept apart, keyed by guild ID
+	conversations      map[string]*claude.ConversationManager
+	conversationsMutex sync.Mutex
+	stopAutoFlush      chan bool
+	autoFlushUpdates   chan struct{} // Tells the background flusher the interval changed
+	autoFlushInterval  atomic.Int64  // Current auto-flush interval (0 = off)
+	debug              atomic.Bool
+	autoMonitorPaused  atomic.Bool // Auto-join and auto-leave turned off with the automonitor command
+	greetOnce          sync.Once   // The startup greeting is posted once, not on every reconnect
+	dirs               paths.Dirs
+	clock              clock.Clock
+
+	// Campaign name used in filenames, prompts and status
+	campaign      string
+	campaignMutex sync.Mutex
+
+	// Random tables from TABLES_FILE, replaced on reload
+	tables      dnd.Tables
+	tablesMutex sync.Mutex
+
+	// Users whose speech is never transcribed
+	ignoredUsers []string
+	ignoredMutex sync.Mutex
+
+	// Character names used instead of Discord names, by campaign and then user ID
+	characterNames      map[string]map[string]string
+	characterNamesMutex sync.Mutex
+
+	// Names fetched from Discord for users missing from the state cache
+	displayNames      map[displayNameKey]cachedDisplayName
+	displayNamesMutex sync.Mutex
+
+	// Recent failures shown by the errors command, oldest first
+	recentErrors []recentError
+	errorsMutex  sync.Mutex
+
+	// Leaves waiting out the DM's grace period, by guild ID
+	pendingLeaves    map[string]*time.Timer
+	pendingLeavesMux sync.Mutex
+
+	// Which optional subsystems started, and why any didn't
+	features startupFeatures
+
+	// Guilds announced in Ready whose data hasn't arrived yet
+	pendingGuilds    map[string]bool
+	guildsLoaded     chan struct{}
+	pendingGuildsMux sync.Mutex
 }
 
 // New creates a new Bot instance
@@ -46,64 +168,137 @@ func New(cfg *config.Config) (*Bot, error) {
 		return nil, fmt.Errorf("failed to create Discord session: %w", err)
 	}
 
-	// Set intents
-	session.Identify.Intents = discordgo.IntentsAll
+	// Request only the intents the configuration asks for
+	session.Identify.Intents = discordIntents(cfg.DiscordIntents)
 
-	// Create speech service if Google Cloud credentials are available
-	var speechService *speech.Service
-	if cfg.GoogleProjectID != "" {
-		log.Printf("🔧 Attempting to create speech service with project ID: %s", cfg.GoogleProjectID)
+	// Cache recent messages so edits can be compared against their previous content
+	if cfg.CommandEditReinvoke {
+		session.State.MaxMessageCount = messageCacheSize
+	}
 
-		// Check if credentials file exists if specified
-		if cfg.GoogleCredsPath != "" {
-			log.Printf("🔧 Using credentials file: %s", cfg.GoogleCredsPath)
-		} else {
-			log.Printf("🔧 Using default credentials (ADC/environment)")
+	// Everything the bot writes lives under the data directory
+	dirs := paths.New(cfg.DataDir)
+	if cfg.Persist {
+		if err := dirs.Create(); err != nil {
+			return nil, err
 		}
+		log.Printf("📁 Data directory: %s", cfg.DataDir)
+	}
 
-		speechService, err = speech.NewService(cfg.GoogleProjectID, cfg.Debug)
-		if err != nil {
-			log.Printf("❌ Warning: Failed to create speech service: %v", err)
-			log.Printf("   📋 Troubleshooting steps:")
-			log.Printf("   1. Ensure GOOGLE_PROJECT_ID is set to your GCP project ID")
-			log.Printf("   2. Set up authentication:")
-			log.Printf("      • Set GOOGLE_APPLICATION_CREDENTIALS to path of service account JSON file")
-			log.Printf("      • OR run 'gcloud auth application-default login'")
-			log.Printf("      • OR use GCE/Cloud Run default credentials")
-			if cfg.GoogleCredsPath != "" {
-				log.Printf("   3. Check that credentials file exists: %s", cfg.GoogleCredsPath)
-			}
-			log.Printf("   🔗 See: https://cloud.google.com/docs/authentication/getting-started")
-			log.Printf("   ⚠️  The bot will continue without speech-to-text functionality.")
-			speechService = nil
-		} else {
-			log.Printf("✅ Speech service created successfully")
-		}
-	} else {
-		log.Printf("ℹ️  Google Project ID not configured - speech service disabled")
-		log.Printf("   Set GOOGLE_PROJECT_ID environment variable to enable speech-to-text")
+	// Record which optional subsystems start, for the features command
+	features := startupFeatures{
+		Persistence: enabledFeature,
+		Tables:      disabledFeature("TABLES_FILE is not set"),
+	}
+	if !cfg.Persist {
+		features.Persistence = disabledFeature("PERSIST is false; conversations and recordings stay in memory")
 	}
 
-	// Create audio processor
-	audioProcessor := audio.New(cfg.Debug, speechService)
+	speechService, speechStatus := newSpeechService(cfg)
+	features.Speech = speechStatus
 
-	// Create Claude conversation manager if API key is available
+	// Batches that don't fit in a full transcription queue are dropped unless spooling is on
+	var spoolDir string
+	if cfg.TranscriptionSpool {
+		spoolDir = dirs.Spool()
+	}
+
+	searchIndex, searchStatus := newSearchIndex(cfg, dirs)
+	features.Search = searchStatus
+
+	// Create audio manager, which runs a processor for each voice connection
+	audioManager := audio.NewManager(cfg.Debug, speechService, audio.Options{
+		FillPacketGaps: cfg.FillPacketGaps,
+		Ephemeral:      !cfg.Persist,
+		Dir:            dirs.Recordings(),
+		LeadIn:         cfg.AudioLeadIn,
+		VoiceGateDB:    cfg.VoiceGateDB,
+		MaxBufferAge:   cfg.MaxBufferAge,
+
+		RecordIgnoredUsers:  cfg.RecordIgnored,
+		RecordingSampleRate: uint32(cfg.RecordingSampleRate),
+		RecordingChannels:   uint16(cfg.RecordingChannels),
+		ValidateOpus:        cfg.ValidateOpus,
+		SegmentLength:       cfg.TranscribeSegmentLength,
+		SegmentConcurrency:  cfg.TranscribeConcurrency,
+		SpoolDir:            spoolDir,
+		SpoolMaxBytes:       int64(cfg.TranscriptionSpoolMaxMB) << 20,
+
+		ResampleTranscription: cfg.TranscriptionResample,
+		MinPackets:            cfg.MinPacketsToTranscribe,
+		MinWords:              cfg.MinTranscriptionWords,
+	})
+
+	// Create Claude conversation manager if API key (or a local backend) is available
+	var claudeService claude.Backend
 	var conversationManager *claude.ConversationManager
-	if cfg.AnthropicAPIKey != "" {
+	if cfg.AnthropicAPIKey != "" || cfg.LLMBackend == config.LLMBackendOpenAI {
 		log.Printf("🔧 Attempting to create Claude conversation manager")
 
-		claudeService := claude.NewService(cfg.AnthropicAPIKey, cfg.Debug)
-		conversationManager = claude.NewConversationManager(
-			claudeService,
-			cfg.ConversationFile,
-			cfg.MaxConversationMsgs,
-			cfg.Debug,
-		)
+		// An empty conversation file keeps the conversation in memory only
+		conversationFile := dirs.Conversation(cfg.ConversationFile)
+		if !cfg.Persist {
+			conversationFile = ""
+		} else if moved, err := dirs.MoveLegacyConversation(cfg.ConversationFile); err != nil {
+			// Keep using the old file rather than start the campaign over
+			log.Printf("⚠️ Couldn't move the conversation file into the data directory, using %s: %v", cfg.ConversationFile, err)
+			conversationFile = cfg.ConversationFile
+		} else if moved {
+			log.Printf("📁 Moved conversation file %s to %s", cfg.ConversationFile, conversationFile)
+		}
 
+		// The exchange log is for analysis; ephemeral mode writes nothing to disk
+		logFile := cfg.ClaudeLogFile
+		if logFile != "" && !cfg.Persist {
+			log.Printf("ℹ️  CLAUDE_LOG_FILE is ignored with PERSIST=false")
+			logFile = ""
+		}
+		logMaxBytes := int64(cfg.ClaudeLogMaxMB) << 20
+
+		if cfg.LLMBackend == config.LLMBackendOpenAI {
+			log.Printf("🔧 Using OpenAI-compatible backend at %s (model %s)", cfg.LLMBaseURL, cfg.LLMModel)
+			claudeService = claude.NewOpenAIService(cfg.Debug, claude.OpenAIOptions{
+				BaseURL:     cfg.LLMBaseURL,
+				APIKey:      cfg.LLMAPIKey,
+				Model:       cfg.LLMModel,
+				LogFile:     logFile,
+				LogMaxBytes: logMaxBytes,
+			})
+		} else {
+			claudeService = claude.NewService(cfg.AnthropicAPIKey, cfg.Debug, claude.Options{
+				APIVersion:    cfg.AnthropicVersion,
+				BetaFeatures:  cfg.AnthropicBeta,
+				FallbackModel: cfg.ClaudeFallbackModel,
+				LogFile:       logFile,
+				LogMaxBytes:   logMaxBytes,
+			})
+		}
+		if logFile != "" {
+			log.Printf("   📜 Logging every Claude request and response to %s", logFile)
+		}
+		// Auto-flush, voice commands and questions can all fire at once; don't let them pile onto the API
+		claudeService = claude.LimitConcurrency(claudeService, cfg.MaxConcurrentClaude)
+		conversationManager = newConversationManager(cfg, claudeService, conversationFile, cfg.Debug)
+
+		features.Claude = enabledFeature
 		log.Printf("✅ Claude conversation manager created successfully")
-		log.Printf("   📝 Conversation file: %s", cfg.ConversationFile)
+		if cfg.Persist {
+			log.Printf("   📝 Conversation file: %s", conversationFile)
+			if cfg.ConversationPerGuild {
+				log.Printf("   🏘️ Each guild gets its own conversation file")
+			}
+			if cfg.SaveInterval > 0 {
+				log.Printf("   💾 Saving changes every %v", cfg.SaveInterval)
+			}
+		} else {
+			log.Printf("   📝 Conversation kept in memory only (PERSIST=false)")
+		}
 		log.Printf("   📊 Max messages: %d", cfg.MaxConversationMsgs)
+		if cfg.ClaudeFallbackModel != "" {
+			log.Printf("   🔁 Fallback model: %s", cfg.ClaudeFallbackModel)
+		}
 	} else {
+		features.Claude = disabledFeature("ANTHROPIC_API_KEY is not set (or set LLM_BACKEND=openai for a local model)")
 		log.Printf("ℹ️  Anthropic API key not configured - Claude assistant disabled")
 		log.Printf("   Set ANTHROPIC_API_KEY environment variable to enable Claude assistant")
 	}
@@ -111,19 +306,61 @@ func New(cfg *config.Config) (*Bot, error) {
 	bot := &Bot{
 		config:              cfg,
 		session:             session,
-		audioProcessor:      audioProcessor,
+		audioManager:        audioManager,
 		speechService:       speechService,
+		claudeService:       claudeService,
 		conversationManager: conversationManager,
+		searchIndex:         searchIndex,
+		conversations:       make(map[string]*claude.ConversationManager),
 		stopAutoFlush:       make(chan bool),
+		autoFlushUpdates:    make(chan struct{}, 1),
+		pendingLeaves:       make(map[string]*time.Timer),
+		displayNames:        make(map[displayNameKey]cachedDisplayName),
+		dirs:                dirs,
+		clock:               clock.Real{},
+		features:            features,
+	}
+	bot.debug.Store(cfg.Debug)
+	bot.autoFlushInterval.Store(int64(cfg.AutoFlushInterval))
+	bot.transcriptions = newTranscriptionDebouncer(cfg.TranscriptionDebounce, bot.deliverTranscription)
+	bot.loadIgnoredUsers()
+	bot.loadCharacterNames()
+	bot.loadCampaign()
+	if cfg.TablesFile != "" {
+		if count, err := bot.loadTables(); err != nil {
+			bot.features.Tables = disabledFeature("failed to load %s: %v", cfg.TablesFile, err)
+			log.Printf("⚠️ Failed to load random tables: %v", err)
+		} else {
+			bot.features.Tables = enabledFeature
+			log.Printf("🎲 Loaded %d random table(s) from %s", count, cfg.TablesFile)
+		}
 	}
 
-	// Set up transcription callback to send transcriptions to Claude
-	if conversationManager != nil {
-		audioProcessor.SetTranscriptionCallback(func(ssrc uint32, text string, confidence float64) {
-			conversationManager.AddTranscription(ssrc, text)
+	// Name recordings after the speaker's display name in the connected guild
+	audioManager.SetUserNameResolver(bot.displayName)
+
+	audioManager.SetErrorCallback(bot.recordError)
+	audioManager.SetSpeakerCallback(bot.updateRoster)
+	if searchIndex != nil {
+		searchIndex.SetErrorCallback(func(err error) {
+			bot.recordError(componentSearch, err)
 		})
+	}
+
+	// Set up transcription callback to handle voice commands and send transcriptions to Claude
+	audioManager.SetTranscriptionCallback(func(guildID string, ssrc uint32, text string, confidence float64, delayed bool) {
+		// A spoken command is stale by the time a spooled batch is transcribed
+		if !delayed && bot.handleVoiceCommand(guildID, ssrc, text) {
+			return
+		}
+		if userID, known := audioManager.UserForSSRC(guildID, ssrc); known && bot.isUserIgnored(userID) {
+			return
+		}
+		bot.transcriptions.add(guildID, ssrc, text, confidence, delayed)
+	})
 
-		// Start auto-flush background process
+	// Start auto-flush background process
+	if conversationManager != nil {
 		go bot.autoFlushTranscriptions()
 	}
 
@@ -133,6 +370,30 @@ func New(cfg *config.Config) (*Bot, error) {
 	return bot, nil
 }
 
+// discordIntents turns intent names from the configuration into the gateway intents bitmask
+func discordIntents(names []string) discordgo.Intent {
+	var intents discordgo.Intent
+	for _, name := range names {
+		switch name {
+		case config.IntentAll:
+			intents |= discordgo.IntentsAll
+		case config.IntentGuilds:
+			intents |= discordgo.IntentsGuilds
+		case config.IntentGuildMembers:
+			intents |= discordgo.IntentsGuildMembers
+		case config.IntentGuildVoiceStates:
+			intents |= discordgo.IntentsGuildVoiceStates
+		case config.IntentGuildMessages:
+			intents |= discordgo.IntentsGuildMessages
+		case config.IntentMessageContent:
+			intents |= discordgo.IntentsMessageContent
+		case config.IntentDirectMessages:
+			intents |= discordgo.IntentsDirectMessages
+		}
+	}
+	return intents
+}
+
 // Start starts the bot
 func (b *Bot) Start() error {
 	// Open connection to Discord
@@ -141,8 +402,13 @@ func (b *Bot) Start() error {
 	}
 
 	log.Printf("Bot connected as %s", b.session.State.User.Username)
-	log.Printf("Monitoring for DM user ID: %s", b.config.DMUserID)
-	log.Printf("Target D&D voice channel ID: %s", b.config.DNDVoiceChannelID)
+	if b.config.DMUserID != "" {
+		log.Printf("Monitoring for DM user ID: %s", b.config.DMUserID)
+		log.Printf("Target D&D voice channel ID: %s", b.config.DNDVoiceChannelID)
+	}
+	if len(b.config.Guilds) > 0 {
+		log.Printf("Per-guild DMs and voice channels for %d guild(s) from %s", len(b.config.Guilds), b.config.GuildConfigFile)
+	}
 
 	return nil
 }
@@ -155,7 +421,7 @@ func (b *Bot) Stop() {
 	if b.conversationManager != nil {
 		select {
 		case b.stopAutoFlush <- true:
-			if b.config.Debug {
+			if b.debug.Load() {
 				log.Printf("Sent stop signal to auto-flush process")
 			}
 		default:
@@ -163,10 +429,30 @@ func (b *Bot) Stop() {
 		}
 	}
 
+	// Leaves still in their grace period are moot; everything is disconnected below
+	b.cancelAllLeaves()
+
 	// Stop audio processing first
-	if b.audioProcessor != nil {
+	if b.audioManager != nil {
 		log.Printf("Stopping audio processing...")
-		b.audioProcessor.StopProcessing()
+		b.audioManager.StopAll()
+	}
+
+	// Deliver transcriptions still being merged so they're saved below
+	b.transcriptions.flush()
+
+	// Write any conversation changes still waiting for a periodic save
+	for _, conversation := range b.allConversations() {
+		if err := conversation.Close(); err != nil {
+			log.Printf("Error saving conversation: %v", err)
+		}
+	}
+
+	// Embed the transcriptions still waiting so they're searchable next time
+	if b.searchIndex != nil {
+		if err := b.searchIndex.Close(); err != nil {
+			log.Printf("Error embedding the last transcriptions: %v", err)
+		}
 	}
 
 	// Close speech service
@@ -199,22 +485,44 @@ func (b *Bot) Stop() {
 // setupEventHandlers sets up Discord event handlers
 func (b *Bot) setupEventHandlers() {
 	b.session.AddHandler(b.onReady)
+	b.session.AddHandler(b.onGuildCreate)
 	b.session.AddHandler(b.onVoiceStateUpdate)
 	b.session.AddHandler(b.onMessageCreate)
+	b.session.AddHandler(b.onChannelDelete)
+	b.session.AddHandler(b.onChannelUpdate)
+	if b.config.CommandEditReinvoke {
+		b.session.AddHandler(b.onMessageUpdate)
+	}
 }
 
 // onReady handles the ready event
 func (b *Bot) onReady(s *discordgo.Session, event *discordgo.Ready) {
 	log.Printf("Bot is ready! Logged in as %s", event.User.Username)
 
-	// Check if DM is already in the target voice channel with fresh data
-	go b.checkDMInVoiceChannelAsync()
+	// Guild data (including voice states) arrives afterwards in GuildCreate events
+	b.expectGuilds(event.Guilds)
 }
 
 // onVoiceStateUpdate handles voice state update events
 func (b *Bot) onVoiceStateUpdate(s *discordgo.Session, vsu *discordgo.VoiceStateUpdate) {
+	// Someone leaving the session's channel drops off the roster
+	if vsu.BeforeUpdate != nil && vsu.BeforeUpdate.ChannelID != vsu.ChannelID {
+		if channelID := b.connectedChannel(vsu.GuildID); channelID != "" && vsu.BeforeUpdate.ChannelID == channelID {
+			b.updateRoster(vsu.GuildID)
+		}
+	}
+
+	// Paused by the DM; only manual join and leave apply
+	if !b.autoMonitorEnabled() {
+		return
+	}
+
 	// Check if this is the DM user
-	if vsu.UserID != b.config.DMUserID {
+	if vsu.UserID != b.config.DMUserFor(vsu.GuildID) {
+		// With a player role required, a player arriving may be what lets the bot join
+		if b.config.PlayerRoleID != "" && b.isPlayerJoin(vsu) {
+			b.onPlayerJoined(vsu.GuildID)
+		}
 		return
 	}
 
@@ -230,12 +538,27 @@ func (b *Bot) onVoiceStateUpdate(s *discordgo.Session, vsu *discordgo.VoiceState
 	}
 
 	// Check if DM joined the target voice channel
-	if vsu.ChannelID == b.config.DNDVoiceChannelID {
+	targetChannelID := b.config.VoiceChannelFor(vsu.GuildID)
+	if vsu.ChannelID == targetChannelID {
+		// A quick rejoin keeps the existing connection and its buffered audio
+		if b.cancelLeave(vsu.GuildID) && b.audioManager.IsProcessingGuild(vsu.GuildID) {
+			log.Printf("DM rejoined the D&D voice channel within the grace period, staying")
+			return
+		}
+		if !b.playersPresent(vsu.GuildID) {
+			log.Printf("DM joined the D&D voice channel, waiting for a player with role %s before joining", b.config.PlayerRoleID)
+			return
+		}
 		log.Printf("DM joined the D&D voice channel, joining...")
 		b.joinVoiceChannel(vsu.GuildID, vsu.ChannelID)
-	} else if previousChannelID == b.config.DNDVoiceChannelID {
-		log.Printf("DM left the D&D voice channel, leaving...")
-		b.leaveVoiceChannel(vsu.GuildID)
+	} else if previousChannelID == targetChannelID {
+		// Nothing to leave or archive if the bot already left, or never joined
+		if !b.audioManager.IsProcessingGuild(vsu.GuildID) {
+			log.Printf("DM left the D&D voice channel, which the bot isn't recording")
+			return
+		}
+		log.Printf("DM left the D&D voice channel")
+		b.scheduleLeave(vsu.GuildID)
 	}
 }
 
@@ -248,21 +571,84 @@ func (b *Bot) onMessageCreate(s *discordgo.Session, m *discordgo.MessageCreate)
 
 	// Handle commands
 	if strings.HasPrefix(m.Content, b.config.CommandPrefix) {
+		// Direct messages to the bot have no guild; guild-only commands check for this
+		if m.GuildID == "" && b.debug.Load() {
+			log.Printf("Command from %s in a direct message: %s", m.Author.Username, m.Content)
+		}
 		b.handleCommand(s, m)
 	}
 }
 
-// handleCommand handles bot commands
-func (b *Bot) handleCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
-	content := strings.TrimPrefix(m.Content, b.config.CommandPrefix)
-	content = strings.TrimSpace(content)
+// onMessageUpdate re-runs commands from messages edited shortly after being sent
+func (b *Bot) onMessageUpdate(s *discordgo.Session, m *discordgo.MessageUpdate) {
+	// Partial updates (e.g. link embeds) have no author or content
+	if m.Author == nil || m.Author.ID == s.State.User.ID {
+		return
+	}
 
-	args := strings.Fields(content)
-	if len(args) == 0 {
+	if !strings.HasPrefix(m.Content, b.config.CommandPrefix) {
 		return
 	}
 
-	command := strings.ToLower(args[0])
+	// Ignore edits to old messages
+	if time.Since(m.Timestamp) > b.config.CommandEditWindow {
+		if b.debug.Load() {
+			log.Printf("Ignoring edit to message %s older than %v", m.ID, b.config.CommandEditWindow)
+		}
+		return
+	}
+
+	// Ignore updates that didn't change the content
+	var before string
+	if m.BeforeUpdate != nil {
+		before = m.BeforeUpdate.Content
+		if before == m.Content {
+			return
+		}
+	}
+
+	// Only re-run an ask if the original message clearly didn't already reach Claude
+	command, _ := b.parseCommand(m.Content)
+	if command == commandAsk {
+		if m.BeforeUpdate == nil {
+			if b.debug.Load() {
+				log.Printf("Not re-running ask from edited message %s: previous content unknown", m.ID)
+			}
+			return
+		}
+		if previous, args := b.parseCommand(before); previous == commandAsk && len(args) > 0 {
+			if b.debug.Load() {
+				log.Printf("Not re-running ask from edited message %s: original was already asked", m.ID)
+			}
+			return
+		}
+	}
+
+	log.Printf("Re-running edited command from %s: %s", m.Author.Username, m.Content)
+	b.handleCommand(s, &discordgo.MessageCreate{Message: m.Message})
+}
+
+// parseCommand splits a prefixed message into the lowercased command name and its arguments
+func (b *Bot) parseCommand(message string) (string, []string) {
+	if !strings.HasPrefix(message, b.config.CommandPrefix) {
+		return "", nil
+	}
+
+	content := strings.TrimPrefix(message, b.config.CommandPrefix)
+	args := strings.Fields(strings.TrimSpace(content))
+	if len(args) == 0 {
+		return "", nil
+	}
+
+	return strings.ToLower(args[0]), args[1:]
+}
+
+// handleCommand handles bot commands
+func (b *Bot) handleCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	command, args := b.parseCommand(m.Content)
+	if command == "" {
+		return
+	}
 
 	switch command {
 	case commandJoin:
@@ -274,16 +660,112 @@ func (b *Bot) handleCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
 	case commandHelp:
 		b.handleHelpCommand(s, m)
 	case commandAsk:
-		b.handleAskCommand(s, m, args[1:])
+		b.handleAskCommand(s, m, args)
 	case commandFlush:
 		b.handleFlushCommand(s, m)
+	case commandFlushUser:
+		b.handleFlushUserCommand(s, m)
 	case commandClear:
 		b.handleClearCommand(s, m)
+	case commandRecap:
+		b.handleRecapCommand(s, m, args)
+	case commandStats:
+		b.handleStatsCommand(s, m, args)
+	case commandHistoryLimit:
+		b.handleHistoryLimitCommand(s, m, args)
+	case commandDebug:
+		b.handleDebugCommand(s, m, args)
+	case commandGetAudio:
+		b.handleGetAudioCommand(s, m)
+	case commandRules:
+		b.handleRulesCommand(s, m, args)
+	case commandEncounter:
+		b.handleEncounterCommand(s, m, args)
+	case commandPin:
+		b.handlePinCommand(s, m, args)
+	case commandPins:
+		b.handlePinsCommand(s, m)
+	case commandErrors:
+		b.handleErrorsCommand(s, m, args)
+	case commandRetranscribe:
+		b.handleRetranscribeCommand(s, m, args)
+	case commandIgnore:
+		b.handleIgnoreCommand(s, m, true)
+	case commandUnignore:
+		b.handleIgnoreCommand(s, m, false)
+	case commandPrompts:
+		b.handlePromptsCommand(s, m)
+	case commandPrompt:
+		b.handlePromptCommand(s, m, args)
+	case commandNote:
+		b.handleNoteCommand(s, m, args)
+	case commandAutoFlush:
+		b.handleAutoFlushCommand(s, m, args)
+	case commandSuggest:
+		b.handleSuggestCommand(s, m)
+	case commandContinue:
+		b.handleContinueCommand(s, m)
+	case commandChannels:
+		b.handleChannelsCommand(s, m)
+	case commandDeaf:
+		b.handleDeafCommand(s, m, args)
+	case commandSubtitles:
+		b.handleSubtitlesCommand(s, m, args)
+	case commandTurns:
+		b.handleTurnsCommand(s, m)
+	case commandCampaign:
+		b.handleCampaignCommand(s, m, args)
+	case commandTable:
+		b.handleTableCommand(s, m, args)
+	case commandTables:
+		b.handleTablesCommand(s, m)
+	case commandLastFail:
+		b.handleLastFailCommand(s, m)
+	case commandVerbose:
+		b.handleVerboseCommand(s, m, args)
+	case commandCheck:
+		b.handleCheckCommand(s, m, args)
+	case commandMeter:
+		b.handleMeterCommand(s, m)
+	case commandSpeechModel:
+		b.handleSpeechModelCommand(s, m, args)
+	case commandFind:
+		b.handleFindCommand(s, m, args)
+	case commandAutoMonitor:
+		b.handleAutoMonitorCommand(s, m, args)
+	case commandMicTest:
+		b.handleMicTestCommand(s, m)
+	case commandArchive:
+		b.handleArchiveCommand(s, m, args)
+	case commandTokens:
+		b.handleTokensCommand(s, m)
+	case commandVoice:
+		b.handleVoiceInfoCommand(s, m)
+	case commandLastRequest:
+		b.handleLastRequestCommand(s, m)
+	case commandBudget:
+		b.handleBudgetCommand(s, m)
+	case commandIdentify:
+		b.handleIdentifyCommand(s, m, args)
+	case commandUnknown:
+		b.handleUnknownCommand(s, m)
+	case commandFeatures:
+		b.handleFeaturesCommand(s, m)
+	case commandTranscript:
+		b.handleTranscriptCommand(s, m)
+	case commandName:
+		b.handleNameCommand(s, m, args)
+	case commandPreview:
+		b.handlePreviewCommand(s, m)
 	}
 }
 
 // handleJoinCommand handles the join command
 func (b *Bot) handleJoinCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if !b.requireGuild(s, m) {
+		return
+	}
+
 	// Find the guild
 	guild, err := s.State.Guild(m.GuildID)
 	if err != nil {
@@ -295,7 +777,10 @@ func (b *Bot) handleJoinCommand(s *discordgo.Session, m *discordgo.MessageCreate
 	// Find the user's voice channel
 	for _, vs := range guild.VoiceStates {
 		if vs.UserID == m.Author.ID {
-			b.joinVoiceChannel(guild.ID, vs.ChannelID)
+			if err := b.joinVoiceChannel(guild.ID, vs.ChannelID); err != nil {
+				s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ %v", err))
+				return
+			}
 			s.ChannelMessageSend(m.ChannelID, "✅ Joined your voice channel!")
 			return
 		}
@@ -306,6 +791,10 @@ func (b *Bot) handleJoinCommand(s *discordgo.Session, m *discordgo.MessageCreate
 
 // handleLeaveCommand handles the leave command
 func (b *Bot) handleLeaveCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if !b.requireGuild(s, m) {
+		return
+	}
+
 	b.leaveVoiceChannel(m.GuildID)
 	s.ChannelMessageSend(m.ChannelID, "✅ Left the voice channel.")
 }
@@ -313,29 +802,69 @@ func (b *Bot) handleLeaveCommand(s *discordgo.Session, m *discordgo.MessageCreat
 // handleStatusCommand handles the status command
 func (b *Bot) handleStatusCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
 	status := "✅ Bot is running\n"
-	status += fmt.Sprintf("📡 Monitoring DM User: <@%s>\n", b.config.DMUserID)
-	status += fmt.Sprintf("🎯 Target Voice Channel: <#%s>\n", b.config.DNDVoiceChannelID)
+	if name := b.campaignName(); name != "" {
+		status += fmt.Sprintf("🏰 Campaign: %s\n", name)
+	}
+	if guildID := b.sessionGuild(m.GuildID); guildID != "" || b.config.DMUserID != "" {
+		status += fmt.Sprintf("📡 Monitoring DM User: <@%s>\n", b.config.DMUserFor(guildID))
+		status += fmt.Sprintf("🎯 Target Voice Channel: <#%s>\n", b.config.VoiceChannelFor(guildID))
+	}
+	if len(b.config.Guilds) > 0 {
+		status += fmt.Sprintf("🗂️ Guild config file: %d guild(s)\n", len(b.config.Guilds))
+	}
+	if b.debug.Load() {
+		status += "🐛 Debug logging: on\n"
+	} else {
+		status += "🐛 Debug logging: off\n"
+	}
+	if !b.config.Persist {
+		status += "🫥 Ephemeral mode: nothing is written to disk\n"
+	}
+	if b.quietNow() {
+		status += fmt.Sprintf("🌙 Quiet hours %s: not posting to channels unprompted\n", b.config.QuietHours)
+	}
+	if b.autoMonitorEnabled() {
+		status += "👀 Auto-join monitoring: on\n"
+	} else {
+		status += fmt.Sprintf("👀 Auto-join monitoring: ⏸️ Off (turn on with `%s %s on`)\n", b.config.CommandPrefix, commandAutoMonitor)
+	}
 
-	if b.audioProcessor.IsProcessing() {
+	if b.audioManager.IsProcessing() && b.audioManager.IsDeafened() {
+		status += "🙉 In voice but deafened (not listening)\n"
+	} else if b.audioManager.IsProcessing() {
 		status += "🎤 Currently processing audio\n"
 	} else {
 		status += "⏸️ Not processing audio\n"
 	}
+	status += b.formatVoiceSessions()
+	status += b.formatAudioStats()
 
-	if b.speechService != nil {
+	if b.speechService != nil && !b.speechEnabledFor(m.GuildID) {
+		status += "🗣️ Speech-to-text service: ⏸️ Off for this server\n"
+	} else if b.speechService != nil {
 		status += "🗣️ Speech-to-text service: ✅ Active\n"
 	} else {
 		status += "🗣️ Speech-to-text service: ❌ Disabled\n"
 	}
 
-	if b.conversationManager != nil {
+	if b.conversationManager != nil && !b.claudeEnabledFor(m.GuildID) {
+		status += "🤖 Claude assistant: ⏸️ Off for this server"
+	} else if b.conversationManager != nil {
 		status += "🤖 Claude assistant: ✅ Active\n"
-		status += fmt.Sprintf("💬 %s\n", b.conversationManager.GetConversationSummary())
+		conversation := b.conversation(m.GuildID)
+		status += fmt.Sprintf("💬 %s\n", conversation.GetConversationSummary())
+		status += fmt.Sprintf("📚 History limit: %d messages\n", conversation.MaxMessages())
+		if conversation.VerboseNext() {
+			status += "📜 Next answer: detailed\n"
+		}
 		status += "📤 Auto-responses: DM via private message\n"
-		if b.conversationManager.HasPendingTranscriptions() {
-			status += "⏱️ Auto-flush: ✅ Running (pending transcriptions)"
+		interval := time.Duration(b.autoFlushInterval.Load())
+		if interval == 0 {
+			status += "⏱️ Auto-flush: ⏸️ Off"
+		} else if conversation.HasPendingTranscriptions() {
+			status += fmt.Sprintf("⏱️ Auto-flush: ✅ Every %v (pending transcriptions)", interval)
 		} else {
-			status += "⏱️ Auto-flush: ✅ Running (no pending transcriptions)"
+			status += fmt.Sprintf("⏱️ Auto-flush: ✅ Every %v (no pending transcriptions)", interval)
 		}
 	} else {
 		status += "🤖 Claude assistant: ❌ Disabled"
@@ -344,6 +873,65 @@ func (b *Bot) handleStatusCommand(s *discordgo.Session, m *discordgo.MessageCrea
 	s.ChannelMessageSend(m.ChannelID, status)
 }
 
+// handleStatsCommand shows the audio statistics, or resets them with "stats reset"
+func (b *Bot) handleStatsCommand(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+	if len(args) > 0 && strings.ToLower(args[0]) == "reset" {
+		b.audioManager.ResetStats()
+		s.ChannelMessageSend(m.ChannelID, "✅ Audio statistics reset.")
+		return
+	}
+
+	s.ChannelMessageSend(m.ChannelID, b.formatAudioStats())
+}
+
+// formatVoiceSessions lists the voice channels audio is being processed in
+func (b *Bot) formatVoiceSessions() string {
+	var sessions string
+	for _, guildID := range b.audioManager.ActiveGuilds() {
+		session, ok := b.audioManager.Session(guildID)
+		if !ok {
+			continue
+		}
+
+		guildName := guildID
+		if guild, err := b.session.State.Guild(guildID); err == nil {
+			guildName = guild.Name
+		}
+		sessions += fmt.Sprintf("   • <#%s> in %s\n", session.ChannelID(), guildName)
+	}
+	return sessions
+}
+
+// formatAudioStats formats the current session and cumulative audio statistics
+func (b *Bot) formatAudioStats() string {
+	session, cumulative := b.audioManager.GetStats()
+	stats := fmt.Sprintf("📊 Session: %d packets, %d silences, %d segments, %d bytes\n",
+		session.PacketsReceived, session.SilenceDetections, session.AudioSegments, session.TotalBytesWritten)
+	stats += fmt.Sprintf("📈 Cumulative: %d packets, %d silences, %d segments, %d bytes\n",
+		cumulative.PacketsReceived, cumulative.SilenceDetections, cumulative.AudioSegments, cumulative.TotalBytesWritten)
+	stats += fmt.Sprintf("📉 Packet loss: %d lost, %d late, %d malformed (session), %d lost, %d late, %d malformed (cumulative)\n",
+		session.PacketsLost, session.PacketsReordered, session.PacketsMalformed,
+		cumulative.PacketsLost, cumulative.PacketsReordered, cumulative.PacketsMalformed)
+	stats += fmt.Sprintf("🫥 Blank or too-short transcriptions dropped: %d (session), %d (cumulative)\n",
+		session.TranscriptsEmpty, cumulative.TranscriptsEmpty)
+	if b.config.MinPacketsToTranscribe > 0 {
+		stats += fmt.Sprintf("🔇 Too short to transcribe (under %d packets): %d (session), %d (cumulative)\n",
+			b.config.MinPacketsToTranscribe, session.BuffersTooShort, cumulative.BuffersTooShort)
+	}
+
+	loss := b.audioManager.PacketLoss()
+	ssrcs := make([]uint32, 0, len(loss))
+	for ssrc := range loss {
+		ssrcs = append(ssrcs, ssrc)
+	}
+	slices.Sort(ssrcs)
+	for _, ssrc := range ssrcs {
+		stats += fmt.Sprintf("   • SSRC %d: %d lost\n", ssrc, loss[ssrc])
+	}
+
+	return stats
+}
+
 // handleHelpCommand handles the help command
 func (b *Bot) handleHelpCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
 	help := "**D&D DM Assistant Bot Commands**\n\n"
@@ -351,75 +939,135 @@ func (b *Bot) handleHelpCommand(s *discordgo.Session, m *discordgo.MessageCreate
 	help += fmt.Sprintf("`%s %s` - Join your current voice channel\n", b.config.CommandPrefix, commandJoin)
 	help += fmt.Sprintf("`%s %s` - Leave the current voice channel\n", b.config.CommandPrefix, commandLeave)
 	help += fmt.Sprintf("`%s %s` - Show bot status\n", b.config.CommandPrefix, commandStatus)
+	help += fmt.Sprintf("`%s %s [reset]` - Show or reset audio statistics\n", b.config.CommandPrefix, commandStats)
+	help += fmt.Sprintf("`%s %s` - Show which features are enabled and why any are disabled\n", b.config.CommandPrefix, commandFeatures)
+	help += fmt.Sprintf("`%s %s` - List voice channels and their IDs (DM only)\n", b.config.CommandPrefix, commandChannels)
+	help += fmt.Sprintf("`%s %s on|off` - Toggle debug logging (DM only)\n", b.config.CommandPrefix, commandDebug)
+	help += fmt.Sprintf("`%s %s [count]` - Show recent transcription and Claude errors (DM only)\n", b.config.CommandPrefix, commandErrors)
+	help += fmt.Sprintf("`%s %s [file]` - Retry a failed transcription (DM only)\n", b.config.CommandPrefix, commandRetranscribe)
+	help += fmt.Sprintf("`%s %s [model]` - Show or change the Google speech model, e.g. `latest_short` for short commands (DM only)\n", b.config.CommandPrefix, commandSpeechModel)
+	if b.searchIndex != nil {
+		help += fmt.Sprintf("`%s %s <query>` - Search past sessions' transcriptions by meaning\n", b.config.CommandPrefix, commandFind)
+	}
+	help += fmt.Sprintf("`%s %s` - Show the last failed transcription and upload its audio (DM only)\n", b.config.CommandPrefix, commandLastFail)
+	help += fmt.Sprintf("`%s %s|%s @user` - Stop or resume transcribing a user (DM only)\n", b.config.CommandPrefix, commandIgnore, commandUnignore)
+	help += fmt.Sprintf("`%s %s @user <character>|%s` - Label a user with their character's name (DM only)\n", b.config.CommandPrefix, commandName, nameClearArg)
+	help += fmt.Sprintf("`%s %s` / `%s %s <ssrc> @user` - List SSRCs with no known speaker, or label one (DM only)\n", b.config.CommandPrefix, commandUnknown, b.config.CommandPrefix, commandIdentify)
+	help += fmt.Sprintf("`%s %s on|off` - Stop or resume listening without leaving the channel (DM only)\n", b.config.CommandPrefix, commandDeaf)
+	help += fmt.Sprintf("`%s %s on|off` - Pause or resume joining and leaving when the DM does (DM only)\n", b.config.CommandPrefix, commandAutoMonitor)
+	help += fmt.Sprintf("`%s %s` - Show voice connection state and when each speaker was last heard (DM only)\n", b.config.CommandPrefix, commandVoice)
+	help += fmt.Sprintf("`%s %s` - Record a few seconds of your voice and show how it was transcribed\n", b.config.CommandPrefix, commandMicTest)
+	help += fmt.Sprintf("`%s %s` - Show each speaker's live packet rate for the next %v (DM only)\n", b.config.CommandPrefix, commandMeter, meterDuration)
+	help += fmt.Sprintf("`%s %s [@user]` - Upload your (or, for the DM, a player's) recording\n", b.config.CommandPrefix, commandGetAudio)
+	help += fmt.Sprintf("`%s %s [vtt|srt]` - Upload per-speaker subtitles for the session (DM only)\n", b.config.CommandPrefix, commandSubtitles)
+	help += fmt.Sprintf("`%s %s [days]` - Zip recordings older than %d (or the given) days into one archive and delete them (DM only)\n", b.config.CommandPrefix, commandArchive, b.config.ArchiveMinAgeDays)
+	help += fmt.Sprintf("`%s %s` - Upload the session's transcript as a text file with speakers and times\n", b.config.CommandPrefix, commandTranscript)
+	help += fmt.Sprintf("`%s %s` - Upload the session's utterances as JSON for analysis tools (DM only)\n", b.config.CommandPrefix, commandTurns)
+	help += fmt.Sprintf("`%s %s [name|%s]` - Show or set the campaign name used in filenames and prompts (setting is DM only)\n", b.config.CommandPrefix, commandCampaign, campaignClearArg)
+	help += fmt.Sprintf("`%s %s <name>` - Roll on a random table from the tables file (`%s %s %s` re-reads the file, DM only)\n", b.config.CommandPrefix, commandTable, b.config.CommandPrefix, commandTable, tableReloadArg)
+	help += fmt.Sprintf("`%s %s` - List the random tables\n", b.config.CommandPrefix, commandTables)
+	help += fmt.Sprintf("`%s %s <ability> <DC> [+modifier] [adv|dis] [name]` - Roll an ability check, narrated by Claude if available\n", b.config.CommandPrefix, commandCheck)
+	help += fmt.Sprintf("`%s %s <level> <size> [difficulty] [theme]` - Compute an encounter XP budget\n", b.config.CommandPrefix, commandEncounter)
 
 	if b.conversationManager != nil {
 		help += "\n**Claude Assistant Commands:**\n"
 		help += fmt.Sprintf("`%s %s <question>` - Ask Claude a question\n", b.config.CommandPrefix, commandAsk)
+		help += fmt.Sprintf("`%s %s <text>` - Tell Claude something nobody said aloud (DM only)\n", b.config.CommandPrefix, commandNote)
+		help += fmt.Sprintf("`%s %s` - Flush transcriptions and ask Claude what could happen next\n", b.config.CommandPrefix, commandSuggest)
+		help += fmt.Sprintf("`%s %s [off]` - Give the next question a detailed answer instead of a concise one (DM only)\n", b.config.CommandPrefix, commandVerbose)
+		help += fmt.Sprintf("`%s %s` - Finish an answer that was cut off\n", b.config.CommandPrefix, commandContinue)
+		help += fmt.Sprintf("`%s %s <question>` - Quick rules lookup, separate from the session\n", b.config.CommandPrefix, commandRules)
 		help += fmt.Sprintf("`%s %s` - Send buffered transcriptions to Claude\n", b.config.CommandPrefix, commandFlush)
+		help += fmt.Sprintf("`%s %s @user` - Transcribe one player's audio now and send it to Claude, without waiting for the others\n", b.config.CommandPrefix, commandFlushUser)
 		help += fmt.Sprintf("`%s %s` - Clear conversation history\n", b.config.CommandPrefix, commandClear)
+		help += fmt.Sprintf("`%s %s [n]` - Show the last n transcriptions (default %d)\n", b.config.CommandPrefix, commandRecap, defaultRecapCount)
+		help += fmt.Sprintf("`%s %s [n]` - Pin the replied-to message or the nth latest response\n", b.config.CommandPrefix, commandPin)
+		help += fmt.Sprintf("`%s %s` - List pinned messages\n", b.config.CommandPrefix, commandPins)
+		help += fmt.Sprintf("`%s %s <n>` - Set how many messages Claude remembers (DM only)\n", b.config.CommandPrefix, commandHistoryLimit)
+		help += fmt.Sprintf("`%s %s` - Estimate how much of the model's context the next question would use\n", b.config.CommandPrefix, commandBudget)
+		help += fmt.Sprintf("`%s %s` - Show the system prompt and the first and last messages the next question would send (DM only)\n", b.config.CommandPrefix, commandPreview)
+		help += fmt.Sprintf("`%s %s` - Show the estimated tokens of the system prompt and the largest messages (DM only)\n", b.config.CommandPrefix, commandTokens)
+		help += fmt.Sprintf("`%s %s` - List saved system prompts (DM only)\n", b.config.CommandPrefix, commandPrompts)
+		help += fmt.Sprintf("`%s %s use|save <name>` - Switch to a saved system prompt, or save the current one (DM only)\n", b.config.CommandPrefix, commandPrompt)
+		help += fmt.Sprintf("`%s %s <seconds>` - Change how often transcriptions are auto-flushed, 0 to stop (DM only)\n", b.config.CommandPrefix, commandAutoFlush)
+		help += fmt.Sprintf("`%s %s` - DM you the raw JSON of the last API request and response, recorded in debug mode (DM only)\n", b.config.CommandPrefix, commandLastRequest)
 	}
 
 	help += fmt.Sprintf("\n`%s %s` - Show this help message\n", b.config.CommandPrefix, commandHelp)
 	help += "\n**Automatic Features:**\n"
-	help += fmt.Sprintf("- Bot automatically joins when <@%s> joins <#%s>\n", b.config.DMUserID, b.config.DNDVoiceChannelID)
+	if guildID := b.sessionGuild(m.GuildID); guildID != "" || b.config.DMUserID != "" {
+		help += fmt.Sprintf("- Bot automatically joins when <@%s> joins <#%s>\n", b.config.DMUserFor(guildID), b.config.VoiceChannelFor(guildID))
+	} else {
+		help += "- Bot automatically joins when each server's DM joins its D&D voice channel\n"
+	}
 	help += "- Voice transcriptions are automatically captured when in voice channel"
+	if b.config.VoiceCommandsEnabled {
+		help += fmt.Sprintf("\n- The DM can speak commands, e.g. \"%s, flush\"", b.config.VoiceWakeWord)
+	}
 
 	if b.conversationManager != nil {
-		help += "\n- Transcriptions are buffered and auto-flushed to Claude every 10 seconds"
+		if interval := time.Duration(b.autoFlushInterval.Load()); interval > 0 {
+			help += fmt.Sprintf("\n- Transcriptions are buffered and auto-flushed to Claude every %v", interval)
+		}
 		help += "\n- Claude may respond automatically via DM when it has insights or answers"
 	}
 
 	s.ChannelMessageSend(m.ChannelID, help)
 }
 
-// checkDMInVoiceChannelAsync checks if the DM is already in the target voice channel
-func (b *Bot) checkDMInVoiceChannelAsync() {
-	log.Printf("Checking if DM is already in the target voice channel...")
+// checkGuildForDM joins the target voice channel if the DM is already in it in this guild.
+// It returns true if the bot joined.
+func (b *Bot) checkGuildForDM(guild *discordgo.Guild) bool {
+	if b.audioManager.IsProcessingGuild(guild.ID) {
+		return false
+	}
 
-	// Wait for Discord state to stabilize after connection
-	time.Sleep(startupDelay)
+	if b.debug.Load() {
+		log.Printf("Checking guild: %s (ID: %s)", guild.Name, guild.ID)
+	}
 
-	// Check each guild the bot is in
-	for _, guild := range b.session.State.Guilds {
-		if b.config.Debug {
-			log.Printf("Checking guild: %s (ID: %s)", guild.Name, guild.ID)
-		}
+	// Verify the target channel exists in this guild
+	if !b.isTargetChannelInGuild(guild.ID) {
+		return false
+	}
 
-		// Verify the target channel exists in this guild
-		if !b.isTargetChannelInGuild(guild.ID) {
-			continue
-		}
+	// Check if DM is in target voice channel
+	if !b.isDMInTargetChannel(guild) {
+		return false
+	}
 
-		// Check if DM is in target voice channel
-		if b.isDMInTargetChannel(guild) {
-			log.Printf("DM is already in the target D&D voice channel! Auto-joining...")
-			b.joinVoiceChannel(guild.ID, b.config.DNDVoiceChannelID)
-			return
-		}
+	if !b.playersPresent(guild.ID) {
+		log.Printf("DM is in the target D&D voice channel, waiting for a player with role %s before joining", b.config.PlayerRoleID)
+		return false
 	}
 
-	log.Printf("DM is not currently in the target D&D channel")
-	log.Printf("Bot will monitor for voice state changes and auto-join when DM joins the target channel")
+	log.Printf("DM is already in the target D&D voice channel! Auto-joining...")
+	b.joinVoiceChannel(guild.ID, b.config.VoiceChannelFor(guild.ID))
+	return true
 }
 
 // isTargetChannelInGuild checks if the target voice channel exists in the given guild
 func (b *Bot) isTargetChannelInGuild(guildID string) bool {
-	targetChannel, err := b.session.Channel(b.config.DNDVoiceChannelID)
+	targetChannelID := b.config.VoiceChannelFor(guildID)
+	if targetChannelID == "" {
+		return false
+	}
+	targetChannel, err := b.session.Channel(targetChannelID)
 	if err != nil {
-		if b.config.Debug {
-			log.Printf("Could not fetch target channel %s: %v", b.config.DNDVoiceChannelID, err)
+		if b.debug.Load() {
+			log.Printf("Could not fetch target channel %s: %v", targetChannelID, err)
 		}
 		return false
 	}
 
 	if targetChannel.GuildID != guildID {
-		if b.config.Debug {
+		if b.debug.Load() {
 			log.Printf("Target channel is not in this guild, skipping")
 		}
 		return false
 	}
 
-	if b.config.Debug {
+	if b.debug.Load() {
 		log.Printf("Found target D&D voice channel: %s", targetChannel.Name)
 	}
 	return true
@@ -428,49 +1076,91 @@ func (b *Bot) isTargetChannelInGuild(guildID string) bool {
 // isDMInTargetChannel checks if the DM is currently in the target voice channel
 func (b *Bot) isDMInTargetChannel(guild *discordgo.Guild) bool {
 	for _, vs := range guild.VoiceStates {
-		if vs.UserID == b.config.DMUserID {
-			if b.config.Debug {
+		if vs.UserID == b.config.DMUserFor(guild.ID) {
+			if b.debug.Load() {
 				log.Printf("Found DM in voice channel: %s", vs.ChannelID)
 			}
-			return vs.ChannelID == b.config.DNDVoiceChannelID
+			return vs.ChannelID == b.config.VoiceChannelFor(guild.ID)
 		}
 	}
 	return false
 }
 
 // joinVoiceChannel joins a voice channel and starts audio processing
-func (b *Bot) joinVoiceChannel(guildID, channelID string) {
+func (b *Bot) joinVoiceChannel(guildID, channelID string) error {
 	log.Printf("Attempting to join voice channel %s in guild %s", channelID, guildID)
 
-	// Join the voice channel with listening enabled
-	// Parameters: guildID, channelID, mute=false, deaf=false
-	vc, err := b.session.ChannelVoiceJoin(guildID, channelID, false, false)
+	// Joining (e.g. with the join command) overrides a leave the DM's departure scheduled
+	b.cancelLeave(guildID)
+
+	if current := b.connectedChannel(guildID); current != "" {
+		if current == channelID && b.audioManager.IsProcessingGuild(guildID) {
+			log.Printf("Already in voice channel %s, nothing to do", channelID)
+			return nil
+		}
+		if current != channelID && b.config.JoinWhileConnected == config.JoinWhileConnectedRefuse {
+			log.Printf("Already in voice channel %s, not moving to %s", current, channelID)
+			return fmt.Errorf("already connected to <#%s>; use `%s %s` first", current, b.config.CommandPrefix, commandLeave)
+		}
+
+		// Stop the old packet loop before the connection changes under it
+		log.Printf("Already in voice channel %s, restarting audio processing in %s", current, channelID)
+		b.audioManager.StopProcessing(guildID)
+	}
+
+	// Join the voice channel with listening enabled, unless deaf mode is on
+	// Parameters: guildID, channelID, mute=false, deaf
+	vc, err := b.session.ChannelVoiceJoin(guildID, channelID, false, b.audioManager.IsDeafened())
 	if err != nil {
 		log.Printf("Error joining voice channel: %v", err)
-		return
+		b.recordError(componentVoice, fmt.Errorf("joining channel %s: %w", channelID, err))
+		return fmt.Errorf("couldn't join the voice channel: %w", err)
 	}
 
 	log.Printf("Successfully joined voice channel (listening enabled)")
-	if b.config.Debug {
+	if b.debug.Load() {
 		log.Printf("Voice connection details: Ready=%v, UserID=%s", vc.Ready, vc.UserID)
 	}
 
-	// Start audio processing
-	if err := b.audioProcessor.StartProcessing(vc); err != nil {
+	// Start audio processing, transcribing only if speech is enabled for this guild
+	if err := b.audioManager.StartProcessing(vc, b.speechEnabledFor(guildID)); err != nil {
 		log.Printf("Error starting audio processing: %v", err)
+		b.recordError(componentVoice, fmt.Errorf("starting audio processing: %w", err))
 		// Still consider the join successful even if audio processing fails
-		return
+		return nil
 	}
 
 	log.Printf("Started audio processing")
+
+	// Speakers from an earlier session may not be here this time
+	b.updateRoster(guildID)
+	return nil
+}
+
+// connectedChannel returns the voice channel the bot is connected to in a guild, or "" if none
+func (b *Bot) connectedChannel(guildID string) string {
+	b.session.RLock()
+	vc, ok := b.session.VoiceConnections[guildID]
+	b.session.RUnlock()
+	if !ok {
+		return ""
+	}
+
+	vc.RLock()
+	defer vc.RUnlock()
+	return vc.ChannelID
 }
 
 // leaveVoiceChannel leaves the current voice channel in the specified guild
 func (b *Bot) leaveVoiceChannel(guildID string) {
 	log.Printf("Attempting to leave voice channel in guild %s", guildID)
+	b.cancelLeave(guildID)
 
 	// Stop audio processing first
-	b.audioProcessor.StopProcessing()
+	b.audioManager.StopProcessing(guildID)
+
+	// The guild's speakers are no longer at the table
+	b.updateRoster(guildID)
 
 	// Find and disconnect from the voice channel in this guild
 	for _, vc := range b.session.VoiceConnections {
@@ -489,8 +1179,7 @@ func (b *Bot) leaveVoiceChannel(guildID string) {
 
 // handleAskCommand handles the ask command for Claude
 func (b *Bot) handleAskCommand(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
-	if b.conversationManager == nil {
-		s.ChannelMessageSend(m.ChannelID, "❌ Claude assistant is not available. Please set ANTHROPIC_API_KEY.")
+	if !b.requireClaude(s, m) {
 		return
 	}
 
@@ -504,47 +1193,224 @@ func (b *Bot) handleAskCommand(s *discordgo.Session, m *discordgo.MessageCreate,
 	// Send typing indicator
 	s.ChannelTyping(m.ChannelID)
 
-	response, err := b.conversationManager.AskQuestion(question)
+	b.flushPendingTranscriptions(m.GuildID)
+	response, err := b.conversation(m.GuildID).AskQuestion(question)
 	if err != nil {
 		log.Printf("Error getting response from Claude: %v", err)
-		s.ChannelMessageSend(m.ChannelID, "❌ Failed to get response from Claude. Please try again.")
+		b.recordError(componentClaude, err)
+		s.ChannelMessageSend(m.ChannelID, claudeErrorMessage(err))
 		return
 	}
 
-	// Format the response with Claude prefix
-	formattedResponse := fmt.Sprintf("[CLAUDE] %s", response)
+	// Format the response with Claude prefix; long responses are split to fit Discord's limit
+	b.sendClaudeAnswer(s, m, "Claude: "+question, response)
+}
 
-	// Discord has a 2000 character limit, so split long responses
-	if len(formattedResponse) > 2000 {
-		chunks := splitMessage(formattedResponse, 2000)
-		for _, chunk := range chunks {
-			s.ChannelMessageSend(m.ChannelID, chunk)
+// handleNoteCommand adds context from the DM, such as a secret, to the conversation
+func (b *Bot) handleNoteCommand(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+	if !b.requireDM(s, m) || !b.requireClaude(s, m) {
+		return
+	}
+
+	if len(args) == 0 {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Please provide a note. Usage: `%s %s <text>`", b.config.CommandPrefix, commandNote))
+		return
+	}
+
+	if err := b.conversation(m.GuildID).AddNote(strings.Join(args, " ")); err != nil {
+		log.Printf("Error adding note: %v", err)
+		s.ChannelMessageSend(m.ChannelID, "❌ Failed to save the note.")
+		return
+	}
+
+	// Notes are often secrets; don't leave them visible to the players
+	if m.GuildID != "" {
+		if err := s.ChannelMessageDelete(m.ChannelID, m.ID); err != nil && b.debug.Load() {
+			log.Printf("Couldn't delete note command message: %v", err)
 		}
-	} else {
-		s.ChannelMessageSend(m.ChannelID, formattedResponse)
 	}
+
+	s.ChannelMessageSend(m.ChannelID, "📝 Noted. Claude will take this into account.")
 }
 
-// handleFlushCommand handles the flush command to send transcriptions to Claude
-func (b *Bot) handleFlushCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+// handleAutoFlushCommand changes how often transcriptions are flushed to Claude automatically
+func (b *Bot) handleAutoFlushCommand(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+	if !b.requireDM(s, m) || !b.requireClaude(s, m) {
+		return
+	}
+
+	if len(args) == 0 {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("⏱️ Auto-flush: %s. Usage: `%s %s <seconds>` (0 to stop)",
+			formatAutoFlush(time.Duration(b.autoFlushInterval.Load())), b.config.CommandPrefix, commandAutoFlush))
+		return
+	}
+
+	seconds, err := strconv.Atoi(args[0])
+	if err != nil || seconds < 0 || seconds > maxAutoFlushSeconds {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ The auto-flush interval must be between 0 and %d seconds.", maxAutoFlushSeconds))
+		return
+	}
+
+	interval := time.Duration(seconds) * time.Second
+	b.autoFlushInterval.Store(int64(interval))
+
+	// Don't wait on a flush in progress; a pending update already picks up the new interval
+	select {
+	case b.autoFlushUpdates <- struct{}{}:
+	default:
+	}
+
+	log.Printf("Auto-flush changed to %s by %s", formatAutoFlush(interval), m.Author.Username)
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Auto-flush: %s.", formatAutoFlush(interval)))
+}
+
+// formatAutoFlush describes an auto-flush interval
+func formatAutoFlush(interval time.Duration) string {
+	if interval == 0 {
+		return "off"
+	}
+	return fmt.Sprintf("every %v", interval)
+}
+
+// handleVerboseCommand makes Claude's next answer a detailed one, or cancels that
+func (b *Bot) handleVerboseCommand(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+	if !b.requireDM(s, m) || !b.requireClaude(s, m) {
+		return
+	}
+
+	if len(args) > 0 && strings.EqualFold(args[0], "off") {
+		b.conversation(m.GuildID).SetVerboseNext(false)
+		s.ChannelMessageSend(m.ChannelID, "📏 Next answer will be concise as usual.")
+		return
+	}
+
+	b.conversation(m.GuildID).SetVerboseNext(true)
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("📜 The next question (`%s %s` or `%s %s`) will get a detailed answer. Normal length resumes after that.",
+		b.config.CommandPrefix, commandAsk, b.config.CommandPrefix, commandSuggest))
+}
+
+// handleSuggestCommand flushes pending transcriptions and asks Claude what the DM could do next
+func (b *Bot) handleSuggestCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if !b.requireClaude(s, m) {
+		return
+	}
+
+	s.ChannelTyping(m.ChannelID)
+
+	// AskQuestion flushes the transcription buffer before asking
+	b.flushPendingTranscriptions(m.GuildID)
+	response, err := b.conversation(m.GuildID).AskQuestion(b.config.SuggestPrompt)
+	if err != nil {
+		log.Printf("Error getting suggestions from Claude: %v", err)
+		b.recordError(componentClaude, err)
+		s.ChannelMessageSend(m.ChannelID, claudeErrorMessage(err))
+		return
+	}
+
+	b.sendClaudeAnswer(s, m, "Claude: suggestions", response)
+}
+
+// handleContinueCommand asks Claude to finish a response that was cut off
+func (b *Bot) handleContinueCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if !b.requireClaude(s, m) {
+		return
+	}
+
+	s.ChannelTyping(m.ChannelID)
+
+	response, err := b.conversation(m.GuildID).ContinueResponse()
+	if errors.Is(err, claude.ErrNothingToContinue) {
+		s.ChannelMessageSend(m.ChannelID, "ℹ️ Claude's last answer wasn't cut off.")
+		return
+	}
+	if err != nil {
+		log.Printf("Error continuing response from Claude: %v", err)
+		b.recordError(componentClaude, err)
+		s.ChannelMessageSend(m.ChannelID, claudeErrorMessage(err))
+		return
+	}
+
+	b.sendClaudeAnswer(s, m, "Claude (continued)", response)
+}
+
+// requireGuild replies with an error and returns false if the message is a direct message
+// rather than one sent in a server channel
+func (b *Bot) requireGuild(s *discordgo.Session, m *discordgo.MessageCreate) bool {
+	if m.GuildID == "" {
+		s.ChannelMessageSend(m.ChannelID, "❌ This command needs a server: use it in a channel of the server with the voice channel.")
+		return false
+	}
+	return true
+}
+
+// requireClaude replies with an error and returns false if Claude can't be used for this message
+func (b *Bot) requireClaude(s *discordgo.Session, m *discordgo.MessageCreate) bool {
 	if b.conversationManager == nil {
 		s.ChannelMessageSend(m.ChannelID, "❌ Claude assistant is not available. Please set ANTHROPIC_API_KEY.")
+		return false
+	}
+	if !b.claudeEnabledFor(m.GuildID) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Claude assistant is turned off for this server.")
+		return false
+	}
+	return true
+}
+
+// claudeEnabledFor reports whether Claude is enabled in the given guild
+func (b *Bot) claudeEnabledFor(guildID string) bool {
+	return b.config.ClaudeEnabledForGuild(guildID, b.conversationManager != nil)
+}
+
+// speechEnabledFor reports whether speech-to-text is enabled in the given guild
+func (b *Bot) speechEnabledFor(guildID string) bool {
+	return b.config.SpeechEnabledForGuild(guildID, b.speechService != nil)
+}
+
+// handleRulesCommand answers a rules question without the session conversation
+func (b *Bot) handleRulesCommand(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+	if !b.requireClaude(s, m) {
+		return
+	}
+
+	if len(args) == 0 {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Please provide a rules question. Usage: `%s %s <question>`",
+			b.config.CommandPrefix, commandRules))
 		return
 	}
 
-	b.conversationManager.FlushTranscriptions()
-	summary := b.conversationManager.GetConversationSummary()
+	s.ChannelTyping(m.ChannelID)
+
+	answer, err := b.conversation(m.GuildID).LookupRule(strings.Join(args, " "))
+	if err != nil {
+		log.Printf("Error getting rules answer from Claude: %v", err)
+		b.recordError(componentClaude, err)
+		s.ChannelMessageSend(m.ChannelID, claudeErrorMessage(err))
+		return
+	}
+
+	b.sendLongMessage(s, m, "Rules: "+strings.Join(args, " "), fmt.Sprintf("📖 %s", answer))
+}
+
+// handleFlushCommand handles the flush command to send transcriptions to Claude
+func (b *Bot) handleFlushCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if !b.requireClaude(s, m) {
+		return
+	}
+
+	b.flushPendingTranscriptions(m.GuildID)
+	conversation := b.conversation(m.GuildID)
+	conversation.FlushTranscriptions()
+	summary := conversation.GetConversationSummary()
 	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Flushed transcriptions to Claude. %s", summary))
 }
 
 // handleClearCommand handles the clear command to clear conversation history
 func (b *Bot) handleClearCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
-	if b.conversationManager == nil {
-		s.ChannelMessageSend(m.ChannelID, "❌ Claude assistant is not available. Please set ANTHROPIC_API_KEY.")
+	if !b.requireClaude(s, m) {
 		return
 	}
 
-	err := b.conversationManager.ClearConversation()
+	err := b.conversation(m.GuildID).ClearConversation()
 	if err != nil {
 		log.Printf("Error clearing conversation: %v", err)
 		s.ChannelMessageSend(m.ChannelID, "❌ Failed to clear conversation history.")
@@ -554,25 +1420,200 @@ func (b *Bot) handleClearCommand(s *discordgo.Session, m *discordgo.MessageCreat
 	s.ChannelMessageSend(m.ChannelID, "✅ Conversation history cleared.")
 }
 
-// sendClaudeResponseToDM sends a Claude response as a direct message to the DM
-func (b *Bot) sendClaudeResponseToDM(response string) {
+// handleRecapCommand posts the most recent transcriptions without calling Claude
+func (b *Bot) handleRecapCommand(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+	if !b.requireClaude(s, m) {
+		return
+	}
+
+	count := defaultRecapCount
+	if len(args) > 0 {
+		parsed, err := strconv.Atoi(args[0])
+		if err != nil || parsed <= 0 {
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Usage: `%s %s [n]`", b.config.CommandPrefix, commandRecap))
+			return
+		}
+		count = min(parsed, maxRecapCount)
+	}
+
+	transcriptions := b.conversation(m.GuildID).RecentTranscriptions(count)
+	if len(transcriptions) == 0 {
+		s.ChannelMessageSend(m.ChannelID, "ℹ️ No transcriptions recorded yet.")
+		return
+	}
+
+	recap := fmt.Sprintf("**Last %d transcriptions:**\n", len(transcriptions))
+	for _, t := range transcriptions {
+		speaker := fmt.Sprintf("SSRC %d", t.SSRC)
+		if t.Speaker != "" {
+			speaker = t.Speaker
+		}
+		recap += fmt.Sprintf("`%s` **%s**: %s\n", t.Timestamp.Format("15:04:05"), speaker, t.Text)
+	}
+
+	b.sendLongMessage(s, m, "Recap", recap)
+}
+
+// handleHistoryLimitCommand changes how many conversation messages are kept
+func (b *Bot) handleHistoryLimitCommand(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+	if !b.requireDM(s, m) || !b.requireClaude(s, m) {
+		return
+	}
+
+	if len(args) == 0 {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("📚 History limit: %d messages. Usage: `%s %s <n>`",
+			b.conversation(m.GuildID).MaxMessages(), b.config.CommandPrefix, commandHistoryLimit))
+		return
+	}
+
+	limit, err := strconv.Atoi(args[0])
+	if err != nil || limit <= 0 {
+		s.ChannelMessageSend(m.ChannelID, "❌ The history limit must be a positive number.")
+		return
+	}
+
+	if err := b.conversation(m.GuildID).SetMaxMessages(limit); err != nil {
+		log.Printf("Error setting history limit: %v", err)
+		s.ChannelMessageSend(m.ChannelID, "❌ Failed to update the history limit.")
+		return
+	}
+
+	reply := fmt.Sprintf("✅ History limit set to %d messages.", limit)
+	if limit > b.config.MaxConversationMsgs*historyLimitWarnFactor {
+		reply += fmt.Sprintf("\n⚠️ That's well above the default of %d — every request will cost more tokens.",
+			b.config.MaxConversationMsgs)
+	}
+	s.ChannelMessageSend(m.ChannelID, reply)
+}
+
+// handleDebugCommand turns debug logging on or off across all components
+func (b *Bot) handleDebugCommand(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+	if !b.requireDM(s, m) {
+		return
+	}
+
+	if len(args) == 0 {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("🐛 Debug logging is %s. Usage: `%s %s on|off`",
+			onOff(b.debug.Load()), b.config.CommandPrefix, commandDebug))
+		return
+	}
+
+	var debug bool
+	switch strings.ToLower(args[0]) {
+	case "on", "true", "1":
+		debug = true
+	case "off", "false", "0":
+		debug = false
+	default:
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Usage: `%s %s on|off`", b.config.CommandPrefix, commandDebug))
+		return
+	}
+
+	b.setDebug(debug)
+	log.Printf("Debug logging turned %s by %s", onOff(debug), m.Author.Username)
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Debug logging turned %s.", onOff(debug)))
+}
+
+// setDebug sets the debug flag on the bot and every component that logs
+func (b *Bot) setDebug(debug bool) {
+	b.debug.Store(debug)
+	b.audioManager.SetDebug(debug)
+	if b.speechService != nil {
+		b.speechService.SetDebug(debug)
+	}
+	for _, conversation := range b.allConversations() {
+		conversation.SetDebug(debug)
+	}
+	if b.claudeService != nil {
+		b.claudeService.SetDebug(debug)
+	}
+	if b.searchIndex != nil {
+		b.searchIndex.SetDebug(debug)
+	}
+}
+
+// onOff formats a flag as "on" or "off"
+func onOff(enabled bool) string {
+	if enabled {
+		return "on"
+	}
+	return "off"
+}
+
+// yesNo formats a flag as "yes" or "no"
+func yesNo(value bool) string {
+	if value {
+		return "yes"
+	}
+	return "no"
+}
+
+// requireDM replies with an error and returns false if the message isn't from the DM
+func (b *Bot) requireDM(s *discordgo.Session, m *discordgo.MessageCreate) bool {
+	if !b.authorIsDM(m) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Only the DM can use this command.")
+		return false
+	}
+	return true
+}
+
+// requireDMOfAnyGuild is requireDM for commands that cover several guilds in a direct message.
+// There the author only has to be the DM of some guild; the command must then limit itself to
+// the guilds canSeeGuild allows.
+func (b *Bot) requireDMOfAnyGuild(s *discordgo.Session, m *discordgo.MessageCreate) bool {
+	if m.GuildID != "" {
+		return b.requireDM(s, m)
+	}
+	if m.Author == nil || !b.config.IsDMOfAnyGuild(m.Author.ID) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Only the DM can use this command.")
+		return false
+	}
+	return true
+}
+
+// canSeeGuild reports whether a message may be answered with a guild's session data: in a
+// server only that server's, in a direct message that of the guilds the author is DM of
+func (b *Bot) canSeeGuild(m *discordgo.MessageCreate, guildID string) bool {
+	if m.GuildID != "" {
+		return guildID == m.GuildID
+	}
+	return m.Author != nil && b.config.IsDM(guildID, m.Author.ID)
+}
+
+// authorIsDM reports whether the message's author is the DM of the guild it applies to. In a
+// direct message that's the guild sessionGuild picks, never the DM of some other guild.
+func (b *Bot) authorIsDM(m *discordgo.MessageCreate) bool {
+	return m.Author != nil && b.config.IsDM(b.sessionGuild(m.GuildID), m.Author.ID)
+}
+
+// sendClaudeResponseToDM sends a Claude response as a direct message to a guild's DM
+func (b *Bot) sendClaudeResponseToDM(guildID, response string) {
 	if response == "" {
 		return
 	}
 
 	// Create DM channel with the DM user
-	dmChannel, err := b.session.UserChannelCreate(b.config.DMUserID)
+	dmChannel, err := b.session.UserChannelCreate(b.config.DMUserFor(guildID))
 	if err != nil {
 		log.Printf("[BOT] ⚠️ Failed to create DM channel with DM: %v", err)
 		return
 	}
 
+	if b.config.UseEmbeds {
+		for _, embed := range claudeEmbeds("Claude", response) {
+			if _, err := b.session.ChannelMessageSendEmbed(dmChannel.ID, embed); err != nil {
+				log.Printf("[BOT] ⚠️ Failed to send Claude response embed to DM: %v", err)
+			}
+		}
+		return
+	}
+
 	// Format the response with Claude prefix
 	formattedResponse := fmt.Sprintf("[CLAUDE] %s", response)
 
 	// Discord has a 2000 character limit, so split long responses
-	if len(formattedResponse) > 2000 {
-		chunks := splitMessage(formattedResponse, 2000)
+	if len(formattedResponse) > discordMessageLimit {
+		chunks := splitMessage(formattedResponse, discordMessageLimit)
 		for _, chunk := range chunks {
 			if _, err := b.session.ChannelMessageSend(dmChannel.ID, chunk); err != nil {
 				log.Printf("[BOT] ⚠️ Failed to send Claude response chunk to DM: %v", err)
@@ -585,6 +1626,22 @@ func (b *Bot) sendClaudeResponseToDM(response string) {
 	}
 }
 
+// claudeErrorMessage returns a user-facing message describing a Claude API failure
+func claudeErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, claude.ErrRateLimited):
+		return "⏳ Claude is rate-limited, try again shortly."
+	case errors.Is(err, claude.ErrOverloaded):
+		return "⏳ Claude is overloaded right now, try again shortly."
+	case errors.Is(err, claude.ErrAuth):
+		return "❌ Claude auth failed — check your API key."
+	case errors.Is(err, claude.ErrBadRequest):
+		return "❌ Claude rejected the request. The conversation may be too long; try `clear`."
+	default:
+		return "❌ Failed to get response from Claude. Please try again."
+	}
+}
+
 // splitMessage splits a message into chunks that fit Discord's character limit
 func splitMessage(message string, maxLength int) []string {
 	if len(message) <= maxLength {
@@ -618,38 +1675,61 @@ func splitMessage(message string, maxLength int) []string {
 	return chunks
 }
 
-// autoFlushTranscriptions runs in the background to automatically flush transcriptions every 10 seconds
+// autoFlushTranscriptions runs in the background to automatically flush transcriptions at the
+// auto-flush interval, picking up interval changes from autoFlushUpdates
 func (b *Bot) autoFlushTranscriptions() {
-	ticker := time.NewTicker(10 * time.Second)
-	defer ticker.Stop()
+	var ticker *time.Ticker
+	var tick <-chan time.Time
+	setInterval := func(interval time.Duration) {
+		if ticker != nil {
+			ticker.Stop()
+			ticker, tick = nil, nil
+		}
+		if interval > 0 {
+			ticker = time.NewTicker(interval)
+			tick = ticker.C
+		}
+	}
+	setInterval(time.Duration(b.autoFlushInterval.Load()))
+	defer setInterval(0)
 
-	if b.config.Debug {
+	if b.debug.Load() {
 		log.Printf("[BOT] Started auto-flush transcriptions background process")
 	}
 
 	for {
 		select {
-		case <-ticker.C:
-			// Check if there are transcriptions to flush
-			if b.conversationManager != nil && b.conversationManager.HasPendingTranscriptions() {
-				if b.config.Debug {
+		case <-b.autoFlushUpdates:
+			interval := time.Duration(b.autoFlushInterval.Load())
+			setInterval(interval)
+			if b.debug.Load() {
+				log.Printf("[BOT] Auto-flush interval changed to %v", interval)
+			}
+		case <-tick:
+			// Check each conversation for transcriptions to flush
+			for _, conversation := range b.allConversations() {
+				if !conversation.HasPendingTranscriptions() {
+					continue
+				}
+				if b.debug.Load() {
 					log.Printf("[BOT] Auto-flushing transcriptions to Claude and requesting response")
 				}
 
 				// Flush transcriptions and get Claude's response
-				response, err := b.conversationManager.FlushTranscriptionsAndRespond()
+				response, err := conversation.FlushTranscriptionsAndRespond()
 				if err != nil {
 					log.Printf("[BOT] ⚠️ Failed to get Claude response during auto-flush: %v", err)
+					b.recordError(componentClaude, fmt.Errorf("auto-flush: %w", err))
 				} else if response != "" {
 					// Send Claude's response to the DM
-					b.sendClaudeResponseToDM(response)
-					if b.config.Debug {
+					b.sendClaudeResponseToDM(b.conversationGuild(conversation), response)
+					if b.debug.Load() {
 						log.Printf("[BOT] Sent Claude auto-response to DM (%d chars)", len(response))
 					}
 				}
 			}
 		case <-b.stopAutoFlush:
-			if b.config.Debug {
+			if b.debug.Load() {
 				log.Printf("[BOT] Stopped auto-flush transcriptions background process")
 			}
 			return
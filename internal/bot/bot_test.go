@@ -0,0 +1,129 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"dnd_dm_assistant_go/internal/audio"
+	"dnd_dm_assistant_go/internal/config"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// newTestBot returns a bot with no Discord connection, processing audio in memory
+func newTestBot(cfg *config.Config) *Bot {
+	if cfg.CommandPrefix == "" {
+		cfg.CommandPrefix = "!dm"
+	}
+	return &Bot{
+		config:        cfg,
+		session:       &discordgo.Session{State: discordgo.NewState(), VoiceConnections: make(map[string]*discordgo.VoiceConnection)},
+		audioManager:  audio.NewManager(false, nil, audio.Options{Ephemeral: true}),
+		pendingLeaves: make(map[string]*time.Timer),
+		displayNames:  make(map[displayNameKey]cachedDisplayName),
+		clock:         &fixedClock{now: time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)},
+	}
+}
+
+// fixedClock is a clock that only moves when the test sets it
+type fixedClock struct {
+	now time.Time
+}
+
+func (c *fixedClock) Now() time.Time {
+	return c.now
+}
+
+// connectTestVoice makes the bot look connected to a voice channel, with audio processing running
+func connectTestVoice(t *testing.T, b *Bot, guildID, channelID string) {
+	t.Helper()
+	vc := &discordgo.VoiceConnection{GuildID: guildID, ChannelID: channelID, OpusRecv: make(chan *discordgo.Packet)}
+	b.session.VoiceConnections[guildID] = vc
+	if err := b.audioManager.StartProcessing(vc, false); err != nil {
+		t.Fatalf("StartProcessing: %v", err)
+	}
+	t.Cleanup(func() { b.audioManager.StopProcessing(guildID) })
+}
+
+func TestJoinChannelAlreadyConnectedDoesNothing(t *testing.T) {
+	b := newTestBot(&config.Config{JoinWhileConnected: config.JoinWhileConnectedMove})
+	connectTestVoice(t, b, "guild", "table")
+
+	if err := b.joinVoiceChannel("guild", "table"); err != nil {
+		t.Fatalf("joining the connected channel: %v", err)
+	}
+	if !b.audioManager.IsProcessingGuild("guild") {
+		t.Fatal("audio processing stopped")
+	}
+}
+
+func TestJoinWhileConnectedRefuses(t *testing.T) {
+	b := newTestBot(&config.Config{JoinWhileConnected: config.JoinWhileConnectedRefuse})
+	connectTestVoice(t, b, "guild", "table")
+
+	err := b.joinVoiceChannel("guild", "tavern")
+	if err == nil {
+		t.Fatal("expected an error joining another channel")
+	}
+	if !strings.Contains(err.Error(), "<#table>") || !strings.Contains(err.Error(), "!dm leave") {
+		t.Errorf("error = %q, want it to name the current channel and the leave command", err)
+	}
+	if !b.audioManager.IsProcessingGuild("guild") {
+		t.Fatal("refusing to move stopped audio processing")
+	}
+}
+
+func TestDirectMessageDMIsCheckedAgainstTheSessionGuild(t *testing.T) {
+	b := newTestBot(&config.Config{Guilds: map[string]config.GuildSettings{
+		"guildA": {DMUserID: "dmA"},
+		"guildB": {DMUserID: "dmB"},
+	}})
+	connectTestVoice(t, b, "guildB", "table")
+
+	directMessage := func(authorID string) *discordgo.MessageCreate {
+		return &discordgo.MessageCreate{Message: &discordgo.Message{Author: &discordgo.User{ID: authorID}}}
+	}
+	if b.authorIsDM(directMessage("dmA")) {
+		t.Error("guild A's DM passed as DM of guild B's session")
+	}
+	if !b.authorIsDM(directMessage("dmB")) {
+		t.Error("guild B's DM was refused for their own session")
+	}
+
+	// With two sessions a direct message doesn't pick one, so neither guild's DM passes
+	connectTestVoice(t, b, "guildA", "table")
+	if b.authorIsDM(directMessage("dmA")) || b.authorIsDM(directMessage("dmB")) {
+		t.Error("a guild's DM passed with no single session to apply the command to")
+	}
+}
+
+func TestCanSeeGuild(t *testing.T) {
+	b := newTestBot(&config.Config{DMUserID: "dm", Guilds: map[string]config.GuildSettings{
+		"guildA": {DMUserID: "dmA"},
+		"guildB": {DMUserID: "dmB"},
+	}})
+
+	message := func(guildID, authorID string) *discordgo.MessageCreate {
+		return &discordgo.MessageCreate{Message: &discordgo.Message{GuildID: guildID, Author: &discordgo.User{ID: authorID}}}
+	}
+	tests := []struct {
+		name    string
+		m       *discordgo.MessageCreate
+		guildID string
+		want    bool
+	}{
+		{"server's own session", message("guildA", "player"), "guildA", true},
+		{"another server's session", message("guildA", "dmA"), "guildB", false},
+		{"direct message from the guild's DM", message("", "dmA"), "guildA", true},
+		{"direct message from another guild's DM", message("", "dmA"), "guildB", false},
+		{"direct message from DM_USER_ID to a configured guild", message("", "dm"), "guildA", false},
+		{"direct message from DM_USER_ID to another guild", message("", "dm"), "guildC", true},
+		{"direct message from a player", message("", "player"), "guildA", false},
+	}
+	for _, tt := range tests {
+		if got := b.canSeeGuild(tt.m, tt.guildID); got != tt.want {
+			t.Errorf("%s: canSeeGuild = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package bot
+
+import (
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// handleBudgetCommand shows how much of the model's context window the next question would use
+func (b *Bot) handleBudgetCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if !b.requireClaude(s, m) {
+		return
+	}
+
+	budget := b.conversation(m.GuildID).ContextBudget()
+	percent := float64(budget.Total()) * 100 / float64(budget.ContextWindow)
+
+	reply := "**Context budget** (estimated)\n"
+	reply += fmt.Sprintf("📜 System prompt: ~%d tokens\n", budget.SystemTokens)
+	reply += fmt.Sprintf("💬 History: ~%d tokens in %d messages\n", budget.HistoryTokens, budget.Messages)
+	reply += fmt.Sprintf("⏳ Pending transcriptions: ~%d tokens\n", budget.PendingTokens)
+	reply += fmt.Sprintf("✍️ Reserved for the reply: %d tokens\n", budget.ReplyTokens)
+	reply += fmt.Sprintf("📊 Total: ~%d of %d tokens (%.0f%%), ~%d left\n",
+		budget.Total(), budget.ContextWindow, percent, max(budget.Remaining(), 0))
+
+	if left := budget.MaxMessages - budget.Messages; left > 0 {
+		reply += fmt.Sprintf("📚 %d more messages before the oldest are compacted (limit %d)\n", left, budget.MaxMessages)
+	} else {
+		reply += fmt.Sprintf("📚 At the history limit of %d messages: the oldest are compacted as new ones arrive\n", budget.MaxMessages)
+	}
+
+	if budget.Remaining() < 0 {
+		reply += fmt.Sprintf("\n🚨 The next question will likely exceed the context window. Use `%s %s` or lower `%s %s`.",
+			b.config.CommandPrefix, commandClear, b.config.CommandPrefix, commandHistoryLimit)
+	} else if budget.NearlyFull() {
+		reply += fmt.Sprintf("\n⚠️ The context is nearly full. Consider `%s %s` or a lower `%s %s`.",
+			b.config.CommandPrefix, commandClear, b.config.CommandPrefix, commandHistoryLimit)
+	}
+
+	s.ChannelMessageSend(m.ChannelID, reply)
+}
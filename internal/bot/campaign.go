@@ -0,0 +1,98 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"dnd_dm_assistant_go/internal/config"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// campaignClearArg removes the campaign name
+const campaignClearArg = "clear"
+
+// loadCampaign picks the campaign name saved with the conversation, falling back to
+// CAMPAIGN_NAME, and applies it to recordings
+func (b *Bot) loadCampaign() {
+	name := b.config.CampaignName
+	if b.conversationManager != nil {
+		if saved := b.conversationManager.Campaign(); saved != "" {
+			name = saved
+		} else if name != "" {
+			if err := b.conversationManager.SetCampaign(name); err != nil {
+				log.Printf("[BOT] ⚠️ Failed to save campaign name: %v", err)
+			}
+		}
+	}
+
+	b.campaignMutex.Lock()
+	b.campaign = name
+	b.campaignMutex.Unlock()
+
+	b.audioManager.SetCampaign(name)
+	if name != "" {
+		log.Printf("🏰 Campaign: %s", name)
+	}
+}
+
+// campaignName returns the active campaign name, or "" if none is set
+func (b *Bot) campaignName() string {
+	b.campaignMutex.Lock()
+	defer b.campaignMutex.Unlock()
+	return b.campaign
+}
+
+// setCampaign changes the active campaign name and saves it with the conversation
+func (b *Bot) setCampaign(name string) error {
+	b.campaignMutex.Lock()
+	b.campaign = name
+	b.campaignMutex.Unlock()
+
+	b.audioManager.SetCampaign(name)
+	for _, conversation := range b.allConversations() {
+		if err := conversation.SetCampaign(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handleCampaignCommand shows or changes the campaign name used in filenames, prompts and status
+func (b *Bot) handleCampaignCommand(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+	if len(args) == 0 {
+		if name := b.campaignName(); name != "" {
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("🏰 Campaign: **%s**", name))
+		} else {
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("🏰 No campaign name set. Usage: `%s %s <name>|%s`",
+				b.config.CommandPrefix, commandCampaign, campaignClearArg))
+		}
+		return
+	}
+
+	if !b.requireDM(s, m) {
+		return
+	}
+
+	name := strings.Join(args, " ")
+	if strings.EqualFold(name, campaignClearArg) {
+		name = ""
+	}
+	if len(name) > config.MaxCampaignNameLength {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Campaign names can be at most %d characters.", config.MaxCampaignNameLength))
+		return
+	}
+
+	if err := b.setCampaign(name); err != nil {
+		log.Printf("Error saving campaign name: %v", err)
+		s.ChannelMessageSend(m.ChannelID, "⚠️ Campaign name changed, but it couldn't be saved and will be lost on restart.")
+		return
+	}
+
+	if name == "" {
+		s.ChannelMessageSend(m.ChannelID, "🏰 Campaign name cleared.")
+	} else {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("🏰 Campaign set to **%s**. New recordings and exports are named after it.", name))
+	}
+}
@@ -0,0 +1,152 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// monitoredChannelIDs returns the voice channels the bot watches for the DM
+func (b *Bot) monitoredChannelIDs() []string {
+	return b.config.VoiceChannelIDs()
+}
+
+// verifyMonitoredChannels warns about configured voice channels that don't exist or can't be seen
+func (b *Bot) verifyMonitoredChannels() {
+	for _, channelID := range b.monitoredChannelIDs() {
+		channel, err := b.session.Channel(channelID)
+		if err != nil {
+			b.warn(fmt.Sprintf("Configured D&D voice channel %s can't be found (%v). "+
+				"The bot will never auto-join until DND_VOICE_CHANNEL_ID is fixed.", channelID, err))
+			continue
+		}
+
+		if channel.Type != discordgo.ChannelTypeGuildVoice && channel.Type != discordgo.ChannelTypeGuildStageVoice {
+			b.warn(fmt.Sprintf("Configured D&D voice channel %s (%s) is not a voice channel.", channelID, channel.Name))
+			continue
+		}
+
+		log.Printf("✅ Monitoring voice channel %s (%s)", channel.Name, channelID)
+	}
+}
+
+// onChannelDelete warns when a monitored voice channel is deleted
+func (b *Bot) onChannelDelete(s *discordgo.Session, c *discordgo.ChannelDelete) {
+	if !slices.Contains(b.monitoredChannelIDs(), c.ID) {
+		return
+	}
+
+	b.warn(fmt.Sprintf("The D&D voice channel %s (%s) was deleted. "+
+		"The bot will never auto-join until DND_VOICE_CHANNEL_ID is updated.", c.Name, c.ID))
+}
+
+// onChannelUpdate logs when a monitored voice channel is renamed
+func (b *Bot) onChannelUpdate(s *discordgo.Session, c *discordgo.ChannelUpdate) {
+	if !slices.Contains(b.monitoredChannelIDs(), c.ID) || c.BeforeUpdate == nil {
+		return
+	}
+
+	// Channels are tracked by ID, so a rename needs no action
+	if c.BeforeUpdate.Name != c.Name {
+		log.Printf("ℹ️  D&D voice channel %s renamed from %q to %q", c.ID, c.BeforeUpdate.Name, c.Name)
+	}
+}
+
+// warn logs a prominent warning and posts it to the announce channel if one is configured
+func (b *Bot) warn(message string) {
+	log.Printf("⚠️ ⚠️ ⚠️  WARNING: %s", message)
+
+	if b.config.AnnounceChannelID == "" || b.quietNow() {
+		return
+	}
+	if _, err := b.session.ChannelMessageSend(b.config.AnnounceChannelID, "⚠️ "+message); err != nil {
+		log.Printf("[BOT] ⚠️ Failed to post warning to announce channel: %v", err)
+	}
+}
+
+// postStartupMessage announces that the bot is online and which features are enabled
+func (b *Bot) postStartupMessage() {
+	if !b.config.StartupMessageEnabled || b.config.AnnounceChannelID == "" {
+		return
+	}
+	if b.quietNow() {
+		log.Printf("[BOT] Quiet hours (%s), not posting the startup message", b.config.QuietHours)
+		return
+	}
+
+	message := b.config.StartupMessage
+	message += fmt.Sprintf("\n• Speech-to-text: %s", onOff(b.speechService != nil))
+	message += fmt.Sprintf("\n• Claude assistant: %s", onOff(b.conversationManager != nil))
+	message += fmt.Sprintf("\n• Voice commands: %s", onOff(b.config.VoiceCommandsEnabled))
+	if !b.config.Persist {
+		message += "\n• Ephemeral mode: nothing is written to disk"
+	}
+	message += fmt.Sprintf("\nType `%s %s` for commands.", b.config.CommandPrefix, commandHelp)
+
+	if _, err := b.session.ChannelMessageSend(b.config.AnnounceChannelID, message); err != nil {
+		log.Printf("[BOT] ⚠️ Failed to post startup message: %v", err)
+	}
+}
+
+// handleChannelsCommand lists the voice channels the bot can see, marking the monitored ones
+func (b *Bot) handleChannelsCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if !b.requireDM(s, m) {
+		return
+	}
+
+	// From a private message, list every server the bot is in
+	var guilds []*discordgo.Guild
+	if m.GuildID != "" {
+		guild, err := s.State.Guild(m.GuildID)
+		if err != nil {
+			s.ChannelMessageSend(m.ChannelID, "❌ Unable to access guild information.")
+			return
+		}
+		guilds = append(guilds, guild)
+	} else {
+		guilds = s.State.Guilds
+	}
+
+	monitored := b.monitoredChannelIDs()
+	list := ""
+	for _, guild := range guilds {
+		var voiceChannels []*discordgo.Channel
+		for _, channel := range guild.Channels {
+			if channel.Type == discordgo.ChannelTypeGuildVoice || channel.Type == discordgo.ChannelTypeGuildStageVoice {
+				voiceChannels = append(voiceChannels, channel)
+			}
+		}
+		sort.Slice(voiceChannels, func(i, j int) bool {
+			return voiceChannels[i].Position < voiceChannels[j].Position
+		})
+
+		list += fmt.Sprintf("**%s** voice channels:\n", guild.Name)
+		if len(voiceChannels) == 0 {
+			list += "   (none visible)\n"
+		}
+		for _, channel := range voiceChannels {
+			marker := ""
+			if slices.Contains(monitored, channel.ID) {
+				marker = " 🎯 (monitored)"
+			}
+			list += fmt.Sprintf("   • %s — `%s`%s\n", channel.Name, channel.ID, marker)
+		}
+		list += "\n"
+	}
+
+	if list == "" {
+		s.ChannelMessageSend(m.ChannelID, "ℹ️ The bot isn't in any servers.")
+		return
+	}
+
+	b.sendLongMessage(s, m, "Voice channels", list)
+}
+
+// quietNow reports whether it's within quiet hours, when the bot only answers commands and
+// doesn't post to channels on its own
+func (b *Bot) quietNow() bool {
+	return b.config.QuietHours.Contains(b.clock.Now())
+}
@@ -0,0 +1,33 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"dnd_dm_assistant_go/internal/config"
+)
+
+func TestQuietNowUsesClock(t *testing.T) {
+	b := newTestBot(&config.Config{QuietHours: config.QuietHours{
+		Start:    22 * time.Hour,
+		End:      8 * time.Hour,
+		Location: time.UTC,
+	}})
+	clock := b.clock.(*fixedClock)
+
+	tests := []struct {
+		at   time.Time
+		want bool
+	}{
+		{time.Date(2024, 1, 2, 21, 59, 0, 0, time.UTC), false},
+		{time.Date(2024, 1, 2, 22, 0, 0, 0, time.UTC), true},
+		{time.Date(2024, 1, 3, 3, 0, 0, 0, time.UTC), true},
+		{time.Date(2024, 1, 3, 8, 0, 0, 0, time.UTC), false},
+	}
+	for _, tt := range tests {
+		clock.now = tt.at
+		if got := b.quietNow(); got != tt.want {
+			t.Errorf("quietNow at %s = %v, want %v", tt.at.Format("15:04"), got, tt.want)
+		}
+	}
+}
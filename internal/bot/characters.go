@@ -0,0 +1,156 @@
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// characterNamesFile holds the character names set with the name command, by campaign
+	characterNamesFile = "character_names.json"
+
+	// Longest character name accepted by the name command
+	maxCharacterNameLength = 50
+
+	// nameClearArg removes a user's character name
+	nameClearArg = "clear"
+)
+
+// loadCharacterNames loads the saved character names, keyed by campaign and then user ID
+func (b *Bot) loadCharacterNames() {
+	names := make(map[string]map[string]string)
+
+	if b.config.Persist {
+		data, err := os.ReadFile(b.dirs.State(characterNamesFile))
+		switch {
+		case err == nil:
+			if err := json.Unmarshal(data, &names); err != nil {
+				log.Printf("[BOT] ⚠️ Failed to parse %s, starting without character names: %v", characterNamesFile, err)
+				names = make(map[string]map[string]string)
+			}
+		case !errors.Is(err, os.ErrNotExist):
+			log.Printf("[BOT] ⚠️ Failed to read %s, starting without character names: %v", characterNamesFile, err)
+		}
+	}
+
+	b.characterNamesMutex.Lock()
+	b.characterNames = names
+	b.characterNamesMutex.Unlock()
+}
+
+// characterName returns the character name set for a user in the active campaign, if any
+func (b *Bot) characterName(userID string) (string, bool) {
+	campaign := b.campaignName()
+
+	b.characterNamesMutex.Lock()
+	defer b.characterNamesMutex.Unlock()
+	name, ok := b.characterNames[campaign][userID]
+	return name, ok
+}
+
+// setCharacterName sets or, with an empty name, removes a user's character name in the
+// active campaign and saves the names
+func (b *Bot) setCharacterName(userID, name string) error {
+	campaign := b.campaignName()
+
+	b.characterNamesMutex.Lock()
+	defer b.characterNamesMutex.Unlock()
+
+	if name == "" {
+		delete(b.characterNames[campaign], userID)
+		if len(b.characterNames[campaign]) == 0 {
+			delete(b.characterNames, campaign)
+		}
+	} else {
+		if b.characterNames[campaign] == nil {
+			b.characterNames[campaign] = make(map[string]string)
+		}
+		b.characterNames[campaign][userID] = name
+	}
+
+	if !b.config.Persist {
+		return nil
+	}
+	data, err := json.MarshalIndent(b.characterNames, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(b.dirs.State(characterNamesFile), data, 0644)
+}
+
+// handleNameCommand sets or clears the character name a user is labeled with in transcriptions,
+// recordings and exports, or lists the names set for the active campaign
+func (b *Bot) handleNameCommand(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+	if !b.requireDM(s, m) {
+		return
+	}
+
+	usage := fmt.Sprintf("Usage: `%s %s @user <character name>|%s`", b.config.CommandPrefix, commandName, nameClearArg)
+	if len(m.Mentions) == 0 {
+		b.characterNamesMutex.Lock()
+		names := b.characterNames[b.campaignName()]
+		userIDs := make([]string, 0, len(names))
+		for userID := range names {
+			userIDs = append(userIDs, userID)
+		}
+		slices.Sort(userIDs)
+		list := ""
+		for _, userID := range userIDs {
+			list += fmt.Sprintf("   • <@%s>: **%s**\n", userID, names[userID])
+		}
+		b.characterNamesMutex.Unlock()
+
+		if list == "" {
+			s.ChannelMessageSend(m.ChannelID, "ℹ️ No character names set. "+usage)
+			return
+		}
+		s.ChannelMessageSend(m.ChannelID, "🎭 Character names:\n"+list+usage)
+		return
+	}
+	if len(m.Mentions) > 1 {
+		s.ChannelMessageSend(m.ChannelID, "❌ Name one user at a time. "+usage)
+		return
+	}
+	user := m.Mentions[0]
+
+	// Everything but the mention is the name
+	var words []string
+	for _, arg := range args {
+		if arg != "<@"+user.ID+">" && arg != "<@!"+user.ID+">" {
+			words = append(words, arg)
+		}
+	}
+	name := strings.Join(words, " ")
+	if name == "" {
+		s.ChannelMessageSend(m.ChannelID, "❌ Give a character name. "+usage)
+		return
+	}
+	if strings.EqualFold(name, nameClearArg) {
+		name = ""
+	}
+	if len(name) > maxCharacterNameLength {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Character names can be at most %d characters.", maxCharacterNameLength))
+		return
+	}
+
+	err := b.setCharacterName(user.ID, name)
+	b.updateAllRosters()
+	if err != nil {
+		log.Printf("Error saving character names: %v", err)
+		s.ChannelMessageSend(m.ChannelID, "⚠️ Name changed, but it couldn't be saved and will be lost on restart.")
+		return
+	}
+
+	if name == "" {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("🎭 <@%s> is labeled with their Discord name again.", user.ID))
+	} else {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("🎭 <@%s> is now labeled **%s** in transcriptions, new recordings and exports.", user.ID, name))
+	}
+}
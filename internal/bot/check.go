@@ -0,0 +1,105 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"dnd_dm_assistant_go/internal/dnd"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// checkModifierPattern matches a signed modifier such as +3 or -1
+var checkModifierPattern = regexp.MustCompile(`^[+-]\d{1,2}$`)
+
+// handleCheckCommand rolls an ability check against a DC and, if Claude is available,
+// narrates the outcome
+func (b *Bot) handleCheckCommand(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+	usage := fmt.Sprintf("Usage: `%s %s <ability> <DC> [+modifier] [adv|dis] [name]`", b.config.CommandPrefix, commandCheck)
+
+	if len(args) < 2 {
+		s.ChannelMessageSend(m.ChannelID, "❌ Please provide an ability and a DC. "+usage)
+		return
+	}
+
+	ability, err := dnd.ParseAbility(args[0])
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ %v. %s", err, usage))
+		return
+	}
+	dc, err := strconv.Atoi(args[1])
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, "❌ DC must be a number. "+usage)
+		return
+	}
+
+	// The modifier, advantage and name can come in any order
+	modifier := 0
+	mode := dnd.Normal
+	var nameWords []string
+	for _, arg := range args[2:] {
+		if checkModifierPattern.MatchString(arg) {
+			modifier, _ = strconv.Atoi(arg)
+		} else if parsed, ok := dnd.ParseRollMode(arg); ok {
+			mode = parsed
+		} else {
+			nameWords = append(nameWords, arg)
+		}
+	}
+	name := strings.Join(nameWords, " ")
+
+	result, err := dnd.RollCheck(modifier, dc, mode)
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ %v. %s", err, usage))
+		return
+	}
+
+	summary := formatCheck(ability, name, result)
+	s.ChannelMessageSend(m.ChannelID, "🎲 "+summary)
+
+	if !b.config.CheckNarration || b.conversationManager == nil || !b.claudeEnabledFor(m.GuildID) {
+		return
+	}
+
+	s.ChannelTyping(m.ChannelID)
+	narration, err := b.conversation(m.GuildID).NarrateOutcome(summary)
+	if err != nil {
+		log.Printf("Error narrating check: %v", err)
+		b.recordError(componentClaude, err)
+		return
+	}
+	s.ChannelMessageSend(m.ChannelID, "📖 *"+strings.TrimSpace(narration)+"*")
+}
+
+// formatCheck describes a check result, e.g. "Alice's Dexterity check (advantage): 14, ~~7~~ +3 = **17** vs DC 15: **success**"
+func formatCheck(ability, name string, result dnd.CheckResult) string {
+	who := ability + " check"
+	if name != "" {
+		who = name + "'s " + who
+	}
+	if result.Mode != dnd.Normal {
+		who += fmt.Sprintf(" (%s)", result.Mode)
+	}
+
+	rolls := make([]string, len(result.Rolls))
+	for i, roll := range result.Rolls {
+		rolls[i] = strconv.Itoa(roll)
+		if len(result.Rolls) > 1 && roll != result.Kept {
+			rolls[i] = "~~" + rolls[i] + "~~"
+		}
+	}
+	// Both dice can show the same number; only strike one of them
+	if len(result.Rolls) == 2 && result.Rolls[0] == result.Rolls[1] {
+		rolls[1] = "~~" + rolls[1] + "~~"
+	}
+
+	outcome := "failure"
+	if result.Success {
+		outcome = "success"
+	}
+	return fmt.Sprintf("%s: %s %+d = **%d** vs DC %d: **%s**",
+		who, strings.Join(rolls, ", "), result.Modifier, result.Total, result.DC, outcome)
+}
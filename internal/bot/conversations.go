@@ -0,0 +1,111 @@
+package bot
+
+import (
+	"log"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"dnd_dm_assistant_go/internal/claude"
+	"dnd_dm_assistant_go/internal/config"
+)
+
+// newConversationManager creates a conversation manager for a history file ("" = in memory only)
+// with the configured history, saving and buffering options
+func newConversationManager(cfg *config.Config, service claude.Backend, path string, debug bool) *claude.ConversationManager {
+	manager := claude.NewConversationManager(service, path, cfg.MaxConversationMsgs, debug)
+	manager.SetCompactStrategy(claude.CompactStrategy(cfg.CompactStrategy))
+	manager.SetSaveInterval(cfg.SaveInterval)
+	manager.SetContextWindow(cfg.ContextWindowTokens)
+	manager.SetBufferOptions(claude.BufferOptions{
+		MaxLines:      cfg.TranscriptionBufferMaxLines,
+		MaxChars:      cfg.TranscriptionBufferMaxChars,
+		FilterFiller:  cfg.FilterFillerTranscriptions,
+		MinConfidence: cfg.MinTranscriptionConfidence,
+
+		LowConfidenceThreshold: cfg.LowConfidenceThreshold,
+		LowConfidenceMarker:    cfg.LowConfidenceMarker,
+	})
+	return manager
+}
+
+// guildConversationFile derives a guild's history file from the configured one,
+// e.g. dnd_conversation.json becomes dnd_conversation_<guildID>.json
+func guildConversationFile(name, guildID string) string {
+	ext := filepath.Ext(name)
+	return strings.TrimSuffix(name, ext) + "_" + guildID + ext
+}
+
+// conversation returns the conversation for a guild. With per-guild conversations each guild
+// gets its own history file, created on first use; otherwise every guild shares one. Messages
+// outside a guild use the guild of the only active voice session, or the shared conversation.
+// It returns nil if Claude isn't configured.
+func (b *Bot) conversation(guildID string) *claude.ConversationManager {
+	if b.conversationManager == nil || !b.config.ConversationPerGuild {
+		return b.conversationManager
+	}
+
+	if guildID = b.sessionGuild(guildID); guildID == "" {
+		return b.conversationManager
+	}
+
+	b.conversationsMutex.Lock()
+	defer b.conversationsMutex.Unlock()
+
+	if manager, ok := b.conversations[guildID]; ok {
+		return manager
+	}
+
+	var path string
+	if b.config.Persist {
+		path = b.dirs.Conversation(guildConversationFile(b.config.ConversationFile, guildID))
+	}
+	manager := newConversationManager(b.config, b.claudeService, path, b.debug.Load())
+	if name := b.campaignName(); name != manager.Campaign() {
+		if err := manager.SetCampaign(name); err != nil {
+			log.Printf("[BOT] ⚠️ Failed to save campaign name for guild %s: %v", guildID, err)
+		}
+	}
+
+	b.conversations[guildID] = manager
+	if path != "" {
+		log.Printf("📝 Conversation file for guild %s: %s", guildID, path)
+	}
+	return manager
+}
+
+// conversationGuild returns the guild a conversation belongs to, or for the shared conversation
+// the guild of the only active voice session ("" if there isn't exactly one)
+func (b *Bot) conversationGuild(conversation *claude.ConversationManager) string {
+	b.conversationsMutex.Lock()
+	defer b.conversationsMutex.Unlock()
+
+	for guildID, manager := range b.conversations {
+		if manager == conversation {
+			return guildID
+		}
+	}
+	return b.sessionGuild("")
+}
+
+// allConversations returns the shared conversation followed by each guild's, in guild ID order
+func (b *Bot) allConversations() []*claude.ConversationManager {
+	if b.conversationManager == nil {
+		return nil
+	}
+
+	b.conversationsMutex.Lock()
+	defer b.conversationsMutex.Unlock()
+
+	guildIDs := make([]string, 0, len(b.conversations))
+	for guildID := range b.conversations {
+		guildIDs = append(guildIDs, guildID)
+	}
+	slices.Sort(guildIDs)
+
+	managers := []*claude.ConversationManager{b.conversationManager}
+	for _, guildID := range guildIDs {
+		managers = append(managers, b.conversations[guildID])
+	}
+	return managers
+}
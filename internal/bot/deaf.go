@@ -0,0 +1,55 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// handleDeafCommand stops or resumes listening without leaving the voice channel
+func (b *Bot) handleDeafCommand(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+	if !b.requireDM(s, m) {
+		return
+	}
+
+	if len(args) == 0 {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("🙉 Deaf mode is %s. Usage: `%s %s on|off`",
+			onOff(b.audioManager.IsDeafened()), b.config.CommandPrefix, commandDeaf))
+		return
+	}
+
+	var deaf bool
+	switch strings.ToLower(args[0]) {
+	case "on", "true", "1":
+		deaf = true
+	case "off", "false", "0":
+		deaf = false
+	default:
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Usage: `%s %s on|off`", b.config.CommandPrefix, commandDeaf))
+		return
+	}
+
+	b.audioManager.SetDeafened(deaf)
+
+	// Also deafen on Discord so everyone can see the bot isn't listening
+	for _, guildID := range b.audioManager.ActiveGuilds() {
+		b.session.RLock()
+		vc, ok := b.session.VoiceConnections[guildID]
+		b.session.RUnlock()
+
+		if ok {
+			if err := b.session.ChannelVoiceJoinManual(guildID, vc.ChannelID, false, deaf); err != nil {
+				log.Printf("[BOT] ⚠️ Failed to update voice deafen state: %v", err)
+			}
+		}
+	}
+
+	log.Printf("Deaf mode turned %s by %s", onOff(deaf), m.Author.Username)
+	if deaf {
+		s.ChannelMessageSend(m.ChannelID, "🙉 Deaf mode on: the bot stays in the channel but isn't listening.")
+	} else {
+		s.ChannelMessageSend(m.ChannelID, "👂 Deaf mode off: listening again.")
+	}
+}
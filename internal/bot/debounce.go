@@ -0,0 +1,143 @@
+package bot
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"dnd_dm_assistant_go/internal/search"
+)
+
+// transcriptionDebouncer merges transcriptions that arrive in quick succession from one speaker,
+// as streaming recognition produces, so a burst reaches the conversation as one line instead
+// of contending for its lock once per result. Nothing is dropped: merged text is joined in
+// arrival order and anything pending is delivered by flush.
+type transcriptionDebouncer struct {
+	window  time.Duration // How long after the first result of a burst to deliver it (0 = no merging)
+	deliver func(guildID string, ssrc uint32, text string, confidence float64, delayed bool)
+
+	pending map[debounceKey]*pendingTranscription
+	mutex   sync.Mutex
+}
+
+// debounceKey separates bursts by speaker. Spooled results are never merged with live ones.
+type debounceKey struct {
+	guildID string
+	ssrc    uint32
+	delayed bool
+}
+
+// pendingTranscription is a burst waiting to be delivered
+type pendingTranscription struct {
+	texts      []string
+	words      int
+	confidence float64 // Sum of each result's confidence weighted by its word count
+	timer      *time.Timer
+}
+
+// newTranscriptionDebouncer creates a debouncer that passes merged transcriptions to deliver
+func newTranscriptionDebouncer(window time.Duration, deliver func(guildID string, ssrc uint32, text string, confidence float64, delayed bool)) *transcriptionDebouncer {
+	return &transcriptionDebouncer{
+		window:  window,
+		deliver: deliver,
+		pending: make(map[debounceKey]*pendingTranscription),
+	}
+}
+
+// add queues a transcription, starting a new burst if the speaker has none pending
+func (d *transcriptionDebouncer) add(guildID string, ssrc uint32, text string, confidence float64, delayed bool) {
+	if d.window <= 0 {
+		d.deliver(guildID, ssrc, text, confidence, delayed)
+		return
+	}
+
+	key := debounceKey{guildID: guildID, ssrc: ssrc, delayed: delayed}
+	words := max(len(strings.Fields(text)), 1)
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	burst, ok := d.pending[key]
+	if !ok {
+		burst = &pendingTranscription{}
+		burst.timer = time.AfterFunc(d.window, func() { d.deliverPending(key) })
+		d.pending[key] = burst
+	}
+	burst.texts = append(burst.texts, text)
+	burst.words += words
+	burst.confidence += confidence * float64(words)
+}
+
+// deliverPending delivers a speaker's burst, if it hasn't been delivered already
+func (d *transcriptionDebouncer) deliverPending(key debounceKey) {
+	d.mutex.Lock()
+	burst, ok := d.pending[key]
+	delete(d.pending, key)
+	d.mutex.Unlock()
+
+	if !ok {
+		return
+	}
+	d.deliver(key.guildID, key.ssrc, strings.Join(burst.texts, " "), burst.confidence/float64(burst.words), key.delayed)
+}
+
+// flush delivers every pending burst now, e.g. before shutting down
+func (d *transcriptionDebouncer) flush() {
+	d.flushMatching(func(debounceKey) bool { return true })
+}
+
+// flushGuild delivers a guild's pending bursts now, so a conversation flush includes them
+func (d *transcriptionDebouncer) flushGuild(guildID string) {
+	d.flushMatching(func(key debounceKey) bool { return key.guildID == guildID })
+}
+
+// flushMatching delivers the pending bursts whose key matches
+func (d *transcriptionDebouncer) flushMatching(match func(debounceKey) bool) {
+	d.mutex.Lock()
+	keys := make([]debounceKey, 0, len(d.pending))
+	for key, burst := range d.pending {
+		if !match(key) {
+			continue
+		}
+		burst.timer.Stop()
+		keys = append(keys, key)
+	}
+	d.mutex.Unlock()
+
+	for _, key := range keys {
+		d.deliverPending(key)
+	}
+}
+
+// flushPendingTranscriptions delivers the transcriptions still being merged for a guild's
+// conversation, so flushing it or asking Claude includes the last thing said
+func (b *Bot) flushPendingTranscriptions(guildID string) {
+	if guildID = b.sessionGuild(guildID); guildID == "" || !b.config.ConversationPerGuild {
+		// Every guild shares the conversation
+		b.transcriptions.flush()
+		return
+	}
+	b.transcriptions.flushGuild(guildID)
+}
+
+// deliverTranscription indexes a (possibly merged) transcription for search and buffers it in
+// the guild's conversation
+func (b *Bot) deliverTranscription(guildID string, ssrc uint32, text string, confidence float64, delayed bool) {
+	speaker := b.speakerLabel(guildID, ssrc)
+	if b.searchIndex != nil {
+		b.searchIndex.Add(search.Entry{
+			Time:     time.Now(),
+			GuildID:  guildID,
+			Campaign: b.campaignName(),
+			Speaker:  speaker,
+			Text:     text,
+		})
+	}
+	if b.conversationManager == nil || !b.claudeEnabledFor(guildID) {
+		return
+	}
+	if delayed {
+		text = delayedTranscriptionMarker + text
+	}
+	b.conversation(guildID).AddTranscription(ssrc, speaker, text, confidence)
+}
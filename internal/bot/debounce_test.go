@@ -0,0 +1,61 @@
+package bot
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"dnd_dm_assistant_go/internal/config"
+)
+
+// deliveries records what a debouncer delivers, keyed by guild
+type deliveries struct {
+	mutex sync.Mutex
+	texts map[string][]string
+}
+
+func (d *deliveries) deliver(guildID string, ssrc uint32, text string, confidence float64, delayed bool) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.texts[guildID] = append(d.texts[guildID], text)
+}
+
+func (d *deliveries) get(guildID string) []string {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return d.texts[guildID]
+}
+
+func TestFlushPendingTranscriptionsDeliversTheGuildsBursts(t *testing.T) {
+	b := newTestBot(&config.Config{ConversationPerGuild: true})
+	delivered := &deliveries{texts: make(map[string][]string)}
+	b.transcriptions = newTranscriptionDebouncer(time.Hour, delivered.deliver)
+	t.Cleanup(b.transcriptions.flush)
+
+	b.transcriptions.add("guildA", 1, "I open", 0.9, false)
+	b.transcriptions.add("guildA", 1, "the door", 0.9, false)
+	b.transcriptions.add("guildB", 1, "I wait", 0.9, false)
+
+	b.flushPendingTranscriptions("guildA")
+	if got := delivered.get("guildA"); len(got) != 1 || got[0] != "I open the door" {
+		t.Errorf("guild A got %q, want the merged burst", got)
+	}
+	if got := delivered.get("guildB"); len(got) != 0 {
+		t.Errorf("guild B got %q before its own flush", got)
+	}
+}
+
+func TestFlushPendingTranscriptionsWithSharedConversation(t *testing.T) {
+	b := newTestBot(&config.Config{})
+	delivered := &deliveries{texts: make(map[string][]string)}
+	b.transcriptions = newTranscriptionDebouncer(time.Hour, delivered.deliver)
+
+	b.transcriptions.add("guildA", 1, "I open the door", 0.9, false)
+	b.transcriptions.add("guildB", 1, "I wait", 0.9, false)
+
+	// Every guild's transcriptions go to the one conversation, so all of them are delivered
+	b.flushPendingTranscriptions("guildA")
+	if len(delivered.get("guildA")) != 1 || len(delivered.get("guildB")) != 1 {
+		t.Errorf("delivered %v, want both guilds' bursts", delivered.texts)
+	}
+}
@@ -0,0 +1,55 @@
+package bot
+
+import (
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// Discord's limits for a single embed
+	maxEmbedTitle       = 256
+	maxEmbedDescription = 4096
+
+	// Side color of Claude's answer embeds
+	claudeEmbedColor = 0xD97757
+)
+
+// claudeEmbeds renders a Claude answer as one embed per description-sized chunk. Each is sent
+// as its own message, which keeps every message under Discord's 6000 character embed total.
+func claudeEmbeds(title, text string) []*discordgo.MessageEmbed {
+	if runes := []rune(title); len(runes) > maxEmbedTitle {
+		title = string(runes[:maxEmbedTitle-1]) + "…"
+	}
+
+	chunks := splitMessage(text, maxEmbedDescription)
+	embeds := make([]*discordgo.MessageEmbed, len(chunks))
+	for i, chunk := range chunks {
+		embeds[i] = &discordgo.MessageEmbed{
+			Description: chunk,
+			Color:       claudeEmbedColor,
+		}
+		if i == 0 {
+			embeds[i].Title = title
+		}
+		if len(chunks) > 1 {
+			embeds[i].Footer = &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Part %d of %d", i+1, len(chunks))}
+		}
+	}
+	return embeds
+}
+
+// sendClaudeAnswer replies to a command with Claude's answer, as embeds when they're enabled
+func (b *Bot) sendClaudeAnswer(s *discordgo.Session, m *discordgo.MessageCreate, title, response string) {
+	if !b.config.UseEmbeds {
+		b.sendLongMessage(s, m, title, fmt.Sprintf("[CLAUDE] %s", response))
+		return
+	}
+
+	embeds := claudeEmbeds(title, response)
+	parts := make([]*discordgo.MessageSend, len(embeds))
+	for i, embed := range embeds {
+		parts[i] = &discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{embed}}
+	}
+	b.sendParts(s, m, title, parts)
+}
@@ -0,0 +1,90 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"dnd_dm_assistant_go/internal/dnd"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Monster counts shown in the per-monster XP breakdown
+var encounterMonsterCounts = []int{1, 2, 4, 6}
+
+// handleEncounterCommand computes an encounter XP budget and, if Claude is available, suggests monsters
+func (b *Bot) handleEncounterCommand(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+	usage := fmt.Sprintf("Usage: `%s %s <partyLevel> <partySize> [easy|medium|hard|deadly] [theme]`",
+		b.config.CommandPrefix, commandEncounter)
+
+	if len(args) < 2 {
+		s.ChannelMessageSend(m.ChannelID, "❌ Please provide the party level and size. "+usage)
+		return
+	}
+
+	level, err := strconv.Atoi(args[0])
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, "❌ Party level must be a number. "+usage)
+		return
+	}
+	size, err := strconv.Atoi(args[1])
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, "❌ Party size must be a number. "+usage)
+		return
+	}
+
+	difficulty := dnd.Medium
+	theme := args[2:]
+	if len(theme) > 0 {
+		if parsed, err := dnd.ParseDifficulty(theme[0]); err == nil {
+			difficulty = parsed
+			theme = theme[1:]
+		}
+	}
+
+	encounter, err := dnd.BuildEncounter(level, size, difficulty)
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ %v. %s", err, usage))
+		return
+	}
+
+	message := formatEncounter(encounter)
+
+	// Monster suggestions are a bonus; the budget above works without Claude
+	if b.conversationManager != nil && b.claudeEnabledFor(m.GuildID) {
+		s.ChannelTyping(m.ChannelID)
+
+		suggestion, err := b.conversation(m.GuildID).SuggestMonsters(encounterPrompt(encounter, strings.Join(theme, " ")))
+		if err != nil {
+			log.Printf("Error getting monster suggestions from Claude: %v", err)
+			b.recordError(componentClaude, err)
+			message += "\n⚠️ Couldn't get monster suggestions from Claude."
+		} else {
+			message += "\n**Suggested monsters:**\n" + suggestion
+		}
+	}
+
+	b.sendLongMessage(s, m, "Encounter", message)
+}
+
+// formatEncounter describes an encounter's XP budget and what it allows per monster
+func formatEncounter(e dnd.Encounter) string {
+	message := fmt.Sprintf("⚔️ **%s encounter** for %d level %d characters\n", e.Difficulty, e.PartySize, e.PartyLevel)
+	message += fmt.Sprintf("XP budget: **%d** (adjusted XP, after the monster-count multiplier)\n", e.XPBudget)
+	for _, count := range encounterMonsterCounts {
+		message += fmt.Sprintf("   • %d monster(s): up to %d XP each (×%g)\n", count, e.MaxMonsterXP(count), e.Multiplier(count))
+	}
+	return message
+}
+
+// encounterPrompt describes an encounter for Claude's monster suggestions
+func encounterPrompt(e dnd.Encounter, theme string) string {
+	prompt := fmt.Sprintf("A %s encounter for %d level %d characters. Adjusted XP budget: %d.",
+		e.Difficulty, e.PartySize, e.PartyLevel, e.XPBudget)
+	if theme != "" {
+		prompt += fmt.Sprintf(" Theme: %s.", theme)
+	}
+	return prompt
+}
@@ -0,0 +1,78 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// Most errors kept for the errors command
+	maxRecentErrors = 50
+
+	// Errors shown when no count is given
+	defaultErrorsShown = 10
+)
+
+// Components recorded by the bot itself; the audio processor reports its own
+const (
+	componentClaude = "claude"
+	componentVoice  = "voice"
+	componentSearch = "search"
+)
+
+// recentError is a failure kept so the DM can see it without server access
+type recentError struct {
+	Time      time.Time
+	Component string
+	Message   string
+}
+
+// recordError adds a failure to the recent errors, dropping the oldest once full
+func (b *Bot) recordError(component string, err error) {
+	b.errorsMutex.Lock()
+	defer b.errorsMutex.Unlock()
+
+	b.recentErrors = append(b.recentErrors, recentError{
+		Time:      b.clock.Now(),
+		Component: component,
+		Message:   err.Error(),
+	})
+	if len(b.recentErrors) > maxRecentErrors {
+		b.recentErrors = b.recentErrors[len(b.recentErrors)-maxRecentErrors:]
+	}
+}
+
+// handleErrorsCommand lists the most recent failures
+func (b *Bot) handleErrorsCommand(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+	if !b.requireDM(s, m) {
+		return
+	}
+
+	count := defaultErrorsShown
+	if len(args) > 0 {
+		parsed, err := strconv.Atoi(args[0])
+		if err != nil || parsed <= 0 {
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Usage: `%s %s [count]`", b.config.CommandPrefix, commandErrors))
+			return
+		}
+		count = min(parsed, maxRecentErrors)
+	}
+
+	b.errorsMutex.Lock()
+	recent := b.recentErrors[max(0, len(b.recentErrors)-count):]
+	list := fmt.Sprintf("**Recent errors** (%d of %d):\n", len(recent), len(b.recentErrors))
+	for _, e := range recent {
+		list += fmt.Sprintf("`%s` **%s**: %s\n", e.Time.Format("2006-01-02 15:04:05"), e.Component, e.Message)
+	}
+	b.errorsMutex.Unlock()
+
+	if len(recent) == 0 {
+		s.ChannelMessageSend(m.ChannelID, "✅ No errors since the bot started.")
+		return
+	}
+
+	b.sendLongMessage(s, m, "Recent errors", list)
+}
@@ -0,0 +1,40 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"dnd_dm_assistant_go/internal/config"
+)
+
+func TestRecordErrorKeepsMostRecent(t *testing.T) {
+	b := newTestBot(&config.Config{})
+	clock := b.clock.(*fixedClock)
+
+	for i := range maxRecentErrors + 5 {
+		clock.now = clock.now.Add(time.Minute)
+		b.recordError(componentClaude, fmt.Errorf("failure %d", i))
+	}
+
+	if len(b.recentErrors) != maxRecentErrors {
+		t.Fatalf("kept %d errors, want %d", len(b.recentErrors), maxRecentErrors)
+	}
+	oldest, newest := b.recentErrors[0], b.recentErrors[len(b.recentErrors)-1]
+	if oldest.Message != "failure 5" || newest.Message != fmt.Sprintf("failure %d", maxRecentErrors+4) {
+		t.Errorf("kept %q to %q, want the newest errors", oldest.Message, newest.Message)
+	}
+	if !newest.Time.Equal(clock.now) {
+		t.Errorf("error recorded at %v, want the clock's time %v", newest.Time, clock.now)
+	}
+}
+
+func TestRecordErrorComponent(t *testing.T) {
+	b := newTestBot(&config.Config{})
+	b.recordError(componentVoice, errors.New("joining channel: timeout"))
+
+	if got := b.recentErrors[0]; got.Component != componentVoice || got.Message != "joining channel: timeout" {
+		t.Errorf("recorded %+v", got)
+	}
+}
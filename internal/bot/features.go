@@ -0,0 +1,55 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// featureStatus records whether a subsystem came up at startup and, if not, why
+type featureStatus struct {
+	Enabled bool
+	Reason  string // Why the subsystem is disabled; empty when enabled
+}
+
+// enabledFeature is the status of a subsystem that started normally
+var enabledFeature = featureStatus{Enabled: true}
+
+// disabledFeature returns the status of a subsystem that didn't start, with the reason
+func disabledFeature(format string, args ...interface{}) featureStatus {
+	return featureStatus{Reason: fmt.Sprintf(format, args...)}
+}
+
+// startupFeatures holds the status of each optional subsystem, captured in New
+type startupFeatures struct {
+	Speech      featureStatus
+	Claude      featureStatus
+	Persistence featureStatus
+	Tables      featureStatus
+	Search      featureStatus
+}
+
+// handleFeaturesCommand reports which subsystems are enabled and why any are disabled
+func (b *Bot) handleFeaturesCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	var reply strings.Builder
+	reply.WriteString("**Features:**\n")
+	for _, feature := range []struct {
+		name   string
+		status featureStatus
+	}{
+		{"Speech-to-text", b.features.Speech},
+		{"Claude assistant", b.features.Claude},
+		{"Saving to disk", b.features.Persistence},
+		{"Random tables", b.features.Tables},
+		{"Transcript search", b.features.Search},
+	} {
+		if feature.status.Enabled {
+			fmt.Fprintf(&reply, "✅ %s: enabled\n", feature.name)
+		} else {
+			fmt.Fprintf(&reply, "❌ %s: disabled - %s\n", feature.name, feature.status.Reason)
+		}
+	}
+
+	s.ChannelMessageSend(m.ChannelID, reply.String())
+}
@@ -0,0 +1,99 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"dnd_dm_assistant_go/internal/config"
+	"dnd_dm_assistant_go/internal/paths"
+	"dnd_dm_assistant_go/internal/search"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// newSearchIndex opens the transcript search index, or returns nil and the reason if
+// embeddings are turned off or the index can't be read
+func newSearchIndex(cfg *config.Config, dirs paths.Dirs) (*search.Index, featureStatus) {
+	if !cfg.EmbeddingsEnabled {
+		return nil, disabledFeature("EMBEDDINGS_ENABLED is false")
+	}
+
+	// Without persistence the index only covers this run
+	file := ""
+	if cfg.Persist {
+		file = dirs.SearchIndex(cfg.EmbeddingsFile)
+	}
+
+	index, err := search.NewIndex(cfg.Debug, search.Options{
+		BaseURL: cfg.EmbeddingsBaseURL,
+		APIKey:  cfg.EmbeddingsAPIKey,
+		Model:   cfg.EmbeddingsModel,
+		File:    file,
+	})
+	if err != nil {
+		log.Printf("⚠️ Failed to open the transcript search index: %v", err)
+		return nil, disabledFeature("failed to load %s: %v", file, err)
+	}
+
+	entries, _ := index.Stats()
+	log.Printf("🔎 Transcript search enabled (model %s, %d transcription(s) indexed)", cfg.EmbeddingsModel, entries)
+	return index, enabledFeature
+}
+
+// handleFindCommand searches past transcriptions of the current campaign by meaning. In a
+// server it searches that server's transcriptions; in a direct message, those of the guilds
+// the author is DM of.
+func (b *Bot) handleFindCommand(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+	if m.GuildID == "" && !b.requireDMOfAnyGuild(s, m) {
+		return
+	}
+	if b.searchIndex == nil {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Transcript search is disabled: %s.", b.features.Search.Reason))
+		return
+	}
+
+	if len(args) == 0 {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Usage: `%s %s <query>`, e.g. `%s %s when did we meet the assassin?`",
+			b.config.CommandPrefix, commandFind, b.config.CommandPrefix, commandFind))
+		return
+	}
+	query := strings.Join(args, " ")
+
+	campaign := b.campaignName()
+	visible := func(guildID string) bool { return b.canSeeGuild(m, guildID) }
+	matches, err := b.searchIndex.Search(query, campaign, visible, b.config.FindResults)
+	if err != nil {
+		log.Printf("Error searching transcripts: %v", err)
+		b.recordError(componentSearch, err)
+		s.ChannelMessageSend(m.ChannelID, "❌ The embeddings service is unavailable right now, so transcripts can't be searched. Try again later.")
+		return
+	}
+
+	entries, pending := b.searchIndex.Stats()
+	if len(matches) == 0 {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("🔎 Nothing found for \"%s\" (%d transcription(s) indexed, %d waiting).", query, entries, pending))
+		return
+	}
+
+	var reply strings.Builder
+	fmt.Fprintf(&reply, "🔎 **Closest matches for \"%s\"**", query)
+	if campaign != "" {
+		fmt.Fprintf(&reply, " in %s", campaign)
+	}
+	reply.WriteString(":\n")
+	for i, match := range matches {
+		speaker := match.Speaker
+		if speaker == "" {
+			speaker = "Unknown"
+		}
+		fmt.Fprintf(&reply, "%d. `%s` **%s**: %s _(%.2f)_\n",
+			i+1, match.Time.Local().Format(time.DateTime), speaker, match.Text, match.Score)
+	}
+	if pending > 0 {
+		fmt.Fprintf(&reply, "\n_%d recent transcription(s) aren't searchable yet._", pending)
+	}
+
+	b.sendLongMessage(s, m, "Find: "+query, reply.String())
+}
@@ -0,0 +1,68 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"dnd_dm_assistant_go/internal/audio"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// How long flushuser waits for the speaker's audio to be transcribed before flushing anyway
+const flushUserTimeout = 30 * time.Second
+
+// handleFlushUserCommand transcribes one speaker's buffered audio right away and flushes the
+// transcriptions to Claude, leaving everyone else's audio buffering
+func (b *Bot) handleFlushUserCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if !b.requireClaude(s, m) {
+		return
+	}
+	// A direct message picks the session for the author, so only that session's DM may use it
+	if m.GuildID == "" && !b.requireDM(s, m) {
+		return
+	}
+
+	if len(m.Mentions) == 0 {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Usage: `%s %s @user`", b.config.CommandPrefix, commandFlushUser))
+		return
+	}
+	user := m.Mentions[0]
+
+	guildID := b.sessionGuild(m.GuildID)
+	if guildID == "" {
+		s.ChannelMessageSend(m.ChannelID, "❌ Use this in the server with the voice session.")
+		return
+	}
+
+	transcribed, err := b.audioManager.FlushUser(guildID, user.ID)
+	if errors.Is(err, audio.ErrNoActiveSSRC) {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ <@%s> has no audio stream in this session. If they've spoken, find their SSRC with `%s %s`.",
+			user.ID, b.config.CommandPrefix, commandUnknown))
+		return
+	}
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, "❌ The bot isn't in a voice session in this server.")
+		return
+	}
+
+	note := ""
+	if transcribed == nil {
+		note = fmt.Sprintf(" (no audio from <@%s> was waiting to be transcribed)", user.ID)
+	} else {
+		select {
+		case <-transcribed:
+		case <-time.After(flushUserTimeout):
+			log.Printf("Transcription for %s took longer than %v, flushing without it", user.Username, flushUserTimeout)
+			note = fmt.Sprintf(" (<@%s>'s audio is still being transcribed and will be sent with the next flush)", user.ID)
+		}
+	}
+
+	b.flushPendingTranscriptions(guildID)
+	conversation := b.conversation(guildID)
+	conversation.FlushTranscriptions()
+	summary := conversation.GetConversationSummary()
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Flushed transcriptions to Claude%s. %s", note, summary))
+}
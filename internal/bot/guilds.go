@@ -0,0 +1,85 @@
+package bot
+
+import (
+	"log"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// expectGuilds records the guilds announced in Ready and, once their data has arrived (or the
+// wait times out), runs the one-off startup checks
+func (b *Bot) expectGuilds(guilds []*discordgo.Guild) {
+	b.pendingGuildsMux.Lock()
+	b.pendingGuilds = make(map[string]bool, len(guilds))
+	for _, guild := range guilds {
+		b.pendingGuilds[guild.ID] = true
+	}
+	loaded := make(chan struct{})
+	b.guildsLoaded = loaded
+	if len(b.pendingGuilds) == 0 {
+		close(loaded)
+	}
+	b.pendingGuildsMux.Unlock()
+
+	go b.afterGuildsLoaded(loaded)
+}
+
+// onGuildCreate checks each guild for the DM as soon as its voice states are available.
+// It also fires when a guild becomes available again after an outage or when the bot joins one.
+func (b *Bot) onGuildCreate(s *discordgo.Session, event *discordgo.GuildCreate) {
+	if event.Unavailable {
+		return
+	}
+
+	b.checkGuildForDM(event.Guild)
+
+	b.pendingGuildsMux.Lock()
+	defer b.pendingGuildsMux.Unlock()
+	if !b.pendingGuilds[event.ID] {
+		return
+	}
+	delete(b.pendingGuilds, event.ID)
+	if len(b.pendingGuilds) == 0 {
+		close(b.guildsLoaded)
+	}
+}
+
+// afterGuildsLoaded waits for every guild's data, up to the configured limit, then verifies the
+// monitored channels, posts the startup greeting and catches any guild that never loaded
+func (b *Bot) afterGuildsLoaded(loaded chan struct{}) {
+	select {
+	case <-loaded:
+		log.Printf("Guild data loaded")
+	case <-time.After(b.config.GuildLoadTimeout):
+		log.Printf("⚠️ Timed out after %v waiting for guild data; checking with what's available", b.config.GuildLoadTimeout)
+	}
+
+	// Surface misconfigured channels instead of silently never joining
+	b.verifyMonitoredChannels()
+
+	b.greetOnce.Do(b.postStartupMessage)
+
+	// GuildCreate normally handles this; it's a fallback for guilds that didn't load in time.
+	// Each guild in the guild config file may have its own session, so check them all.
+	for _, guild := range b.session.State.Guilds {
+		b.checkGuildForDM(guild)
+	}
+
+	if !b.audioManager.IsProcessing() {
+		log.Printf("DM is not currently in the target D&D channel")
+		log.Printf("Bot will monitor for voice state changes and auto-join when DM joins the target channel")
+	}
+}
+
+// sessionGuild returns the guild a command applies to: the guild it was sent in, or for a
+// direct message the guild of the only active voice session ("" if there isn't exactly one)
+func (b *Bot) sessionGuild(guildID string) string {
+	if guildID != "" {
+		return guildID
+	}
+	if guilds := b.audioManager.ActiveGuilds(); len(guilds) == 1 {
+		return guilds[0]
+	}
+	return ""
+}
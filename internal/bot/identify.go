@@ -0,0 +1,74 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// handleIdentifyCommand maps an SSRC to a user by hand when Discord never said who it was
+func (b *Bot) handleIdentifyCommand(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+	if !b.requireDM(s, m) {
+		return
+	}
+
+	usage := fmt.Sprintf("❌ Usage: `%s %s <ssrc> @user` (see `%s %s` for unlabeled SSRCs)",
+		b.config.CommandPrefix, commandIdentify, b.config.CommandPrefix, commandUnknown)
+	if len(args) < 2 || len(m.Mentions) == 0 {
+		s.ChannelMessageSend(m.ChannelID, usage)
+		return
+	}
+	ssrc, err := strconv.ParseUint(args[0], 10, 32)
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, usage)
+		return
+	}
+
+	guildID := b.sessionGuild(m.GuildID)
+	if guildID == "" {
+		s.ChannelMessageSend(m.ChannelID, "❌ Use this in the server with the voice session.")
+		return
+	}
+
+	user := m.Mentions[0]
+	if err := b.audioManager.SetSSRCUser(guildID, uint32(ssrc), user.ID); err != nil {
+		s.ChannelMessageSend(m.ChannelID, "❌ The bot isn't in a voice session in this server.")
+		return
+	}
+
+	log.Printf("SSRC %d identified as %s by %s", ssrc, user.Username, m.Author.Username)
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ SSRC %d is now <@%s> for the rest of this session.", ssrc, user.ID))
+}
+
+// handleUnknownCommand lists the SSRCs heard this session that aren't linked to a user
+func (b *Bot) handleUnknownCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if !b.requireDM(s, m) {
+		return
+	}
+
+	guildID := b.sessionGuild(m.GuildID)
+	processor, ok := b.audioManager.Session(guildID)
+	if guildID == "" || !ok || !processor.IsProcessing() {
+		s.ChannelMessageSend(m.ChannelID, "❌ The bot isn't in a voice session in this server.")
+		return
+	}
+
+	var unknown []string
+	for _, stream := range processor.SSRCActivity() {
+		if stream.UserID == "" {
+			unknown = append(unknown, fmt.Sprintf("   • SSRC %d, last heard %s ago",
+				stream.SSRC, stream.LastPacketAge.Round(time.Second)))
+		}
+	}
+	if len(unknown) == 0 {
+		s.ChannelMessageSend(m.ChannelID, "✅ Every SSRC heard this session is linked to a user.")
+		return
+	}
+
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❓ **Unlabeled SSRCs** (`%s %s <ssrc> @user` to label one):\n%s",
+		b.config.CommandPrefix, commandIdentify, strings.Join(unknown, "\n")))
+}
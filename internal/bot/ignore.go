@@ -0,0 +1,125 @@
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// ignoredUsersFile holds the ignored users list, which overrides IGNORED_USER_IDS once it exists
+const ignoredUsersFile = "ignored_users.json"
+
+// loadIgnoredUsers loads the saved ignored users list, falling back to the configured one
+func (b *Bot) loadIgnoredUsers() {
+	ids := b.config.IgnoredUserIDs
+
+	if b.config.Persist {
+		data, err := os.ReadFile(b.dirs.State(ignoredUsersFile))
+		switch {
+		case err == nil:
+			var saved []string
+			if err := json.Unmarshal(data, &saved); err != nil {
+				log.Printf("[BOT] ⚠️ Failed to parse %s, using IGNORED_USER_IDS: %v", ignoredUsersFile, err)
+			} else {
+				ids = saved
+			}
+		case !errors.Is(err, os.ErrNotExist):
+			log.Printf("[BOT] ⚠️ Failed to read %s, using IGNORED_USER_IDS: %v", ignoredUsersFile, err)
+		}
+	}
+
+	b.ignoredMutex.Lock()
+	b.ignoredUsers = slices.Clone(ids)
+	b.ignoredMutex.Unlock()
+
+	b.audioManager.SetIgnoredUsers(ids)
+	if len(ids) > 0 {
+		log.Printf("🙈 Ignoring speech from %d user(s)", len(ids))
+	}
+}
+
+// setUserIgnored adds or removes a user from the ignored list and saves it.
+// It returns false if the user was already in the requested state.
+func (b *Bot) setUserIgnored(userID string, ignored bool) bool {
+	b.ignoredMutex.Lock()
+	defer b.ignoredMutex.Unlock()
+
+	index := slices.Index(b.ignoredUsers, userID)
+	if (index >= 0) == ignored {
+		return false
+	}
+
+	if ignored {
+		b.ignoredUsers = append(b.ignoredUsers, userID)
+	} else {
+		b.ignoredUsers = slices.Delete(b.ignoredUsers, index, index+1)
+	}
+	b.audioManager.SetIgnoredUsers(b.ignoredUsers)
+
+	if b.config.Persist {
+		data, err := json.MarshalIndent(b.ignoredUsers, "", "  ")
+		if err == nil {
+			err = os.WriteFile(b.dirs.State(ignoredUsersFile), data, 0644)
+		}
+		if err != nil {
+			log.Printf("[BOT] ⚠️ Failed to save ignored users: %v", err)
+		}
+	}
+
+	return true
+}
+
+// isUserIgnored returns true if the user's speech should never be transcribed
+func (b *Bot) isUserIgnored(userID string) bool {
+	b.ignoredMutex.Lock()
+	defer b.ignoredMutex.Unlock()
+	return slices.Contains(b.ignoredUsers, userID)
+}
+
+// handleIgnoreCommand ignores or unignores the mentioned users, or lists the ignored users
+func (b *Bot) handleIgnoreCommand(s *discordgo.Session, m *discordgo.MessageCreate, ignore bool) {
+	if !b.requireDM(s, m) {
+		return
+	}
+
+	if len(m.Mentions) == 0 {
+		b.ignoredMutex.Lock()
+		ids := slices.Clone(b.ignoredUsers)
+		b.ignoredMutex.Unlock()
+
+		usage := fmt.Sprintf("Usage: `%s %s @user` / `%s %s @user`", b.config.CommandPrefix, commandIgnore, b.config.CommandPrefix, commandUnignore)
+		if len(ids) == 0 {
+			s.ChannelMessageSend(m.ChannelID, "ℹ️ No users are ignored. "+usage)
+			return
+		}
+
+		list := "🙈 Ignored users:"
+		for _, id := range ids {
+			list += fmt.Sprintf(" <@%s>", id)
+		}
+		s.ChannelMessageSend(m.ChannelID, list+"\n"+usage)
+		return
+	}
+
+	for _, user := range m.Mentions {
+		changed := b.setUserIgnored(user.ID, ignore)
+		switch {
+		case ignore && changed:
+			log.Printf("Ignoring speech from %s (requested by %s)", user.Username, m.Author.Username)
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("🙈 <@%s>'s speech will no longer be transcribed.", user.ID))
+		case ignore:
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("ℹ️ <@%s> is already ignored.", user.ID))
+		case changed:
+			log.Printf("No longer ignoring speech from %s (requested by %s)", user.Username, m.Author.Username)
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("👂 <@%s>'s speech will be transcribed again.", user.ID))
+		default:
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("ℹ️ <@%s> isn't ignored.", user.ID))
+		}
+	}
+	b.updateAllRosters()
+}
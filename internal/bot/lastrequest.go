@@ -0,0 +1,74 @@
+package bot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Room left in a message for the heading and code fence around a JSON dump
+const exchangeDumpOverhead = 100
+
+// handleLastRequestCommand sends the raw JSON of the most recent API request and its response
+// to the DM in a private message: it includes the whole conversation and system prompt, which
+// players in the channel shouldn't see
+func (b *Bot) handleLastRequestCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if !b.requireDM(s, m) || !b.requireClaude(s, m) {
+		return
+	}
+
+	exchange, ok := b.claudeService.LastExchange()
+	if !ok {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("ℹ️ No request recorded. Requests are only kept while debug mode is on (`%s %s on`).",
+			b.config.CommandPrefix, commandDebug))
+		return
+	}
+
+	dmChannel, err := s.UserChannelCreate(m.Author.ID)
+	if err != nil {
+		log.Printf("[BOT] ⚠️ Failed to create DM channel for the last request: %v", err)
+		s.ChannelMessageSend(m.ChannelID, "❌ Couldn't send you a direct message. Check that your privacy settings allow them.")
+		return
+	}
+	if dmChannel.ID != m.ChannelID {
+		s.ChannelMessageSend(m.ChannelID, "📬 Sent the last request to you in a direct message.")
+	}
+
+	s.ChannelMessageSend(dmChannel.ID, fmt.Sprintf("📤 Request to `%s` <t:%d:R>:\n```json\n%s\n```",
+		exchange.Backend, exchange.Time.Unix(), formatJSONDump(exchange.Request)))
+
+	if exchange.StatusCode == 0 {
+		s.ChannelMessageSend(dmChannel.ID, "📥 No response: the request failed before the server answered.")
+		return
+	}
+	s.ChannelMessageSend(dmChannel.ID, fmt.Sprintf("📥 Response (HTTP %d):\n```json\n%s\n```",
+		exchange.StatusCode, formatJSONDump(exchange.Response)))
+}
+
+// formatJSONDump indents a JSON body and truncates it to fit in one message
+func formatJSONDump(data []byte) string {
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, data, "", "  "); err == nil {
+		data = indented.Bytes()
+	}
+
+	return truncateDump(string(data), discordMessageLimit-exchangeDumpOverhead)
+}
+
+// truncateDump cuts text to at most limit bytes (plus a short note of how much was cut)
+// on a character boundary, so the message stays valid UTF-8
+func truncateDump(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s\n… (%d more bytes)", text[:cut], len(text)-cut)
+}
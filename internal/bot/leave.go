@@ -0,0 +1,78 @@
+package bot
+
+import (
+	"log"
+	"time"
+)
+
+// scheduleLeave leaves the guild's voice channel once the DM has been gone for the grace
+// period, so a brief disconnect doesn't restart audio processing
+func (b *Bot) scheduleLeave(guildID string) {
+	grace := b.config.DMLeaveGrace
+	if grace <= 0 {
+		b.leaveAndClear(guildID)
+		return
+	}
+
+	b.pendingLeavesMux.Lock()
+	defer b.pendingLeavesMux.Unlock()
+
+	if timer, exists := b.pendingLeaves[guildID]; exists {
+		timer.Stop()
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(grace, func() {
+		// Only leave if this timer wasn't cancelled or replaced in the meantime
+		b.pendingLeavesMux.Lock()
+		current := b.pendingLeaves[guildID] == timer
+		if current {
+			delete(b.pendingLeaves, guildID)
+		}
+		b.pendingLeavesMux.Unlock()
+
+		if current {
+			log.Printf("DM didn't return within %v, leaving...", grace)
+			b.leaveAndClear(guildID)
+		}
+	})
+	b.pendingLeaves[guildID] = timer
+
+	log.Printf("Leaving voice channel in guild %s in %v unless the DM returns", guildID, grace)
+}
+
+// leaveAndClear leaves the guild's voice channel and auto-clears the conversation. If the bot
+// stopped recording during the grace period, e.g. someone used leave, that session was
+// already ended and the conversation is left alone.
+func (b *Bot) leaveAndClear(guildID string) {
+	recording := b.audioManager.IsProcessingGuild(guildID)
+	b.leaveVoiceChannel(guildID)
+	if recording {
+		b.autoClearConversation(guildID)
+	}
+}
+
+// cancelLeave cancels a scheduled leave in the guild, returning whether one was pending
+func (b *Bot) cancelLeave(guildID string) bool {
+	b.pendingLeavesMux.Lock()
+	defer b.pendingLeavesMux.Unlock()
+
+	timer, exists := b.pendingLeaves[guildID]
+	if !exists {
+		return false
+	}
+	timer.Stop()
+	delete(b.pendingLeaves, guildID)
+	return true
+}
+
+// cancelAllLeaves cancels every scheduled leave, e.g. on shutdown
+func (b *Bot) cancelAllLeaves() {
+	b.pendingLeavesMux.Lock()
+	defer b.pendingLeavesMux.Unlock()
+
+	for guildID, timer := range b.pendingLeaves {
+		timer.Stop()
+		delete(b.pendingLeaves, guildID)
+	}
+}
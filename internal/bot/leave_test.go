@@ -0,0 +1,41 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"dnd_dm_assistant_go/internal/config"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// dmLeaves reports the DM leaving the guild's D&D channel
+func dmLeaves(b *Bot, guildID string) {
+	b.onVoiceStateUpdate(b.session, &discordgo.VoiceStateUpdate{
+		VoiceState:   &discordgo.VoiceState{GuildID: guildID, UserID: "dm"},
+		BeforeUpdate: &discordgo.VoiceState{GuildID: guildID, UserID: "dm", ChannelID: "table"},
+	})
+}
+
+func leavePending(b *Bot, guildID string) bool {
+	b.pendingLeavesMux.Lock()
+	defer b.pendingLeavesMux.Unlock()
+	_, ok := b.pendingLeaves[guildID]
+	return ok
+}
+
+func TestDMLeavingSchedulesLeaveOnlyWhileRecording(t *testing.T) {
+	b := newTestBot(&config.Config{DMUserID: "dm", DNDVoiceChannelID: "table", DMLeaveGrace: time.Hour})
+	t.Cleanup(b.cancelAllLeaves)
+
+	dmLeaves(b, "guild1")
+	if leavePending(b, "guild1") {
+		t.Error("a leave was scheduled for a guild the bot isn't recording")
+	}
+
+	connectTestVoice(t, b, "guild1", "table")
+	dmLeaves(b, "guild1")
+	if !leavePending(b, "guild1") {
+		t.Error("no leave was scheduled while recording")
+	}
+}
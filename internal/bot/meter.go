@@ -0,0 +1,99 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"dnd_dm_assistant_go/internal/audio"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// How long the meter keeps updating, and how often
+	meterDuration = 20 * time.Second
+	meterInterval = 2 * time.Second
+
+	// Characters in a full packet rate bar
+	meterBarWidth = 10
+)
+
+// handleMeterCommand shows the live packet rate of each speaker, updating the message for a
+// while, for checking whether the bot is actually hearing anyone
+func (b *Bot) handleMeterCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if !b.requireDM(s, m) {
+		return
+	}
+
+	guildID := b.sessionGuild(m.GuildID)
+	processor, ok := b.audioManager.Session(guildID)
+	if guildID == "" || !ok || !processor.IsProcessing() {
+		s.ChannelMessageSend(m.ChannelID, "❌ The bot isn't in a voice session in this server.")
+		return
+	}
+
+	message, err := s.ChannelMessageSend(m.ChannelID, b.formatMeter(processor, meterDuration))
+	if err != nil {
+		log.Printf("Error sending packet meter: %v", err)
+		return
+	}
+
+	// Tail the rate in the background so other commands aren't held up
+	go func() {
+		ticker := time.NewTicker(meterInterval)
+		defer ticker.Stop()
+
+		for remaining := meterDuration - meterInterval; remaining >= 0; remaining -= meterInterval {
+			<-ticker.C
+			if !processor.IsProcessing() {
+				s.ChannelMessageEdit(m.ChannelID, message.ID, "📶 **Packet meter:** audio processing stopped.")
+				return
+			}
+			if _, err := s.ChannelMessageEdit(m.ChannelID, message.ID, b.formatMeter(processor, remaining)); err != nil {
+				log.Printf("Error updating packet meter: %v", err)
+				return
+			}
+		}
+	}()
+}
+
+// formatMeter renders the current packet rate of the session and of each stream in it
+func (b *Bot) formatMeter(processor *audio.Processor, remaining time.Duration) string {
+	activity := processor.SSRCActivity()
+
+	var total float64
+	for _, stream := range activity {
+		total += stream.PacketRate
+	}
+
+	var meter strings.Builder
+	fmt.Fprintf(&meter, "📶 **Packet meter:** %.1f packets/s across %d stream(s)", total, len(activity))
+	if remaining > 0 {
+		fmt.Fprintf(&meter, " (updating for %v)", remaining)
+	}
+	meter.WriteString("\n")
+
+	if len(activity) == 0 {
+		meter.WriteString("No audio heard yet this session.")
+		return meter.String()
+	}
+
+	for _, stream := range activity {
+		speaker := fmt.Sprintf("SSRC %d", stream.SSRC)
+		if stream.UserID != "" {
+			speaker = b.displayName(processor.GuildID(), stream.UserID)
+		}
+		fmt.Fprintf(&meter, "`%s` %5.1f/s %s\n", meterBar(stream.PacketRate), stream.PacketRate, speaker)
+	}
+	return meter.String()
+}
+
+// meterBar draws a packet rate as a bar, full at the rate of continuous speech
+func meterBar(rate float64) string {
+	filled := int(math.Round(rate / audio.MaxPacketRate * meterBarWidth))
+	filled = max(0, min(filled, meterBarWidth))
+	return strings.Repeat("█", filled) + strings.Repeat("░", meterBarWidth-filled)
+}
@@ -0,0 +1,75 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"dnd_dm_assistant_go/internal/audio"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// How long the microphone test records
+const micTestDuration = 5 * time.Second
+
+// handleMicTestCommand records a few seconds of the caller's voice and transcribes it, so a new
+// player can check the whole capture and transcription path in one step
+func (b *Bot) handleMicTestCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if !b.requireGuild(s, m) {
+		return
+	}
+
+	if b.speechService == nil {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Speech-to-text is disabled: %s. See `%s %s`.",
+			b.features.Speech.Reason, b.config.CommandPrefix, commandFeatures))
+		return
+	}
+	if !b.speechEnabledFor(m.GuildID) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Speech-to-text is turned off for this server (`GUILD_FEATURES`).")
+		return
+	}
+
+	botChannel := b.connectedChannel(m.GuildID)
+	if botChannel == "" || !b.audioManager.IsProcessingGuild(m.GuildID) {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ The bot isn't in a voice channel here. Use `%s %s` first.",
+			b.config.CommandPrefix, commandJoin))
+		return
+	}
+	if state, err := s.State.VoiceState(m.GuildID, m.Author.ID); err != nil || state.ChannelID != botChannel {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Join <#%s> with the bot, then run the test again.", botChannel))
+		return
+	}
+	if b.isUserIgnored(m.Author.ID) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Your speech is ignored, so it can't be tested. Ask the DM to unignore you.")
+		return
+	}
+	if b.audioManager.IsDeafened() {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ The bot is deafened. The DM can turn that off with `%s %s off`.",
+			b.config.CommandPrefix, commandDeaf))
+		return
+	}
+
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("🎙️ <@%s>, say something now: recording for %v...", m.Author.ID, micTestDuration))
+
+	result, packets, err := b.audioManager.TestTranscription(m.GuildID, m.Author.ID, micTestDuration)
+	if errors.Is(err, audio.ErrNothingCaptured) {
+		s.ChannelMessageSend(m.ChannelID, "❌ No audio reached the bot from you. Check you aren't muted, that Discord is using the right microphone and that your input sensitivity isn't too high.")
+		return
+	}
+	if err != nil {
+		log.Printf("Microphone test for %s failed: %v", m.Author.Username, err)
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("⚠️ Heard %d packets (%.1fs) from you, but transcription failed: %v",
+			packets, packetSeconds(packets), err))
+		return
+	}
+
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Heard %.1fs of audio from you and transcribed it as:\n> %s\nConfidence: %.2f",
+		packetSeconds(packets), result.Transcript, result.Confidence))
+}
+
+// packetSeconds converts a count of 20ms Opus packets to seconds of audio
+func packetSeconds(packets int) float64 {
+	return float64(packets) / audio.MaxPacketRate
+}
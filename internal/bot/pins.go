@@ -0,0 +1,78 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// handlePinCommand pins the replied-to message, or the nth most recent Claude response
+func (b *Bot) handlePinCommand(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+	if !b.requireClaude(s, m) {
+		return
+	}
+
+	var text string
+	if m.MessageReference != nil {
+		referenced := m.ReferencedMessage
+		if referenced == nil {
+			var err error
+			referenced, err = s.ChannelMessage(m.MessageReference.ChannelID, m.MessageReference.MessageID)
+			if err != nil {
+				log.Printf("Error fetching message to pin: %v", err)
+				s.ChannelMessageSend(m.ChannelID, "❌ Couldn't find the message you replied to.")
+				return
+			}
+		}
+		text = referenced.Content
+	} else {
+		index := 1
+		if len(args) > 0 {
+			parsed, err := strconv.Atoi(args[0])
+			if err != nil || parsed <= 0 {
+				s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Usage: reply to a message with `%s %s`, or `%s %s [n]` to pin the nth most recent response",
+					b.config.CommandPrefix, commandPin, b.config.CommandPrefix, commandPin))
+				return
+			}
+			index = parsed
+		}
+
+		var ok bool
+		text, ok = b.conversation(m.GuildID).RecentResponse(index)
+		if !ok {
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ There is no response #%d in the conversation history.", index))
+			return
+		}
+	}
+
+	count, err := b.conversation(m.GuildID).AddPin(text, m.Author.ID)
+	if err != nil {
+		log.Printf("Error pinning message: %v", err)
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Failed to pin: %v", err))
+		return
+	}
+
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("📌 Pinned as #%d. Use `%s %s` to list pins.", count, b.config.CommandPrefix, commandPins))
+}
+
+// handlePinsCommand lists the pinned messages
+func (b *Bot) handlePinsCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if !b.requireClaude(s, m) {
+		return
+	}
+
+	pins := b.conversation(m.GuildID).Pins()
+	if len(pins) == 0 {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("ℹ️ Nothing pinned yet. Reply to a message with `%s %s` to pin it.", b.config.CommandPrefix, commandPin))
+		return
+	}
+
+	list := fmt.Sprintf("**📌 Pins (%d):**\n", len(pins))
+	for i, pin := range pins {
+		list += fmt.Sprintf("**#%d** `%s`\n%s\n\n", i+1, pin.Timestamp.Format("2006-01-02 15:04"), pin.Text)
+	}
+
+	b.sendLongMessage(s, m, "Pins", list)
+}
@@ -0,0 +1,52 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	"dnd_dm_assistant_go/internal/claude"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// handlePreviewCommand shows the system prompt and the first and last messages the next
+// question would send, to check how custom prompts and instructions compose
+func (b *Bot) handlePreviewCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if !b.requireDM(s, m) || !b.requireClaude(s, m) {
+		return
+	}
+
+	preview := b.conversation(m.GuildID).PromptPreview()
+
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("🧾 **System prompt** (~%d tokens):\n```\n%s\n```",
+		claude.EstimateTokens(preview.SystemPrompt), previewBlock(preview.SystemPrompt)))
+
+	if preview.Total == 0 {
+		s.ChannelMessageSend(m.ChannelID, "ℹ️ The conversation is empty; the next question would be the first message.")
+		return
+	}
+
+	for i, msg := range preview.First {
+		b.sendPreviewMessage(s, m.ChannelID, i+1, preview.Total, msg)
+	}
+	if preview.Omitted > 0 {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("… %d messages omitted …", preview.Omitted))
+	}
+	for i, msg := range preview.Last {
+		b.sendPreviewMessage(s, m.ChannelID, preview.Total-len(preview.Last)+i+1, preview.Total, msg)
+	}
+}
+
+// sendPreviewMessage posts one message of a prompt preview as a code block
+func (b *Bot) sendPreviewMessage(s *discordgo.Session, channelID string, n, total int, msg claude.Message) {
+	text := msg.Text()
+	s.ChannelMessageSend(channelID, fmt.Sprintf("💬 **%d/%d %s** (~%d tokens):\n```\n%s\n```",
+		n, total, msg.Role, claude.EstimateTokens(text), previewBlock(text)))
+}
+
+// previewBlock prepares prompt text for a code block: fences inside it are broken up so they
+// don't end the block early, and it's truncated to fit in one message
+func previewBlock(text string) string {
+	text = strings.ReplaceAll(text, "```", "`\u200b``")
+	return truncateDump(text, discordMessageLimit-exchangeDumpOverhead)
+}
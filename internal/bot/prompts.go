@@ -0,0 +1,130 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// Saved prompt names, e.g. "combat" or "horror-tone"
+	promptNamePattern = `^[a-z0-9_-]{1,32}$`
+
+	// Name that restores the built-in system prompt; it can't be saved over
+	defaultPromptName = "default"
+)
+
+var promptNameRegex = regexp.MustCompile(promptNamePattern)
+
+// promptPath returns the file a named prompt is saved in
+func (b *Bot) promptPath(name string) string {
+	return filepath.Join(b.dirs.Prompts(), name+".txt")
+}
+
+// handlePromptsCommand lists the saved system prompts
+func (b *Bot) handlePromptsCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if !b.requireDM(s, m) {
+		return
+	}
+
+	files, _ := filepath.Glob(filepath.Join(b.dirs.Prompts(), "*.txt"))
+
+	list := "**Saved system prompts:**\n"
+	list += fmt.Sprintf("   • `%s` (built in)\n", defaultPromptName)
+	for _, file := range files {
+		list += fmt.Sprintf("   • `%s`\n", strings.TrimSuffix(filepath.Base(file), ".txt"))
+	}
+	list += fmt.Sprintf("\nUse one with `%s %s use <name>`, or save the current prompt with `%s %s save <name>`.",
+		b.config.CommandPrefix, commandPrompt, b.config.CommandPrefix, commandPrompt)
+
+	b.sendLongMessage(s, m, "System prompts", list)
+}
+
+// handlePromptCommand switches to a saved system prompt, or saves the current one under a name
+func (b *Bot) handlePromptCommand(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+	if !b.requireDM(s, m) || !b.requireClaude(s, m) {
+		return
+	}
+
+	usage := fmt.Sprintf("❌ Usage: `%s %s use|save <name>`", b.config.CommandPrefix, commandPrompt)
+	if len(args) != 2 {
+		s.ChannelMessageSend(m.ChannelID, usage)
+		return
+	}
+
+	name := strings.ToLower(args[1])
+	if !promptNameRegex.MatchString(name) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Prompt names may only use letters, numbers, `-` and `_` (up to 32 characters).")
+		return
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "use":
+		b.usePrompt(s, m, name)
+	case "save":
+		b.savePrompt(s, m, name)
+	default:
+		s.ChannelMessageSend(m.ChannelID, usage)
+	}
+}
+
+// usePrompt makes a saved prompt the conversation's system prompt
+func (b *Bot) usePrompt(s *discordgo.Session, m *discordgo.MessageCreate, name string) {
+	var prompt string
+	if name != defaultPromptName {
+		data, err := os.ReadFile(b.promptPath(name))
+		if errors.Is(err, os.ErrNotExist) {
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ There's no saved prompt called `%s`. See `%s %s`.",
+				name, b.config.CommandPrefix, commandPrompts))
+			return
+		}
+		if err != nil {
+			log.Printf("Error reading prompt %s: %v", name, err)
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Couldn't read the `%s` prompt.", name))
+			return
+		}
+
+		prompt = strings.TrimSpace(string(data))
+		if prompt == "" {
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ The `%s` prompt is empty.", name))
+			return
+		}
+	}
+
+	// An empty prompt restores the default
+	if err := b.conversation(m.GuildID).SetSystemPrompt(prompt); err != nil {
+		log.Printf("Error setting system prompt: %v", err)
+		s.ChannelMessageSend(m.ChannelID, "❌ Failed to save the new system prompt.")
+		return
+	}
+
+	log.Printf("System prompt switched to %s by %s", name, m.Author.Username)
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Now using the `%s` system prompt.", name))
+}
+
+// savePrompt saves the current system prompt under a name
+func (b *Bot) savePrompt(s *discordgo.Session, m *discordgo.MessageCreate, name string) {
+	if !b.config.Persist {
+		s.ChannelMessageSend(m.ChannelID, "❌ Prompts can't be saved in ephemeral mode.")
+		return
+	}
+	if name == defaultPromptName {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ `%s` is the built-in prompt; pick another name.", defaultPromptName))
+		return
+	}
+
+	if err := os.WriteFile(b.promptPath(name), []byte(b.conversation(m.GuildID).SystemPrompt()+"\n"), 0644); err != nil {
+		log.Printf("Error saving prompt %s: %v", name, err)
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Failed to save the `%s` prompt.", name))
+		return
+	}
+
+	log.Printf("System prompt saved as %s by %s", name, m.Author.Username)
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("💾 Saved the current system prompt as `%s`.", name))
+}
@@ -0,0 +1,263 @@
+package bot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"dnd_dm_assistant_go/internal/audio"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// handleGetAudioCommand uploads a speaker's recordings from the current or last session
+func (b *Bot) handleGetAudioCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if !b.config.Persist {
+		s.ChannelMessageSend(m.ChannelID, "❌ Recordings aren't kept in ephemeral mode.")
+		return
+	}
+
+	target := m.Author
+	if len(m.Mentions) > 0 {
+		target = m.Mentions[0]
+	}
+
+	// Players may only fetch their own audio; the DM may fetch anyone's
+	if target.ID != m.Author.ID && !b.isDMUser(m.GuildID, m.Author.ID) {
+		s.ChannelMessageSend(m.ChannelID, "❌ You can only download your own recording.")
+		return
+	}
+
+	recordings := b.audioManager.RecordingsForUser(target.ID)
+	if len(recordings) == 0 {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("ℹ️ No recordings found for <@%s> in the current or last session.", target.ID))
+		return
+	}
+
+	for _, recording := range recordings {
+		b.uploadRecording(s, m.ChannelID, recording.Path, recording.InProgress)
+	}
+}
+
+// uploadRecording uploads one recording to a channel, if it fits within Discord's upload limit
+func (b *Bot) uploadRecording(s *discordgo.Session, channelID, path string, inProgress bool) {
+	name := filepath.Base(path)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		log.Printf("Error reading recording %s: %v", path, err)
+		s.ChannelMessageSend(channelID, fmt.Sprintf("❌ Recording `%s` is no longer available.", name))
+		return
+	}
+
+	if info.Size() > discordUploadLimit {
+		s.ChannelMessageSend(channelID, fmt.Sprintf("❌ Recording `%s` is %.1f MB, too large to upload (limit %d MB). Ask the bot host for it.",
+			name, float64(info.Size())/(1<<20), discordUploadLimit>>20))
+		return
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		log.Printf("Error opening recording %s: %v", path, err)
+		s.ChannelMessageSend(channelID, fmt.Sprintf("❌ Failed to open recording `%s`.", name))
+		return
+	}
+	defer file.Close()
+
+	content := fmt.Sprintf("🎧 `%s`", name)
+	if inProgress {
+		content += " (session still in progress — this is a partial recording)"
+	}
+
+	_, err = s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Content: content,
+		Files: []*discordgo.File{{
+			Name:        name,
+			ContentType: "audio/ogg",
+			Reader:      file,
+		}},
+	})
+	if err != nil {
+		log.Printf("Error uploading recording %s: %v", path, err)
+		s.ChannelMessageSend(channelID, fmt.Sprintf("❌ Failed to upload recording `%s`.", name))
+	}
+}
+
+// handleLastFailCommand shows the most recent failed transcription and uploads its audio
+func (b *Bot) handleLastFailCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if !b.requireDM(s, m) {
+		return
+	}
+
+	failure, ok := b.audioManager.LastFailure()
+	if !ok {
+		s.ChannelMessageSend(m.ChannelID, "✅ No transcription failures since the bot started.")
+		return
+	}
+
+	speaker := fmt.Sprintf("SSRC %d", failure.SSRC)
+	if failure.UserID != "" {
+		speaker = fmt.Sprintf("<@%s> (SSRC %d)", failure.UserID, failure.SSRC)
+	}
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("⚠️ Last transcription failure <t:%d:R> from %s:\n```\n%s\n```",
+		failure.Time.Unix(), speaker, failure.Err))
+
+	if failure.Path == "" {
+		s.ChannelMessageSend(m.ChannelID, "ℹ️ The audio wasn't saved (ephemeral mode or the debug file couldn't be written).")
+		return
+	}
+	b.uploadRecording(s, m.ChannelID, failure.Path, false)
+}
+
+// handleSubtitlesCommand uploads a subtitle file per speaker for the current or last session
+func (b *Bot) handleSubtitlesCommand(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+	if !b.requireDM(s, m) {
+		return
+	}
+
+	format := audio.SubtitleFormatVTT
+	if len(args) > 0 {
+		format = strings.ToLower(args[0])
+	}
+
+	files, err := b.audioManager.ExportSubtitles(format)
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ %v. Usage: `%s %s [vtt|srt]`", err, b.config.CommandPrefix, commandSubtitles))
+		return
+	}
+	if len(files) == 0 {
+		s.ChannelMessageSend(m.ChannelID, "ℹ️ No transcriptions recorded in the current or last session.")
+		return
+	}
+
+	discordFiles := make([]*discordgo.File, 0, len(files))
+	for _, file := range files {
+		discordFiles = append(discordFiles, &discordgo.File{
+			Name:        file.Name,
+			ContentType: "text/plain",
+			Reader:      bytes.NewReader(file.Data),
+		})
+	}
+
+	// Discord allows up to 10 attachments per message
+	for start := 0; start < len(discordFiles); start += 10 {
+		end := min(start+10, len(discordFiles))
+		_, err := s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
+			Content: fmt.Sprintf("📝 Subtitles (%s), timed from the start of the session", format),
+			Files:   discordFiles[start:end],
+		})
+		if err != nil {
+			log.Printf("Error uploading subtitles: %v", err)
+			s.ChannelMessageSend(m.ChannelID, "❌ Failed to upload subtitles.")
+			return
+		}
+	}
+}
+
+// handleTurnsCommand uploads each session's utterances in speaking order as JSON. In a server
+// it covers that server's session; in a direct message, those of the guilds the author is DM of.
+func (b *Bot) handleTurnsCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if !b.requireDMOfAnyGuild(s, m) {
+		return
+	}
+
+	transcripts := b.visibleTurns(m)
+	if len(transcripts) == 0 {
+		s.ChannelMessageSend(m.ChannelID, "ℹ️ No transcriptions recorded in the current or last session.")
+		return
+	}
+
+	discordFiles := make([]*discordgo.File, 0, len(transcripts))
+	for _, transcript := range transcripts {
+		data, err := json.MarshalIndent(transcript, "", "  ")
+		if err != nil {
+			log.Printf("Error encoding transcript for guild %s: %v", transcript.GuildID, err)
+			s.ChannelMessageSend(m.ChannelID, "❌ Failed to export the transcript.")
+			return
+		}
+
+		discordFiles = append(discordFiles, &discordgo.File{
+			Name:        transcript.FileName(),
+			ContentType: "application/json",
+			Reader:      bytes.NewReader(data),
+		})
+	}
+
+	// Discord allows up to 10 attachments per message
+	for start := 0; start < len(discordFiles); start += 10 {
+		end := min(start+10, len(discordFiles))
+		_, err := s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
+			Content: "📝 Speaking turns for the current or last session",
+			Files:   discordFiles[start:end],
+		})
+		if err != nil {
+			log.Printf("Error uploading transcript: %v", err)
+			s.ChannelMessageSend(m.ChannelID, "❌ Failed to upload the transcript.")
+			return
+		}
+	}
+}
+
+// handleRetranscribeCommand re-runs a failed transcription saved as a debug file, or lists them
+func (b *Bot) handleRetranscribeCommand(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+	if !b.requireDM(s, m) {
+		return
+	}
+
+	if !b.config.Persist {
+		s.ChannelMessageSend(m.ChannelID, "❌ Failed transcriptions aren't saved in ephemeral mode.")
+		return
+	}
+	if b.speechService == nil {
+		s.ChannelMessageSend(m.ChannelID, "❌ Speech-to-text is not available.")
+		return
+	}
+
+	if len(args) == 0 {
+		// Failed batches are saved as WAV when transcription audio is resampled
+		files, _ := filepath.Glob(filepath.Join(b.dirs.Recordings(), "debug_audio_*.ogg"))
+		wavFiles, _ := filepath.Glob(filepath.Join(b.dirs.Recordings(), "debug_audio_*.wav"))
+		files = append(files, wavFiles...)
+		if len(files) == 0 {
+			s.ChannelMessageSend(m.ChannelID, "ℹ️ There are no failed transcriptions to retry.")
+			return
+		}
+
+		list := fmt.Sprintf("**Failed transcriptions** (`%s %s <file>` to retry):\n", b.config.CommandPrefix, commandRetranscribe)
+		for _, file := range files {
+			list += fmt.Sprintf("   • `%s`\n", filepath.Base(file))
+		}
+		b.sendLongMessage(s, m, "Failed transcriptions", list)
+		return
+	}
+
+	// Only files inside the recordings directory may be read
+	name := args[0]
+	if ext := filepath.Ext(name); !filepath.IsLocal(name) || (ext != ".ogg" && ext != ".wav") {
+		s.ChannelMessageSend(m.ChannelID, "❌ Give the name of an `.ogg` or `.wav` file in the recordings directory.")
+		return
+	}
+
+	data, err := os.ReadFile(filepath.Join(b.dirs.Recordings(), name))
+	if err != nil {
+		log.Printf("Error reading %s for retranscription: %v", name, err)
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Couldn't read `%s`.", name))
+		return
+	}
+
+	s.ChannelTyping(m.ChannelID)
+
+	result, err := b.speechService.RecognizeAudio(data)
+	if err != nil {
+		log.Printf("Error retranscribing %s: %v", name, err)
+		b.recordError(audio.ComponentSpeech, fmt.Errorf("retranscribing %s: %w", name, err))
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Transcription failed again: %v", err))
+		return
+	}
+
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("📝 `%s` (confidence %.2f): %s", name, result.Confidence, result.Transcript))
+}
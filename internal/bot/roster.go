@@ -0,0 +1,76 @@
+package bot
+
+import "slices"
+
+// updateRoster tells a guild's conversation who is in its voice session, labeled as in
+// transcriptions, so Claude addresses players by name rather than by SSRC. Guilds sharing a
+// conversation share its roster, so it's built from every active session that uses it.
+func (b *Bot) updateRoster(guildID string) {
+	if !b.config.RosterInPrompt || !b.claudeEnabledFor(guildID) {
+		return
+	}
+	conversation := b.conversation(guildID)
+
+	var roster []string
+	for _, activeGuildID := range b.audioManager.ActiveGuilds() {
+		if !b.claudeEnabledFor(activeGuildID) || b.conversation(activeGuildID) != conversation {
+			continue
+		}
+		for _, label := range b.sessionRoster(activeGuildID) {
+			if !slices.Contains(roster, label) {
+				roster = append(roster, label)
+			}
+		}
+	}
+	conversation.SetRoster(roster)
+}
+
+// sessionRoster returns the labels of the speakers heard in a guild's voice session who are
+// still in its voice channel
+func (b *Bot) sessionRoster(guildID string) []string {
+	processor, ok := b.audioManager.Session(guildID)
+	if !ok {
+		return nil
+	}
+	present := b.voiceChannelMembers(guildID, b.connectedChannel(guildID))
+
+	var roster []string
+	for _, userID := range processor.Speakers() {
+		if !present[userID] || b.isUserIgnored(userID) {
+			continue
+		}
+		label := "PLAYER " + b.displayName(guildID, userID)
+		if b.isDMUser(guildID, userID) {
+			label = "DM"
+		}
+		if !slices.Contains(roster, label) {
+			roster = append(roster, label)
+		}
+	}
+	return roster
+}
+
+// voiceChannelMembers returns the users in a guild's voice channel, as cached in state
+func (b *Bot) voiceChannelMembers(guildID, channelID string) map[string]bool {
+	members := make(map[string]bool)
+	guild, err := b.session.State.Guild(guildID)
+	if err != nil || channelID == "" {
+		return members
+	}
+
+	b.session.State.RLock()
+	defer b.session.State.RUnlock()
+	for _, vs := range guild.VoiceStates {
+		if vs.ChannelID == channelID {
+			members[vs.UserID] = true
+		}
+	}
+	return members
+}
+
+// updateAllRosters rebuilds the roster of every active voice session, e.g. after a rename
+func (b *Bot) updateAllRosters() {
+	for _, guildID := range b.audioManager.ActiveGuilds() {
+		b.updateRoster(guildID)
+	}
+}
@@ -0,0 +1,108 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+
+	"dnd_dm_assistant_go/internal/claude"
+	"dnd_dm_assistant_go/internal/config"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// newRosterBot returns a bot with a Claude conversation and the roster note enabled
+func newRosterBot(perGuild bool) *Bot {
+	b := newTestBot(&config.Config{RosterInPrompt: true, ConversationPerGuild: perGuild})
+	b.conversationManager = claude.NewConversationManager(nil, "", 50, false)
+	b.conversations = make(map[string]*claude.ConversationManager)
+	return b
+}
+
+// seatPlayer puts a user in a guild's voice channel, heard on an SSRC
+func seatPlayer(t *testing.T, b *Bot, guildID, channelID, userID, name string, ssrc uint32) {
+	t.Helper()
+	guild, err := b.session.State.Guild(guildID)
+	if err != nil {
+		guild = &discordgo.Guild{ID: guildID}
+		if err := b.session.State.GuildAdd(guild); err != nil {
+			t.Fatal(err)
+		}
+		guild, _ = b.session.State.Guild(guildID)
+	}
+	guild.VoiceStates = append(guild.VoiceStates, &discordgo.VoiceState{GuildID: guildID, ChannelID: channelID, UserID: userID})
+	if err := b.session.State.MemberAdd(&discordgo.Member{GuildID: guildID, User: &discordgo.User{ID: userID, Username: name}}); err != nil {
+		t.Fatal(err)
+	}
+
+	processor, ok := b.audioManager.Session(guildID)
+	if !ok {
+		t.Fatalf("no session in guild %s", guildID)
+	}
+	processor.SetSSRCUser(ssrc, userID)
+}
+
+// leaveVoice takes a user out of a guild's voice channel as Discord would report it
+func leaveVoice(b *Bot, guildID, channelID, userID string) {
+	guild, _ := b.session.State.Guild(guildID)
+	for i, vs := range guild.VoiceStates {
+		if vs.UserID == userID {
+			guild.VoiceStates = append(guild.VoiceStates[:i], guild.VoiceStates[i+1:]...)
+			break
+		}
+	}
+	b.onVoiceStateUpdate(b.session, &discordgo.VoiceStateUpdate{
+		VoiceState:   &discordgo.VoiceState{GuildID: guildID, UserID: userID},
+		BeforeUpdate: &discordgo.VoiceState{GuildID: guildID, UserID: userID, ChannelID: channelID},
+	})
+}
+
+// rosterNote returns the roster line of a conversation's system prompt, or "" if there is none
+func rosterNote(cm *claude.ConversationManager) string {
+	prompt := cm.PromptPreview().SystemPrompt
+	if i := strings.Index(prompt, "At the table: "); i >= 0 {
+		return strings.SplitN(prompt[i:], ".", 2)[0]
+	}
+	return ""
+}
+
+func TestSharedConversationRosterCoversEveryGuild(t *testing.T) {
+	b := newRosterBot(false)
+	connectTestVoice(t, b, "guild1", "table1")
+	connectTestVoice(t, b, "guild2", "table2")
+	seatPlayer(t, b, "guild1", "table1", "alice", "Alice", 1)
+	seatPlayer(t, b, "guild2", "table2", "bob", "Bob", 2)
+
+	b.updateRoster("guild1")
+	b.updateRoster("guild2")
+	if got, want := rosterNote(b.conversationManager), "At the table: PLAYER Alice, PLAYER Bob"; got != want {
+		t.Errorf("roster = %q, want %q", got, want)
+	}
+
+	leaveVoice(b, "guild1", "table1", "alice")
+	if got, want := rosterNote(b.conversationManager), "At the table: PLAYER Bob"; got != want {
+		t.Errorf("roster after Alice left = %q, want %q", got, want)
+	}
+
+	// Without a voice connection to disconnect, leaving only stops the session
+	delete(b.session.VoiceConnections, "guild2")
+	b.leaveVoiceChannel("guild2")
+	if got := rosterNote(b.conversationManager); got != "" {
+		t.Errorf("roster after leaving = %q, want none", got)
+	}
+}
+
+func TestPerGuildRosterOnlyHasItsGuild(t *testing.T) {
+	b := newRosterBot(true)
+	connectTestVoice(t, b, "guild1", "table1")
+	connectTestVoice(t, b, "guild2", "table2")
+	seatPlayer(t, b, "guild1", "table1", "alice", "Alice", 1)
+	seatPlayer(t, b, "guild2", "table2", "bob", "Bob", 2)
+
+	b.updateAllRosters()
+	if got, want := rosterNote(b.conversation("guild1")), "At the table: PLAYER Alice"; got != want {
+		t.Errorf("guild1 roster = %q, want %q", got, want)
+	}
+	if got, want := rosterNote(b.conversation("guild2")), "At the table: PLAYER Bob"; got != want {
+		t.Errorf("guild2 roster = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,90 @@
+package bot
+
+import (
+	"slices"
+	"time"
+)
+
+// How long a name fetched from Discord's API is reused before it's fetched again
+const displayNameTTL = 10 * time.Minute
+
+// displayNameKey identifies a user in a guild in the display name cache
+type displayNameKey struct {
+	guildID string
+	userID  string
+}
+
+// cachedDisplayName is a name fetched from Discord's API, or the user ID if that failed
+type cachedDisplayName struct {
+	name    string
+	expires time.Time
+}
+
+// speakerLabel returns the role label for the speaker on an SSRC, such as "DM" or
+// "PLAYER Alice", or "" if the speaker hasn't been identified yet
+func (b *Bot) speakerLabel(guildID string, ssrc uint32) string {
+	userID, ok := b.audioManager.UserForSSRC(guildID, ssrc)
+	if !ok {
+		return ""
+	}
+
+	if b.isDMUser(guildID, userID) {
+		return "DM"
+	}
+	return "PLAYER " + b.displayName(guildID, userID)
+}
+
+// isDMUser reports whether the user is the guild's DM or one of the co-DMs
+func (b *Bot) isDMUser(guildID, userID string) bool {
+	return b.config.IsDM(guildID, userID) || slices.Contains(b.config.CoDMUserIDs, userID)
+}
+
+// displayName returns the user's character name if one is set, or else the name they're shown
+// with in the guild, falling back to their user ID
+func (b *Bot) displayName(guildID, userID string) string {
+	if name, ok := b.characterName(userID); ok {
+		return name
+	}
+
+	if member, err := b.session.State.Member(guildID, userID); err == nil {
+		if member.Nick != "" {
+			return member.Nick
+		}
+		if member.User != nil {
+			if member.User.GlobalName != "" {
+				return member.User.GlobalName
+			}
+			return member.User.Username
+		}
+	}
+
+	return b.fetchDisplayName(guildID, userID)
+}
+
+// fetchDisplayName asks Discord for the name of a user who isn't in the state cache. Names,
+// and failures, are remembered for displayNameTTL, since transcriptions and the level meter
+// look speakers up far more often than that.
+func (b *Bot) fetchDisplayName(guildID, userID string) string {
+	key := displayNameKey{guildID: guildID, userID: userID}
+	now := b.clock.Now()
+
+	b.displayNamesMutex.Lock()
+	cached, ok := b.displayNames[key]
+	b.displayNamesMutex.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.name
+	}
+
+	name := userID
+	if user, err := b.session.User(userID); err == nil {
+		name = user.Username
+		if user.GlobalName != "" {
+			name = user.GlobalName
+		}
+	}
+
+	b.displayNamesMutex.Lock()
+	defer b.displayNamesMutex.Unlock()
+	b.displayNames[key] = cachedDisplayName{name: name, expires: now.Add(displayNameTTL)}
+	return name
+}
@@ -0,0 +1,35 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"dnd_dm_assistant_go/internal/config"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestDisplayNameReusesFetchedNames(t *testing.T) {
+	b := newTestBot(&config.Config{})
+	clock := b.clock.(*fixedClock)
+	b.displayNames[displayNameKey{guildID: "guild1", userID: "alice"}] = cachedDisplayName{
+		name:    "Alice",
+		expires: clock.now.Add(time.Minute),
+	}
+
+	// A cached name is used without asking Discord again
+	if got := b.displayName("guild1", "alice"); got != "Alice" {
+		t.Errorf("displayName = %q, want the cached name", got)
+	}
+
+	// A member in the state cache is looked up there, so a new nickname shows at once
+	guild := &discordgo.Guild{ID: "guild1", Members: []*discordgo.Member{
+		{GuildID: "guild1", Nick: "Ally", User: &discordgo.User{ID: "alice", Username: "alice"}},
+	}}
+	if err := b.session.State.GuildAdd(guild); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.displayName("guild1", "alice"); got != "Ally" {
+		t.Errorf("displayName = %q, want the nickname from state", got)
+	}
+}
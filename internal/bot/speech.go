@@ -0,0 +1,62 @@
+package bot
+
+import (
+	"log"
+
+	"dnd_dm_assistant_go/internal/config"
+	"dnd_dm_assistant_go/internal/speech"
+)
+
+// newSpeechService creates the configured speech-to-text backend, or returns nil and the reason
+// if it's unavailable
+func newSpeechService(cfg *config.Config) (speech.Transcriber, featureStatus) {
+	if cfg.SpeechBackend == config.SpeechBackendWhisper {
+		log.Printf("🔧 Using Whisper speech service at %s (model %s)", cfg.WhisperBaseURL, cfg.WhisperModel)
+		return speech.NewWhisperService(cfg.Debug, speech.WhisperOptions{
+			BaseURL: cfg.WhisperBaseURL,
+			APIKey:  cfg.WhisperAPIKey,
+			Model:   cfg.WhisperModel,
+		}), enabledFeature
+	}
+
+	// Create speech service if Google Cloud credentials are available
+	if cfg.GoogleProjectID == "" {
+		log.Printf("ℹ️  Google Project ID not configured - speech service disabled")
+		log.Printf("   Set GOOGLE_PROJECT_ID environment variable to enable speech-to-text")
+		return nil, disabledFeature("GOOGLE_PROJECT_ID is not set")
+	}
+
+	log.Printf("🔧 Attempting to create speech service with project ID: %s", cfg.GoogleProjectID)
+
+	// Check if credentials file exists if specified
+	if cfg.GoogleCredsPath != "" {
+		log.Printf("🔧 Using credentials file: %s", cfg.GoogleCredsPath)
+	} else {
+		log.Printf("🔧 Using default credentials (ADC/environment)")
+	}
+
+	speechService, err := speech.NewService(cfg.GoogleProjectID, cfg.Debug, speech.Options{
+		AutomaticPunctuation: cfg.SpeechAutomaticPunctuation,
+		WordConfidence:       cfg.SpeechWordConfidence,
+		WordTimeOffsets:      cfg.SpeechWordTimeOffsets,
+		Model:                cfg.SpeechModel,
+	})
+	if err != nil {
+		log.Printf("❌ Warning: Failed to create speech service: %v", err)
+		log.Printf("   📋 Troubleshooting steps:")
+		log.Printf("   1. Ensure GOOGLE_PROJECT_ID is set to your GCP project ID")
+		log.Printf("   2. Set up authentication:")
+		log.Printf("      • Set GOOGLE_APPLICATION_CREDENTIALS to path of service account JSON file")
+		log.Printf("      • OR run 'gcloud auth application-default login'")
+		log.Printf("      • OR use GCE/Cloud Run default credentials")
+		if cfg.GoogleCredsPath != "" {
+			log.Printf("   3. Check that credentials file exists: %s", cfg.GoogleCredsPath)
+		}
+		log.Printf("   🔗 See: https://cloud.google.com/docs/authentication/getting-started")
+		log.Printf("   ⚠️  The bot will continue without speech-to-text functionality.")
+		return nil, disabledFeature("failed to create the Google speech client: %v", err)
+	}
+
+	log.Printf("✅ Speech service created successfully (model %s)", cfg.SpeechModel)
+	return speechService, enabledFeature
+}
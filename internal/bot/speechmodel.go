@@ -0,0 +1,44 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"dnd_dm_assistant_go/internal/config"
+	"dnd_dm_assistant_go/internal/speech"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// handleSpeechModelCommand shows or switches the Google recognition model, e.g. latest_short
+// for a table that mostly gives short commands
+func (b *Bot) handleSpeechModelCommand(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+	if !b.requireDM(s, m) {
+		return
+	}
+
+	google, ok := b.speechService.(*speech.Service)
+	if !ok {
+		s.ChannelMessageSend(m.ChannelID, "❌ The speech model can only be changed for Google Speech-to-Text. Whisper uses `WHISPER_MODEL`.")
+		return
+	}
+
+	models := strings.Join(config.SpeechModels, "`, `")
+	if len(args) == 0 {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("🎙️ Speech model: `%s`. Usage: `%s %s <model>` with one of `%s`",
+			google.Model(), b.config.CommandPrefix, commandSpeechModel, models))
+		return
+	}
+
+	model := strings.ToLower(args[0])
+	if !slices.Contains(config.SpeechModels, model) {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Unknown speech model `%s`. Choose one of `%s`.", args[0], models))
+		return
+	}
+
+	google.SetModel(model)
+	log.Printf("Speech model changed to %s by %s", model, m.Author.Username)
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Speech model: `%s`. Audio already sent for transcription keeps the old model.", model))
+}
@@ -0,0 +1,91 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"dnd_dm_assistant_go/internal/dnd"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// tableReloadArg re-reads the tables file instead of rolling
+const tableReloadArg = "reload"
+
+// loadTables reads the random tables from TABLES_FILE, keeping the previously loaded
+// tables if the file can't be read
+func (b *Bot) loadTables() (int, error) {
+	if b.config.TablesFile == "" {
+		return 0, fmt.Errorf("no tables file configured (set TABLES_FILE)")
+	}
+
+	tables, err := dnd.LoadTables(b.config.TablesFile)
+	if err != nil {
+		return 0, err
+	}
+	if _, exists := tables[tableReloadArg]; exists {
+		log.Printf("[BOT] ⚠️ Table %q can't be rolled; %s %s reloads the tables file instead",
+			tableReloadArg, commandTable, tableReloadArg)
+	}
+
+	b.tablesMutex.Lock()
+	b.tables = tables
+	b.tablesMutex.Unlock()
+	return len(tables), nil
+}
+
+// handleTableCommand rolls on a random table, or reloads the tables file
+func (b *Bot) handleTableCommand(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+	if len(args) == 0 {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Please name a table. Usage: `%s %s <name>|%s`",
+			b.config.CommandPrefix, commandTable, tableReloadArg))
+		return
+	}
+
+	name := strings.ToLower(args[0])
+	if name == tableReloadArg {
+		if !b.requireDM(s, m) {
+			return
+		}
+		count, err := b.loadTables()
+		if err != nil {
+			log.Printf("Error reloading tables: %v", err)
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Couldn't reload tables, keeping the old ones: %v", err))
+			return
+		}
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("🎲 Reloaded %d table(s).", count))
+		return
+	}
+
+	b.tablesMutex.Lock()
+	table, exists := b.tables[name]
+	b.tablesMutex.Unlock()
+	if !exists {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ No table named `%s`. Use `%s %s` to list them.",
+			name, b.config.CommandPrefix, commandTables))
+		return
+	}
+
+	entry := table.Roll()
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("🎲 **%s**: %s", table.Name, entry.Text))
+}
+
+// handleTablesCommand lists the loaded random tables
+func (b *Bot) handleTablesCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	b.tablesMutex.Lock()
+	tables := b.tables
+	b.tablesMutex.Unlock()
+
+	if len(tables) == 0 {
+		s.ChannelMessageSend(m.ChannelID, "ℹ️ No random tables loaded. Set `TABLES_FILE` to a JSON file of tables.")
+		return
+	}
+
+	list := "🎲 **Random tables:**\n"
+	for _, name := range tables.Names() {
+		list += fmt.Sprintf("• `%s` (%d entries)\n", name, len(tables[name].Entries))
+	}
+	list += fmt.Sprintf("\nRoll with `%s %s <name>`.", b.config.CommandPrefix, commandTable)
+	b.sendLongMessage(s, m, "Random tables", list)
+}
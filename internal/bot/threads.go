@@ -0,0 +1,59 @@
+package bot
+
+import (
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// Thread names are limited to 100 characters
+	maxThreadNameLength = 100
+
+	// Minutes of inactivity before an output thread is archived
+	threadArchiveMinutes = 1440
+
+	// Longest text message Discord accepts
+	discordMessageLimit = 2000
+)
+
+// sendLongMessage replies to a command with text that may exceed Discord's message limit.
+// With threads enabled, the first chunk goes to the channel and the rest into a thread started
+// from the command message; otherwise (or if a thread can't be created) every chunk goes to the channel.
+func (b *Bot) sendLongMessage(s *discordgo.Session, m *discordgo.MessageCreate, threadName, text string) {
+	chunks := splitMessage(text, discordMessageLimit)
+
+	parts := make([]*discordgo.MessageSend, len(chunks))
+	for i, chunk := range chunks {
+		parts[i] = &discordgo.MessageSend{Content: chunk}
+	}
+	b.sendParts(s, m, threadName, parts)
+}
+
+// sendParts replies to a command with a series of messages, moving all but the first into a
+// thread when threads are enabled, like sendLongMessage
+func (b *Bot) sendParts(s *discordgo.Session, m *discordgo.MessageCreate, threadName string, parts []*discordgo.MessageSend) {
+	channelID := m.ChannelID
+	s.ChannelMessageSendComplex(channelID, parts[0])
+	if len(parts) == 1 {
+		return
+	}
+
+	// Private messages can't have threads
+	if b.config.LongOutputThreads && m.GuildID != "" {
+		if name := []rune(threadName); len(name) > maxThreadNameLength {
+			threadName = string(name[:maxThreadNameLength])
+		}
+
+		thread, err := s.MessageThreadStart(m.ChannelID, m.ID, threadName, threadArchiveMinutes)
+		if err != nil {
+			log.Printf("[BOT] ⚠️ Failed to start thread for long output, posting in channel: %v", err)
+		} else {
+			channelID = thread.ID
+		}
+	}
+
+	for _, part := range parts[1:] {
+		s.ChannelMessageSendComplex(channelID, part)
+	}
+}
@@ -0,0 +1,67 @@
+package bot
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"dnd_dm_assistant_go/internal/claude"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// Largest messages listed individually by the tokens command; the rest are summed
+	tokensTopMessages = 10
+
+	// Characters of each listed message shown to tell which one it is
+	tokensSnippetLength = 40
+)
+
+// handleTokensCommand shows roughly where the next request's tokens go: the system prompt,
+// pending transcriptions and the largest messages, to decide what's worth trimming
+func (b *Bot) handleTokensCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if !b.requireDM(s, m) || !b.requireClaude(s, m) {
+		return
+	}
+
+	breakdown := b.conversation(m.GuildID).TokenBreakdown()
+	total := max(breakdown.Total(), 1)
+
+	var table strings.Builder
+	row := func(part string, tokens int, note string) {
+		fmt.Fprintf(&table, "%-24s %7s %4.0f%%  %s\n", part, fmt.Sprintf("~%d", tokens), float64(tokens)*100/float64(total), note)
+	}
+	fmt.Fprintf(&table, "%-24s %7s %5s\n", "Part", "Tokens", "Share")
+	row("system prompt", breakdown.SystemTokens, "")
+	if breakdown.PendingTokens > 0 {
+		row("pending transcripts", breakdown.PendingTokens, "")
+	}
+
+	largest := slices.Clone(breakdown.Messages)
+	slices.SortStableFunc(largest, func(a, b claude.MessageTokens) int { return b.Tokens - a.Tokens })
+	shown := largest[:min(len(largest), tokensTopMessages)]
+	for _, msg := range shown {
+		row(fmt.Sprintf("message #%d %s", msg.Number, msg.Role), msg.Tokens, tokenSnippet(msg.Text))
+	}
+	if rest := largest[len(shown):]; len(rest) > 0 {
+		tokens := 0
+		for _, msg := range rest {
+			tokens += msg.Tokens
+		}
+		row(fmt.Sprintf("%d other messages", len(rest)), tokens, "")
+	}
+
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("🧮 **Token breakdown** (estimated, ~%d tokens in %d messages, before the reply)\n```\n%s```",
+		breakdown.Total(), len(breakdown.Messages), table.String()))
+}
+
+// tokenSnippet returns the start of a message on one line, to tell it apart in the breakdown
+func tokenSnippet(text string) string {
+	text = strings.Join(strings.Fields(strings.ReplaceAll(text, "`", "'")), " ")
+	runes := []rune(text)
+	if len(runes) <= tokensSnippetLength {
+		return text
+	}
+	return string(runes[:tokensSnippetLength-1]) + "…"
+}
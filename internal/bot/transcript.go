@@ -0,0 +1,79 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"dnd_dm_assistant_go/internal/audio"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// handleTranscriptCommand uploads the spoken record of the current or last session as a text
+// file with speaker names and times. In a server it covers that server's session; in a
+// direct message, the sessions of every guild the author is DM of.
+func (b *Bot) handleTranscriptCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if m.GuildID == "" && !b.requireDMOfAnyGuild(s, m) {
+		return
+	}
+
+	transcripts := b.visibleTurns(m)
+	if len(transcripts) == 0 {
+		s.ChannelMessageSend(m.ChannelID, "ℹ️ No transcriptions recorded in the current or last session.")
+		return
+	}
+
+	for _, transcript := range transcripts {
+		parts := splitTranscript(transcript.Text(), discordUploadLimit)
+		for i, part := range parts {
+			name := transcript.TextFileName()
+			content := fmt.Sprintf("📜 Transcript of the session started <t:%d:f> (%d lines)", transcript.StartTime.Unix(), len(transcript.Turns))
+			if len(parts) > 1 {
+				name = strings.TrimSuffix(name, ".txt") + fmt.Sprintf("_part%d.txt", i+1)
+				content = fmt.Sprintf("📜 Transcript of the session started <t:%d:f>, part %d of %d", transcript.StartTime.Unix(), i+1, len(parts))
+			}
+
+			_, err := s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
+				Content: content,
+				Files: []*discordgo.File{{
+					Name:        name,
+					ContentType: "text/plain",
+					Reader:      strings.NewReader(part),
+				}},
+			})
+			if err != nil {
+				log.Printf("Error uploading transcript: %v", err)
+				s.ChannelMessageSend(m.ChannelID, "❌ Failed to upload the transcript.")
+				return
+			}
+		}
+	}
+}
+
+// visibleTurns returns the speaking turns of the current or last sessions the message may be
+// answered with (see canSeeGuild), ordered by guild
+func (b *Bot) visibleTurns(m *discordgo.MessageCreate) []audio.TurnTranscript {
+	var transcripts []audio.TurnTranscript
+	for _, transcript := range b.audioManager.ExportTurns() {
+		if b.canSeeGuild(m, transcript.GuildID) {
+			transcripts = append(transcripts, transcript)
+		}
+	}
+	return transcripts
+}
+
+// splitTranscript splits text into pieces of at most limit bytes, breaking between lines
+func splitTranscript(text string, limit int) []string {
+	var parts []string
+	for len(text) > limit {
+		cut := strings.LastIndexByte(text[:limit], '\n') + 1
+		if cut == 0 {
+			// A single line longer than the limit; break it mid-line
+			cut = limit
+		}
+		parts = append(parts, text[:cut])
+		text = text[cut:]
+	}
+	return append(parts, text)
+}
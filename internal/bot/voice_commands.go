@@ -0,0 +1,85 @@
+package bot
+
+import (
+	"log"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// handleVoiceCommand runs a spoken command if the transcription is one from the DM.
+// It returns true if the transcription was a command and should not be sent to Claude.
+func (b *Bot) handleVoiceCommand(guildID string, ssrc uint32, text string) bool {
+	if !b.config.VoiceCommandsEnabled {
+		return false
+	}
+
+	// Only the DM may control the bot by voice
+	userID, known := b.audioManager.UserForSSRC(guildID, ssrc)
+	if !known || userID != b.config.DMUserFor(guildID) {
+		return false
+	}
+
+	commandLine, ok := b.matchVoiceCommand(text)
+	if !ok {
+		return false
+	}
+
+	log.Printf("🎙️ Voice command from DM: %q -> %s", text, commandLine)
+	go b.runVoiceCommand(guildID, commandLine)
+	return true
+}
+
+// matchVoiceCommand returns the command line for a transcription of the form "<wake word> <phrase>"
+func (b *Bot) matchVoiceCommand(text string) (string, bool) {
+	words := normalizeSpeech(text)
+	wake := normalizeSpeech(b.config.VoiceWakeWord)
+	if len(wake) == 0 || len(words) <= len(wake) || !slices.Equal(words[:len(wake)], wake) {
+		return "", false
+	}
+
+	rest := strings.Join(words[len(wake):], " ")
+
+	// Prefer the longest matching phrase so "clear all" wins over "clear"
+	var bestPhrase, bestCommand string
+	for phrase, command := range b.config.VoiceCommands {
+		phrase = strings.Join(normalizeSpeech(phrase), " ")
+		if rest != phrase && !strings.HasPrefix(rest, phrase+" ") {
+			continue
+		}
+		if len(phrase) > len(bestPhrase) {
+			bestPhrase, bestCommand = phrase, command
+		}
+	}
+
+	return bestCommand, bestCommand != ""
+}
+
+// runVoiceCommand runs a command line on behalf of the DM in the guild they spoke in,
+// replying in a private message
+func (b *Bot) runVoiceCommand(guildID, commandLine string) {
+	dmUserID := b.config.DMUserFor(guildID)
+	dmChannel, err := b.session.UserChannelCreate(dmUserID)
+	if err != nil {
+		log.Printf("[BOT] ⚠️ Failed to create DM channel for voice command: %v", err)
+		return
+	}
+
+	b.handleCommand(b.session, &discordgo.MessageCreate{
+		Message: &discordgo.Message{
+			ChannelID: dmChannel.ID,
+			GuildID:   guildID,
+			Content:   b.config.CommandPrefix + " " + commandLine,
+			Author:    &discordgo.User{ID: dmUserID},
+		},
+	})
+}
+
+// normalizeSpeech lowercases a transcription and splits it into words, dropping punctuation
+func normalizeSpeech(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+}
@@ -0,0 +1,72 @@
+package bot
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// handleVoiceInfoCommand reports the live state of each voice connection and the audio heard on it,
+// for diagnosing a bot that joined but hears nothing
+func (b *Bot) handleVoiceInfoCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if !b.requireDM(s, m) {
+		return
+	}
+
+	s.RLock()
+	connections := make([]*discordgo.VoiceConnection, 0, len(s.VoiceConnections))
+	for _, vc := range s.VoiceConnections {
+		connections = append(connections, vc)
+	}
+	s.RUnlock()
+
+	if len(connections) == 0 {
+		s.ChannelMessageSend(m.ChannelID, "ℹ️ The bot has no voice connections.")
+		return
+	}
+
+	slices.SortFunc(connections, func(a, b *discordgo.VoiceConnection) int {
+		return strings.Compare(a.GuildID, b.GuildID)
+	})
+
+	var report strings.Builder
+	report.WriteString("**Voice connections:**\n")
+	for _, vc := range connections {
+		vc.RLock()
+		guildID, channelID, ready, receiving := vc.GuildID, vc.ChannelID, vc.Ready, vc.OpusRecv != nil
+		vc.RUnlock()
+
+		fmt.Fprintf(&report, "\n🔊 Guild `%s`, channel <#%s> (`%s`)\n", guildID, channelID, channelID)
+		fmt.Fprintf(&report, "   • Ready: %s\n", yesNo(ready))
+		fmt.Fprintf(&report, "   • Receiving audio (OpusRecv): %s\n", yesNo(receiving))
+
+		processor, ok := b.audioManager.Session(guildID)
+		if !ok || !processor.IsProcessing() {
+			report.WriteString("   • Audio processing: not running\n")
+			continue
+		}
+
+		activity := processor.SSRCActivity()
+		active := 0
+		for _, stream := range activity {
+			if stream.Active {
+				active++
+			}
+		}
+		fmt.Fprintf(&report, "   • Active SSRCs: %d (%d heard)\n", active, len(activity))
+
+		for _, stream := range activity {
+			speaker := "unknown user"
+			if stream.UserID != "" {
+				speaker = fmt.Sprintf("<@%s>", stream.UserID)
+			}
+			fmt.Fprintf(&report, "      ◦ SSRC %d, %s: last packet %s ago, %.1f packets/s\n",
+				stream.SSRC, speaker, stream.LastPacketAge.Round(100*time.Millisecond), stream.PacketRate)
+		}
+	}
+
+	b.sendLongMessage(s, m, "Voice connections", report.String())
+}
@@ -0,0 +1,57 @@
+package claude
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ArchiveAndClear saves a copy of the conversation, including buffered transcriptions, next to
+// the conversation file and then clears it. It returns the copy's path ("" if the conversation
+// is in memory only) and whether there was anything to clear.
+func (cm *ConversationManager) ArchiveAndClear() (string, bool, error) {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	if len(cm.messages) == 0 && len(cm.transcriptionBuf) == 0 {
+		return "", false, nil
+	}
+
+	var archivePath string
+	if !cm.IsEphemeral() {
+		cm.appendTranscriptionBuffer()
+		if err := cm.writeToDisk(); err != nil {
+			return "", false, err
+		}
+
+		data, err := os.ReadFile(cm.filePath)
+		if err != nil {
+			return "", false, fmt.Errorf("failed to read conversation file for archiving: %w", err)
+		}
+		archivePath = cm.archivePath()
+		if err := writeFileAtomic(archivePath, data); err != nil {
+			return "", false, fmt.Errorf("failed to write conversation archive: %w", err)
+		}
+	}
+
+	cm.messages = cm.messages[:0]
+	cm.transcriptionBuf = cm.transcriptionBuf[:0]
+	if err := cm.writeToDisk(); err != nil {
+		return archivePath, true, fmt.Errorf("failed to save cleared conversation: %w", err)
+	}
+
+	if archivePath != "" {
+		log.Printf("[CLAUDE] Archived conversation to %s and cleared it", archivePath)
+	}
+	return archivePath, true, nil
+}
+
+// archivePath returns a timestamped path for an archive of the conversation file, e.g.
+// dnd_conversation_20240102_150405.json. The caller must hold the mutex.
+func (cm *ConversationManager) archivePath() string {
+	ext := filepath.Ext(cm.filePath)
+	stem := strings.TrimSuffix(cm.filePath, ext)
+	return fmt.Sprintf("%s_%s%s", stem, cm.clock.Now().Format("20060102_150405"), ext)
+}
@@ -0,0 +1,152 @@
+package claude
+
+import "unicode/utf8"
+
+const (
+	// Default context window of the model, in tokens
+	defaultContextWindow = 200000
+
+	// Rough characters per token for English text; good enough to see when a limit is near
+	charsPerToken = 4
+
+	// Tokens each message adds beyond its text, for the role and formatting
+	messageOverheadTokens = 4
+
+	// Share of the context window past which the budget counts as nearly full
+	budgetWarnFraction = 0.8
+)
+
+// EstimateTokens roughly estimates how many tokens a text takes
+func EstimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + charsPerToken - 1) / charsPerToken
+}
+
+// EstimateMessageTokens roughly estimates how many tokens a message takes in a request,
+// including its role and formatting
+func EstimateMessageTokens(msg Message) int {
+	return messageOverheadTokens + EstimateTokens(msg.Text())
+}
+
+// ContextBudget estimates how much of the model's context window the next request would use
+type ContextBudget struct {
+	SystemTokens  int // System prompt, including the campaign line
+	HistoryTokens int // Messages in the conversation
+	PendingTokens int // Buffered transcriptions not yet flushed
+	ReplyTokens   int // Room reserved for the answer (the request's max_tokens)
+	ContextWindow int
+
+	Messages    int // Messages in the conversation
+	MaxMessages int // History limit, past which old messages are compacted
+}
+
+// Total returns the estimated tokens of the next request plus its reply
+func (b ContextBudget) Total() int {
+	return b.SystemTokens + b.HistoryTokens + b.PendingTokens + b.ReplyTokens
+}
+
+// Remaining returns the estimated tokens left in the context window
+func (b ContextBudget) Remaining() int {
+	return b.ContextWindow - b.Total()
+}
+
+// NearlyFull reports whether the next request would use most of the context window
+func (b ContextBudget) NearlyFull() bool {
+	return float64(b.Total()) >= float64(b.ContextWindow)*budgetWarnFraction
+}
+
+// SetContextWindow sets the model's context window used by ContextBudget (0 = the default)
+func (cm *ConversationManager) SetContextWindow(tokens int) {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+	cm.contextWindow = tokens
+}
+
+// ContextBudget estimates the tokens the next question would send and how close that is to
+// the model's context window
+func (cm *ConversationManager) ContextBudget() ContextBudget {
+	cm.mutex.RLock()
+	defer cm.mutex.RUnlock()
+
+	budget := ContextBudget{
+		SystemTokens:  EstimateTokens(cm.requestSystemPrompt()),
+		ReplyTokens:   maxTokens,
+		ContextWindow: cm.contextWindow,
+		Messages:      len(cm.messages),
+		MaxMessages:   cm.maxMessages,
+	}
+	if budget.ContextWindow <= 0 {
+		budget.ContextWindow = defaultContextWindow
+	}
+	if cm.verboseNext {
+		budget.SystemTokens += EstimateTokens(verboseInstruction)
+		budget.ReplyTokens = verboseMaxTokens
+	}
+
+	for _, msg := range cm.messages {
+		budget.HistoryTokens += EstimateMessageTokens(msg)
+	}
+	budget.PendingTokens = cm.pendingTokens()
+
+	return budget
+}
+
+// pendingTokens estimates the tokens of the buffered transcriptions once flushed into a
+// message. The caller must hold the mutex.
+func (cm *ConversationManager) pendingTokens() int {
+	if len(cm.transcriptionBuf) == 0 {
+		return 0
+	}
+	tokens := messageOverheadTokens
+	for _, t := range cm.transcriptionBuf {
+		tokens += EstimateTokens(formatTranscriptionLine(t)) + 1
+	}
+	return tokens
+}
+
+// MessageTokens is the estimated size of one message in the conversation
+type MessageTokens struct {
+	Number int // Position in the conversation, from 1
+	Role   string
+	Tokens int
+	Text   string
+}
+
+// TokenBreakdown estimates the tokens of each part of the next request, to see where they go
+type TokenBreakdown struct {
+	SystemTokens  int             // System prompt, including the campaign line and roster
+	PendingTokens int             // Buffered transcriptions not yet flushed
+	Messages      []MessageTokens // In conversation order
+}
+
+// Total returns the estimated tokens of the next request, without the reply
+func (b TokenBreakdown) Total() int {
+	total := b.SystemTokens + b.PendingTokens
+	for _, msg := range b.Messages {
+		total += msg.Tokens
+	}
+	return total
+}
+
+// TokenBreakdown estimates the tokens the system prompt and each message would send
+func (cm *ConversationManager) TokenBreakdown() TokenBreakdown {
+	cm.mutex.RLock()
+	defer cm.mutex.RUnlock()
+
+	breakdown := TokenBreakdown{
+		SystemTokens:  EstimateTokens(cm.requestSystemPrompt()),
+		PendingTokens: cm.pendingTokens(),
+		Messages:      make([]MessageTokens, 0, len(cm.messages)),
+	}
+	if cm.verboseNext {
+		breakdown.SystemTokens += EstimateTokens(verboseInstruction)
+	}
+	for i, msg := range cm.messages {
+		breakdown.Messages = append(breakdown.Messages, MessageTokens{
+			Number: i + 1,
+			Role:   msg.Role,
+			Tokens: EstimateMessageTokens(msg),
+			Text:   msg.Text(),
+		})
+	}
+	return breakdown
+}
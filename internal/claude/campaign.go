@@ -0,0 +1,36 @@
+package claude
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Campaign returns the name of the campaign the conversation belongs to, or "" if unnamed
+func (cm *ConversationManager) Campaign() string {
+	cm.mutex.RLock()
+	defer cm.mutex.RUnlock()
+	return cm.campaign
+}
+
+// SetCampaign names the campaign the conversation belongs to and saves it with the
+// conversation. An empty name clears it.
+func (cm *ConversationManager) SetCampaign(name string) error {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	cm.campaign = strings.TrimSpace(name)
+	if err := cm.saveToDisk(); err != nil {
+		return fmt.Errorf("failed to save campaign name: %w", err)
+	}
+	return nil
+}
+
+// requestSystemPrompt returns the system prompt to send with a request, telling Claude which
+// campaign it's assisting if one is named and who is at the table. The caller must hold the mutex.
+func (cm *ConversationManager) requestSystemPrompt() string {
+	prompt := cm.systemPrompt
+	if cm.campaign != "" {
+		prompt += fmt.Sprintf("\n\nYou are assisting the %s campaign.", cm.campaign)
+	}
+	return prompt + cm.rosterNote()
+}
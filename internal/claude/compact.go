@@ -0,0 +1,121 @@
+package claude
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+)
+
+// CompactStrategy is how old messages are dropped once the conversation exceeds its limit
+type CompactStrategy string
+
+const (
+	// CompactTrim drops the oldest messages
+	CompactTrim CompactStrategy = "trim"
+
+	// CompactSummarize replaces the oldest messages with a summary written by Claude
+	CompactSummarize CompactStrategy = "summarize"
+)
+
+const (
+	// summaryPrefix marks the message that stands in for summarized history
+	summaryPrefix = "[PREVIOUSLY IN THIS SESSION] "
+
+	// Shortest time between summarization requests; compactions in between fall back to trimming
+	compactCooldown = 2 * time.Minute
+)
+
+const compactSystemPrompt = `You condense the log of a D&D session so it can replace the original messages in an assistant's memory.
+Write a concise summary of what happened: plot developments, decisions, NPCs met, combat outcomes, items gained, open threads, and anything the DM noted privately.
+Keep names, numbers and rulings exact. Write plain prose or short bullet points, with no preamble.`
+
+// SetCompactStrategy sets how old messages are dropped when the conversation is full
+func (cm *ConversationManager) SetCompactStrategy(strategy CompactStrategy) {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+	cm.compactStrategy = strategy
+}
+
+// isSummary returns whether a message is a summary of earlier messages
+func isSummary(msg Message) bool {
+	text, ok := msg.Content.(string)
+	return ok && strings.HasPrefix(text, summaryPrefix)
+}
+
+// startSummary starts replacing the messages before end with a summary from Claude, returning
+// false if it can't be done right now because the last summary was too recent. The request is
+// sent in the background so the conversation isn't locked while Claude writes the summary.
+// The caller must hold the mutex.
+func (cm *ConversationManager) startSummary(end int) bool {
+	now := cm.clock.Now()
+	if !cm.lastCompaction.IsZero() && now.Sub(cm.lastCompaction) < compactCooldown {
+		if cm.debug.Load() {
+			log.Printf("[CLAUDE] Last summary was %v ago, trimming instead", now.Sub(cm.lastCompaction).Round(time.Second))
+		}
+		return false
+	}
+	cm.lastCompaction = now
+	cm.compacting = true
+
+	old := slices.Clone(cm.messages[:end])
+	var history strings.Builder
+	for _, msg := range old {
+		fmt.Fprintf(&history, "%s: %s\n\n", strings.ToUpper(msg.Role), messageText(msg.Content))
+	}
+	request := []Message{cm.newMessage("user", history.String())}
+
+	cm.compactions.Add(1)
+	go cm.summarize(old, request)
+	return true
+}
+
+// summarize sends a summary request for old messages, then swaps the summary in for them.
+// If the summary can't be made, the conversation is trimmed instead.
+func (cm *ConversationManager) summarize(old, request []Message) {
+	defer cm.compactions.Done()
+
+	response, err := cm.service.SendMessage(request, compactSystemPrompt)
+
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+	cm.compacting = false
+
+	summary := ""
+	if err != nil {
+		log.Printf("[CLAUDE] ⚠️ Failed to summarize old messages, trimming instead: %v", err)
+	} else if summary = strings.TrimSpace(GetResponseText(response)); summary == "" {
+		log.Printf("[CLAUDE] ⚠️ Got an empty summary of old messages, trimming instead")
+	} else if cm.replaceWithSummary(old, summary) {
+		log.Printf("[CLAUDE] Summarized %d old messages (%d chars)", len(old), len(summary))
+	} else {
+		log.Printf("[CLAUDE] The summarized messages changed while Claude summarized them, trimming instead")
+	}
+
+	// Messages may have arrived during the request; trimming falls back to dropping them
+	// since the cooldown has started
+	cm.trimMessages()
+	if err := cm.saveToDisk(); err != nil {
+		log.Printf("[CLAUDE] ⚠️ Failed to save conversation: %v", err)
+	}
+}
+
+// replaceWithSummary puts a summary in place of old, the oldest messages of the conversation,
+// returning false if they've since been cleared, trimmed or added to. The caller must hold the mutex.
+func (cm *ConversationManager) replaceWithSummary(old []Message, summary string) bool {
+	end := cm.lastIndexOf(old[len(old)-1]) + 1
+	if end != len(old) {
+		return false
+	}
+
+	// The summary takes the place of the messages it covers, so it keeps their position in time
+	compacted := make([]Message, 0, len(cm.messages)-end+1)
+	compacted = append(compacted, Message{
+		Role:      "user",
+		Content:   summaryPrefix + summary,
+		Timestamp: old[len(old)-1].Timestamp,
+	})
+	cm.messages = append(compacted, cm.messages[end:]...)
+	return true
+}
@@ -0,0 +1,133 @@
+package claude
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fillConversation flushes one transcription line per message until there are n
+func fillConversation(cm *ConversationManager, n int) {
+	for i := 1; i <= n; i++ {
+		cm.AddTranscription(1, "DM", fmt.Sprintf("line %d", i), 0.9)
+		cm.FlushTranscriptions()
+	}
+}
+
+func TestCompactSummarizeReplacesOldMessages(t *testing.T) {
+	fake := &fakeSender{reply: func(messages []Message, systemPrompt string) (*Response, error) {
+		return textResponse("The party reached the tavern."), nil
+	}}
+	cm := newTestConversation(fake, 8)
+	cm.SetCompactStrategy(CompactSummarize)
+
+	fillConversation(cm, 9)
+	cm.compactions.Wait()
+
+	if fake.calls() != 1 {
+		t.Fatalf("sent %d summary requests, want 1", fake.calls())
+	}
+	if fake.prompts[0] != compactSystemPrompt {
+		t.Errorf("summary request used system prompt %q", fake.prompts[0])
+	}
+	request := fake.lastRequest()[0].Text()
+	for _, want := range []string{"line 1", "line 2", "line 3"} {
+		if !strings.Contains(request, want) {
+			t.Errorf("summary request is missing %q: %q", want, request)
+		}
+	}
+	if strings.Contains(request, "line 4") {
+		t.Errorf("summary request includes a message that's kept: %q", request)
+	}
+
+	messages := messagesOf(cm)
+	if len(messages) != 7 {
+		t.Fatalf("conversation has %d messages, want the summary and the newest 6", len(messages))
+	}
+	if got := messages[0].Text(); got != summaryPrefix+"The party reached the tavern." {
+		t.Errorf("first message = %q, want the summary", got)
+	}
+	if !strings.HasSuffix(messages[1].Text(), "line 4") {
+		t.Errorf("message after the summary = %q, want line 4", messages[1].Text())
+	}
+}
+
+func TestCompactSummarizeFallsBackToTrimming(t *testing.T) {
+	fake := &fakeSender{reply: func([]Message, string) (*Response, error) {
+		return nil, errors.New("connection reset")
+	}}
+	cm := newTestConversation(fake, 8)
+	cm.SetCompactStrategy(CompactSummarize)
+
+	fillConversation(cm, 9)
+	cm.compactions.Wait()
+
+	messages := messagesOf(cm)
+	if len(messages) != 6 {
+		t.Fatalf("conversation has %d messages, want the newest 6 after trimming", len(messages))
+	}
+	if isSummary(messages[0]) {
+		t.Error("a failed summary left a summary message")
+	}
+}
+
+func TestCompactSummarizeDoesNotHoldLock(t *testing.T) {
+	release := make(chan struct{})
+	fake := &fakeSender{reply: func([]Message, string) (*Response, error) {
+		<-release
+		return textResponse("Summary."), nil
+	}}
+	cm := newTestConversation(fake, 8)
+	cm.SetCompactStrategy(CompactSummarize)
+
+	fillConversation(cm, 9)
+
+	// The conversation stays usable while the summary request is outstanding
+	done := make(chan struct{})
+	go func() {
+		cm.AddTranscription(2, "PLAYER Alice", "I order an ale.", 0.9)
+		cm.HasPendingTranscriptions()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("the conversation was locked during the summary request")
+	}
+
+	// More messages while summarizing don't start a second summary
+	fillConversation(cm, 2)
+
+	close(release)
+	cm.compactions.Wait()
+
+	if fake.calls() != 1 {
+		t.Errorf("sent %d summary requests, want 1", fake.calls())
+	}
+	if messages := messagesOf(cm); !isSummary(messages[0]) {
+		t.Errorf("first message = %q, want the summary", messages[0].Text())
+	}
+}
+
+func TestCompactSummarizeDiscardedAfterClear(t *testing.T) {
+	release := make(chan struct{})
+	fake := &fakeSender{reply: func([]Message, string) (*Response, error) {
+		<-release
+		return textResponse("Summary."), nil
+	}}
+	cm := newTestConversation(fake, 8)
+	cm.SetCompactStrategy(CompactSummarize)
+
+	fillConversation(cm, 9)
+	if err := cm.ClearConversation(); err != nil {
+		t.Fatalf("ClearConversation: %v", err)
+	}
+	close(release)
+	cm.compactions.Wait()
+
+	if messages := messagesOf(cm); len(messages) != 0 {
+		t.Errorf("conversation has %d messages after clearing, want none", len(messages))
+	}
+}
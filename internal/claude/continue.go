@@ -0,0 +1,61 @@
+package claude
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// ErrNothingToContinue is returned by ContinueResponse when the last response wasn't cut off
+var ErrNothingToContinue = errors.New("the last response is complete")
+
+// ContinueResponse asks Claude to finish a response that was cut off. The partial turn is sent
+// as-is so Claude picks up where it stopped, and the continuation is appended to it. The stored
+// turn only changes once the continuation has arrived.
+func (cm *ConversationManager) ContinueResponse() (string, error) {
+	cm.mutex.Lock()
+	if len(cm.messages) == 0 || !cm.messages[len(cm.messages)-1].Partial {
+		cm.mutex.Unlock()
+		return "", ErrNothingToContinue
+	}
+
+	// The API rejects a final assistant turn that ends in whitespace; trim the copy sent
+	partial := cm.messages[len(cm.messages)-1]
+	trimmed := strings.TrimRight(messageText(partial.Content), " \t\n")
+	messages := cm.apiMessages()
+	messages[len(messages)-1].Content = trimmed
+	systemPrompt := cm.requestSystemPrompt()
+	cm.mutex.Unlock()
+
+	if cm.debug.Load() {
+		log.Printf("[CLAUDE] Continuing partial response")
+	}
+
+	response, err := cm.service.SendMessage(messages, systemPrompt)
+	if err != nil {
+		return "", fmt.Errorf("failed to get continuation from Claude: %w", err)
+	}
+
+	continuation := GetResponseText(response)
+	if continuation == "" {
+		return "", fmt.Errorf("received empty response from Claude")
+	}
+
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	i := cm.lastIndexOf(partial)
+	if i < 0 {
+		log.Printf("[CLAUDE] The partial response was cleared or trimmed from the conversation while Claude continued it, not recording the rest")
+		return withModelNote(response, continuation), nil
+	}
+	cm.messages[i].Content = trimmed + continuation
+	cm.messages[i].Partial = response.Truncated()
+
+	if err := cm.saveToDisk(); err != nil {
+		log.Printf("[CLAUDE] ⚠️ Failed to save conversation: %v", err)
+	}
+
+	return withModelNote(response, continuation), nil
+}
@@ -0,0 +1,118 @@
+package claude
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// truncatedResponse builds a response that stopped at the token limit
+func truncatedResponse(text string) *Response {
+	response := textResponse(text)
+	response.StopReason = "max_tokens"
+	return response
+}
+
+// askTruncated leaves the conversation ending in a partial answer
+func askTruncated(t *testing.T, fake *fakeSender, cm *ConversationManager) {
+	t.Helper()
+	fake.reply = func([]Message, string) (*Response, error) {
+		return truncatedResponse("The dragon breathes fire and \n"), nil
+	}
+	answer, err := cm.AskQuestion("What does the dragon do?")
+	if err != nil {
+		t.Fatalf("AskQuestion: %v", err)
+	}
+	if !strings.Contains(answer, "cut off") {
+		t.Errorf("answer %q doesn't say it was cut off", answer)
+	}
+}
+
+func TestTruncatedAnswerIsKeptAsPartial(t *testing.T) {
+	fake := &fakeSender{}
+	cm := newTestConversation(fake, 50)
+	askTruncated(t, fake, cm)
+
+	messages := messagesOf(cm)
+	last := messages[len(messages)-1]
+	if !last.Partial || last.Text() != "The dragon breathes fire and \n" {
+		t.Errorf("last message = %+v, want the partial answer", last)
+	}
+}
+
+func TestContinueResponseAppendsContinuation(t *testing.T) {
+	fake := &fakeSender{}
+	cm := newTestConversation(fake, 50)
+	askTruncated(t, fake, cm)
+
+	fake.reply = func([]Message, string) (*Response, error) {
+		return textResponse(" everyone takes 8d6 damage."), nil
+	}
+	continuation, err := cm.ContinueResponse()
+	if err != nil {
+		t.Fatalf("ContinueResponse: %v", err)
+	}
+	if continuation != " everyone takes 8d6 damage." {
+		t.Errorf("continuation = %q", continuation)
+	}
+
+	// The partial turn is sent without trailing whitespace as the prefill
+	request := fake.lastRequest()
+	if prefill := request[len(request)-1]; prefill.Role != "assistant" || prefill.Text() != "The dragon breathes fire and" {
+		t.Errorf("prefill = %+v", prefill)
+	}
+
+	messages := messagesOf(cm)
+	last := messages[len(messages)-1]
+	if last.Partial || last.Text() != "The dragon breathes fire and everyone takes 8d6 damage." {
+		t.Errorf("last message = %+v, want the completed answer", last)
+	}
+
+	if _, err := cm.ContinueResponse(); !errors.Is(err, ErrNothingToContinue) {
+		t.Errorf("continuing a complete answer: err = %v, want ErrNothingToContinue", err)
+	}
+}
+
+func TestContinueResponseFailureLeavesPartialTurn(t *testing.T) {
+	fake := &fakeSender{}
+	cm := newTestConversation(fake, 50)
+	askTruncated(t, fake, cm)
+
+	fake.reply = func([]Message, string) (*Response, error) {
+		return nil, &APIError{StatusCode: 529, Message: "overloaded"}
+	}
+	if _, err := cm.ContinueResponse(); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	messages := messagesOf(cm)
+	last := messages[len(messages)-1]
+	if !last.Partial || last.Text() != "The dragon breathes fire and \n" {
+		t.Errorf("last message = %+v, want the partial answer untouched", last)
+	}
+}
+
+func TestContinueResponseStillTruncated(t *testing.T) {
+	fake := &fakeSender{}
+	cm := newTestConversation(fake, 50)
+	askTruncated(t, fake, cm)
+
+	fake.reply = func([]Message, string) (*Response, error) {
+		return truncatedResponse(" the wizard"), nil
+	}
+	if _, err := cm.ContinueResponse(); err != nil {
+		t.Fatalf("ContinueResponse: %v", err)
+	}
+
+	messages := messagesOf(cm)
+	if last := messages[len(messages)-1]; !last.Partial {
+		t.Error("answer cut off again should stay partial")
+	}
+}
+
+func TestContinueResponseNothingToContinue(t *testing.T) {
+	cm := newTestConversation(&fakeSender{}, 50)
+	if _, err := cm.ContinueResponse(); !errors.Is(err, ErrNothingToContinue) {
+		t.Errorf("err = %v, want ErrNothingToContinue", err)
+	}
+}
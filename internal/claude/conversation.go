@@ -2,30 +2,99 @@ package claude
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+	"unicode"
+
+	"dnd_dm_assistant_go/internal/clock"
 )
 
 // ConversationManager manages the persistent conversation with Claude
 type ConversationManager struct {
-	service          *Service
+	service          MessageSender
 	filePath         string
 	maxMessages      int
-	debug            bool
+	debug            atomic.Bool
 	systemPrompt     string
+	campaign         string // Name of the campaign, persisted with the conversation
 	messages         []Message
-	transcriptionBuf []string
+	transcriptionBuf []Transcription
+	pins             []Pin // Saved answers, untouched by trimming and clearing
+	maxMessagesSet   bool  // maxMessages was changed at runtime and is persisted
+	clock            clock.Clock
+	bufferOptions    BufferOptions
+	bufferStats      BufferStats
+	compactStrategy  CompactStrategy
+	lastCompaction   time.Time // When old messages were last summarized
+	compacting       bool      // A summary of old messages is being written
+	compactions      sync.WaitGroup
+	verboseNext      bool     // Give the next question a long answer
+	roster           []string // Speakers at the table, e.g. "DM" or "PLAYER Alice"
+	saveInterval     time.Duration
+	contextWindow    int           // Model context window in tokens, for budget estimates
+	dirty            bool          // Changed since the last write, with periodic saving
+	stopSaving       chan struct{} // Stops the periodic saver
 	mutex            sync.RWMutex
 }
 
+// BufferOptions bounds the transcription buffer so a single flush can't flood Claude's context
+type BufferOptions struct {
+	// Flush the buffer into the conversation once it holds this many lines or characters (0 = unlimited)
+	MaxLines int
+	MaxChars int
+
+	// Drop transcriptions that are only filler words ("um", "uh", ...) when flushing
+	FilterFiller bool
+
+	// Drop transcriptions below this confidence when flushing (0 = keep all)
+	MinConfidence float64
+
+	// Append LowConfidenceMarker to transcriptions below this confidence, so Claude knows
+	// which parts may be misheard (0 = never)
+	LowConfidenceThreshold float64
+	LowConfidenceMarker    string
+}
+
+// BufferStats counts how the transcription buffer has been throttled
+type BufferStats struct {
+	OverflowFlushes int // Flushes triggered by the buffer exceeding its limits
+	FillerDropped   int // Transcriptions dropped as filler
+	LowConfDropped  int // Transcriptions dropped for low confidence
+}
+
+// Transcription is a single transcribed utterance from one speaker
+type Transcription struct {
+	SSRC       uint32
+	Speaker    string // Role label such as "DM" or "PLAYER Alice", empty if unknown
+	Text       string
+	Confidence float64
+	Timestamp  time.Time
+}
+
+// transcriptionPrefix marks transcription lines in conversation messages
+const transcriptionPrefix = "[TRANSCRIPTION] SSRC "
+
+// fillerWords are disfluencies that carry no meaning in a transcription. Short answers such as
+// "yes", "no" or "okay" are left out: at the table they're often replies to the DM.
+var fillerWords = map[string]bool{
+	"um": true, "uh": true, "er": true, "erm": true, "hmm": true, "mm": true, "mhm": true,
+}
+
 // ConversationData represents the data structure saved to disk
 type ConversationData struct {
 	SystemPrompt string    `json:"system_prompt"`
+	Campaign     string    `json:"campaign,omitempty"`
 	Messages     []Message `json:"messages"`
+	MaxMessages  int       `json:"max_messages,omitempty"` // Set when changed at runtime
+	Pins         []Pin     `json:"pins,omitempty"`
 	LastSaved    time.Time `json:"last_saved"`
 	Version      string    `json:"version"`
 }
@@ -52,23 +121,34 @@ Guidelines:
 - Only respond when you have something genuinely helpful to contribute
 - If there's nothing that needs your input, you can stay silent
 
-The conversation below represents the ongoing D&D session. Recent transcriptions will show as "[TRANSCRIPTION] SSRC <number>: <text>" where each SSRC represents a different speaker.`
+The conversation below represents the ongoing D&D session. Recent transcriptions will show as "[TRANSCRIPTION] SSRC <number> [<speaker>]: <text>" where each SSRC represents a different speaker.
+The speaker label is "DM" for the Dungeon Master and "PLAYER <name>" for players; it is omitted when the speaker is unknown.
+A message starting with "[PREVIOUSLY IN THIS SESSION]" summarizes earlier parts of the session that are no longer shown in full.
+Messages starting with "[DM NOTE]" are private context typed by the DM, not speech. Treat them as true, and don't reveal secrets from them unless the DM asks.
+Give the DM's narration and questions the most weight - they define what is happening in the game. Player chatter is useful context but may be off-topic.`
 )
 
-// NewConversationManager creates a new conversation manager
-func NewConversationManager(service *Service, filePath string, maxMessages int, debug bool) *ConversationManager {
+// NewConversationManager creates a new conversation manager. An empty filePath keeps
+// the conversation in memory only.
+func NewConversationManager(service MessageSender, filePath string, maxMessages int, debug bool) *ConversationManager {
 	cm := &ConversationManager{
 		service:          service,
 		filePath:         filePath,
 		maxMessages:      maxMessages,
-		debug:            debug,
 		systemPrompt:     defaultSystemPrompt,
 		messages:         make([]Message, 0),
-		transcriptionBuf: make([]string, 0),
+		transcriptionBuf: make([]Transcription, 0),
+		clock:            clock.Real{},
+		compactStrategy:  CompactTrim,
 	}
+	cm.debug.Store(debug)
 
 	// Try to load existing conversation
-	if err := cm.loadFromDisk(); err != nil {
+	if err := cm.loadFromDisk(); errors.Is(err, ErrUnknownConversationVersion) {
+		// Saving would overwrite the newer file with a format it may not understand
+		log.Printf("[CLAUDE] ⚠️ Not loading %s: %v. Keeping this conversation in memory only", filePath, err)
+		cm.filePath = ""
+	} else if err != nil {
 		if debug {
 			log.Printf("[CLAUDE] No existing conversation file or failed to load: %v", err)
 			log.Printf("[CLAUDE] Starting fresh conversation")
@@ -78,17 +158,65 @@ func NewConversationManager(service *Service, filePath string, maxMessages int,
 	return cm
 }
 
-// AddTranscription adds a transcription to the buffer
-func (cm *ConversationManager) AddTranscription(ssrc uint32, text string) {
+// SetDebug enables or disables debug logging
+func (cm *ConversationManager) SetDebug(debug bool) {
+	cm.debug.Store(debug)
+}
+
+// SetClock replaces the clock used for message timestamps
+func (cm *ConversationManager) SetClock(c clock.Clock) {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+	cm.clock = c
+}
+
+// newMessage creates a conversation message timestamped with the manager's clock
+func (cm *ConversationManager) newMessage(role string, content string) Message {
+	return Message{
+		Role:      role,
+		Content:   content,
+		Timestamp: cm.clock.Now(),
+	}
+}
+
+// SetBufferOptions sets the limits and filters applied to the transcription buffer
+func (cm *ConversationManager) SetBufferOptions(opts BufferOptions) {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+	cm.bufferOptions = opts
+}
+
+// AddTranscription adds a transcription to the buffer. The speaker is a role label
+// such as "DM" or "PLAYER Alice", or empty if the speaker is unknown.
+func (cm *ConversationManager) AddTranscription(ssrc uint32, speaker string, text string, confidence float64) {
 	cm.mutex.Lock()
 	defer cm.mutex.Unlock()
 
-	transcription := fmt.Sprintf("[TRANSCRIPTION] SSRC %d: %s", ssrc, text)
-	cm.transcriptionBuf = append(cm.transcriptionBuf, transcription)
+	cm.transcriptionBuf = append(cm.transcriptionBuf, Transcription{
+		SSRC:       ssrc,
+		Speaker:    speaker,
+		Text:       text,
+		Confidence: confidence,
+		Timestamp:  cm.clock.Now(),
+	})
 
-	if cm.debug {
+	if cm.debug.Load() {
 		log.Printf("[CLAUDE] Added transcription to buffer (total: %d)", len(cm.transcriptionBuf))
 	}
+
+	// Keep each flushed user turn bounded by moving an oversized buffer into the conversation now
+	if cm.bufferExceedsLimits() {
+		cm.bufferStats.OverflowFlushes++
+		log.Printf("[CLAUDE] Transcription buffer exceeded limits (%d lines), flushing to conversation",
+			len(cm.transcriptionBuf))
+
+		if cm.appendTranscriptionBuffer() {
+			cm.trimMessages()
+			if err := cm.saveToDisk(); err != nil {
+				log.Printf("[CLAUDE] ⚠️ Failed to save conversation: %v", err)
+			}
+		}
+	}
 }
 
 // FlushTranscriptions flushes buffered transcriptions to the conversation
@@ -101,13 +229,11 @@ func (cm *ConversationManager) FlushTranscriptions() {
 	}
 
 	// Combine all buffered transcriptions into a single user message
-	content := strings.Join(cm.transcriptionBuf, "\n")
-	message := CreateUserMessage(content)
-
-	cm.messages = append(cm.messages, message)
-	cm.transcriptionBuf = cm.transcriptionBuf[:0] // Clear buffer
+	if !cm.appendTranscriptionBuffer() {
+		return
+	}
 
-	if cm.debug {
+	if cm.debug.Load() {
 		log.Printf("[CLAUDE] Flushed transcriptions to conversation (total messages: %d)", len(cm.messages))
 	}
 
@@ -120,37 +246,32 @@ func (cm *ConversationManager) FlushTranscriptions() {
 	}
 }
 
-// AskQuestion sends a direct question to Claude and returns the response
+// AskQuestion sends a direct question to Claude and returns the response. The lock is released
+// during the request so transcriptions keep arriving while Claude answers.
 func (cm *ConversationManager) AskQuestion(question string) (string, error) {
 	cm.mutex.Lock()
-	defer cm.mutex.Unlock()
 
 	// First flush any pending transcriptions
-	if len(cm.transcriptionBuf) > 0 {
-		content := strings.Join(cm.transcriptionBuf, "\n")
-		transcriptionMsg := CreateUserMessage(content)
-		cm.messages = append(cm.messages, transcriptionMsg)
-		cm.transcriptionBuf = cm.transcriptionBuf[:0]
-	}
+	cm.appendTranscriptionBuffer()
 
 	// Add the question as a user message
-	questionMsg := CreateUserMessage(question)
+	questionMsg := cm.newMessage("user", question)
 	cm.messages = append(cm.messages, questionMsg)
 
-	if cm.debug {
+	if cm.debug.Load() {
 		log.Printf("[CLAUDE] Asking question: %s", question)
 	}
 
-	// Prepare messages for API (exclude system messages from the message array)
-	apiMessages := make([]Message, 0, len(cm.messages))
-	for _, msg := range cm.messages {
-		if msg.Role != "system" {
-			apiMessages = append(apiMessages, msg)
-		}
-	}
+	messages := cm.apiMessages()
+	systemPrompt, verbose := cm.questionSystemPrompt()
+	cm.mutex.Unlock()
 
 	// Send to Claude
-	response, err := cm.service.SendMessage(apiMessages, cm.systemPrompt)
+	response, err := cm.sendQuestion(messages, systemPrompt, verbose)
+
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
 	if err != nil {
 		return "", fmt.Errorf("failed to get response from Claude: %w", err)
 	}
@@ -161,9 +282,18 @@ func (cm *ConversationManager) AskQuestion(question string) (string, error) {
 		return "", fmt.Errorf("received empty response from Claude")
 	}
 
-	// Add Claude's response to the conversation
-	assistantMsg := CreateAssistantMessage(responseText)
-	cm.messages = append(cm.messages, assistantMsg)
+	// A verbose answer is a one-off
+	if verbose {
+		cm.verboseNext = false
+	}
+
+	// Add Claude's response to the conversation, next to its question
+	assistantMsg := cm.newMessage("assistant", responseText)
+	assistantMsg.Partial = response.Truncated()
+	if !cm.insertAfter(questionMsg, assistantMsg) {
+		log.Printf("[CLAUDE] The question was cleared or trimmed from the conversation while Claude answered, not recording the answer")
+		return withModelNote(response, responseText), nil
+	}
 
 	// Trim messages if needed
 	cm.trimMessages()
@@ -173,42 +303,44 @@ func (cm *ConversationManager) AskQuestion(question string) (string, error) {
 		log.Printf("[CLAUDE] ⚠️ Failed to save conversation: %v", err)
 	}
 
-	if cm.debug {
+	if cm.debug.Load() {
 		log.Printf("[CLAUDE] Got response (%d chars)", len(responseText))
 	}
 
-	return responseText, nil
+	return withModelNote(response, responseText), nil
 }
 
-// FlushTranscriptionsAndRespond flushes buffered transcriptions and gets Claude's response
+// FlushTranscriptionsAndRespond flushes buffered transcriptions and gets Claude's response.
+// Like AskQuestion, it doesn't hold the lock during the request.
 func (cm *ConversationManager) FlushTranscriptionsAndRespond() (string, error) {
 	cm.mutex.Lock()
-	defer cm.mutex.Unlock()
 
 	if len(cm.transcriptionBuf) == 0 {
+		cm.mutex.Unlock()
 		return "", nil // No transcriptions to flush
 	}
 
 	// Combine all buffered transcriptions into a single user message
-	content := strings.Join(cm.transcriptionBuf, "\n")
-	transcriptionMsg := CreateUserMessage(content)
-	cm.messages = append(cm.messages, transcriptionMsg)
-	cm.transcriptionBuf = cm.transcriptionBuf[:0] // Clear buffer
+	if !cm.appendTranscriptionBuffer() {
+		cm.mutex.Unlock()
+		return "", nil // Everything was filtered out
+	}
+	flushedMsg := cm.messages[len(cm.messages)-1]
 
-	if cm.debug {
+	if cm.debug.Load() {
 		log.Printf("[CLAUDE] Flushed transcriptions to conversation and requesting response (total messages: %d)", len(cm.messages))
 	}
 
-	// Prepare messages for API (exclude system messages from the message array)
-	apiMessages := make([]Message, 0, len(cm.messages))
-	for _, msg := range cm.messages {
-		if msg.Role != "system" {
-			apiMessages = append(apiMessages, msg)
-		}
-	}
+	messages := cm.apiMessages()
+	systemPrompt := cm.requestSystemPrompt()
+	cm.mutex.Unlock()
 
 	// Send to Claude for analysis/response
-	response, err := cm.service.SendMessage(apiMessages, cm.systemPrompt)
+	response, err := cm.service.SendMessage(messages, systemPrompt)
+
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
 	if err != nil {
 		// Save the conversation even if Claude request failed
 		if saveErr := cm.saveToDisk(); saveErr != nil {
@@ -228,9 +360,13 @@ func (cm *ConversationManager) FlushTranscriptionsAndRespond() (string, error) {
 		return "", nil // No response from Claude
 	}
 
-	// Add Claude's response to the conversation
-	assistantMsg := CreateAssistantMessage(responseText)
-	cm.messages = append(cm.messages, assistantMsg)
+	// Add Claude's response to the conversation, after the transcriptions it responds to
+	assistantMsg := cm.newMessage("assistant", responseText)
+	assistantMsg.Partial = response.Truncated()
+	if !cm.insertAfter(flushedMsg, assistantMsg) {
+		log.Printf("[CLAUDE] The transcriptions were cleared or trimmed from the conversation while Claude responded, not recording the response")
+		return withModelNote(response, responseText), nil
+	}
 
 	// Trim messages if needed
 	cm.trimMessages()
@@ -240,11 +376,11 @@ func (cm *ConversationManager) FlushTranscriptionsAndRespond() (string, error) {
 		log.Printf("[CLAUDE] ⚠️ Failed to save conversation: %v", err)
 	}
 
-	if cm.debug {
+	if cm.debug.Load() {
 		log.Printf("[CLAUDE] Got auto-response (%d chars)", len(responseText))
 	}
 
-	return responseText, nil
+	return withModelNote(response, responseText), nil
 }
 
 // GetConversationSummary returns a summary of the current conversation
@@ -256,6 +392,10 @@ func (cm *ConversationManager) GetConversationSummary() string {
 	if len(cm.transcriptionBuf) > 0 {
 		summary += fmt.Sprintf(", %d pending transcriptions", len(cm.transcriptionBuf))
 	}
+	if stats := cm.bufferStats; stats.OverflowFlushes+stats.FillerDropped+stats.LowConfDropped > 0 {
+		summary += fmt.Sprintf(" (throttled: %d overflow flushes, %d filler and %d low-confidence lines dropped)",
+			stats.OverflowFlushes, stats.FillerDropped, stats.LowConfDropped)
+	}
 
 	return summary
 }
@@ -268,17 +408,49 @@ func (cm *ConversationManager) ClearConversation() error {
 	cm.messages = cm.messages[:0]
 	cm.transcriptionBuf = cm.transcriptionBuf[:0]
 
-	if err := cm.saveToDisk(); err != nil {
+	// Write immediately so a crash can't bring the cleared history back
+	if err := cm.writeToDisk(); err != nil {
 		return fmt.Errorf("failed to save cleared conversation: %w", err)
 	}
 
-	if cm.debug {
+	if cm.debug.Load() {
 		log.Printf("[CLAUDE] Conversation cleared")
 	}
 
 	return nil
 }
 
+// MaxMessages returns the current conversation message limit
+func (cm *ConversationManager) MaxMessages() int {
+	cm.mutex.RLock()
+	defer cm.mutex.RUnlock()
+	return cm.maxMessages
+}
+
+// SetMaxMessages changes the conversation message limit, trims to it and persists it
+func (cm *ConversationManager) SetMaxMessages(maxMessages int) error {
+	if maxMessages <= 0 {
+		return fmt.Errorf("message limit must be positive")
+	}
+
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	cm.maxMessages = maxMessages
+	cm.maxMessagesSet = true
+	cm.trimMessages()
+
+	if err := cm.saveToDisk(); err != nil {
+		return fmt.Errorf("failed to save conversation: %w", err)
+	}
+
+	if cm.debug.Load() {
+		log.Printf("[CLAUDE] Message limit set to %d", maxMessages)
+	}
+
+	return nil
+}
+
 // HasPendingTranscriptions returns true if there are transcriptions waiting to be flushed
 func (cm *ConversationManager) HasPendingTranscriptions() bool {
 	cm.mutex.RLock()
@@ -286,6 +458,229 @@ func (cm *ConversationManager) HasPendingTranscriptions() bool {
 	return len(cm.transcriptionBuf) > 0
 }
 
+// RecentTranscriptions returns up to n of the most recent transcriptions, oldest first,
+// including ones still waiting in the buffer
+func (cm *ConversationManager) RecentTranscriptions(n int) []Transcription {
+	cm.mutex.RLock()
+	defer cm.mutex.RUnlock()
+
+	if n <= 0 {
+		return nil
+	}
+
+	// Walk backwards from the buffer through the stored messages until we have enough
+	recent := make([]Transcription, 0, n)
+	for i := len(cm.transcriptionBuf) - 1; i >= 0 && len(recent) < n; i-- {
+		recent = append(recent, cm.transcriptionBuf[i])
+	}
+
+	for i := len(cm.messages) - 1; i >= 0 && len(recent) < n; i-- {
+		msg := cm.messages[i]
+		content, ok := msg.Content.(string)
+		if msg.Role != "user" || !ok {
+			continue
+		}
+
+		lines := strings.Split(content, "\n")
+		for j := len(lines) - 1; j >= 0 && len(recent) < n; j-- {
+			if t, ok := parseTranscriptionLine(lines[j]); ok {
+				t.Timestamp = msg.Timestamp
+				recent = append(recent, t)
+			}
+		}
+	}
+
+	// Reverse into chronological order
+	for i, j := 0, len(recent)-1; i < j; i, j = i+1, j-1 {
+		recent[i], recent[j] = recent[j], recent[i]
+	}
+
+	return recent
+}
+
+// appendTranscriptionBuffer moves the buffered transcriptions into the conversation as a single
+// user message and clears the buffer. It returns false if nothing was left after filtering.
+func (cm *ConversationManager) appendTranscriptionBuffer() bool {
+	lines := cm.bufferedLines()
+
+	dropped := len(cm.transcriptionBuf) - len(lines)
+	if dropped > 0 && cm.debug.Load() {
+		log.Printf("[CLAUDE] Dropped %d of %d buffered transcriptions as filler or low confidence",
+			dropped, len(cm.transcriptionBuf))
+	}
+
+	cm.transcriptionBuf = cm.transcriptionBuf[:0]
+	if len(lines) == 0 {
+		return false
+	}
+
+	cm.messages = append(cm.messages, cm.newMessage("user", strings.Join(lines, "\n")))
+	return true
+}
+
+// bufferedLines returns the lines the buffered transcriptions would add to the conversation,
+// without filler or low-confidence ones. The caller must hold the mutex.
+func (cm *ConversationManager) bufferedLines() []string {
+	lines := make([]string, 0, len(cm.transcriptionBuf))
+	for _, t := range cm.transcriptionBuf {
+		if cm.shouldDropTranscription(t) {
+			continue
+		}
+		if cm.isLowConfidence(t) {
+			t.Text += " " + cm.bufferOptions.LowConfidenceMarker
+		}
+		lines = append(lines, formatTranscriptionLine(t))
+	}
+	return lines
+}
+
+// apiMessages returns the conversation as sent to the API, without system messages.
+// The caller must hold the mutex.
+func (cm *ConversationManager) apiMessages() []Message {
+	messages := make([]Message, 0, len(cm.messages))
+	for _, msg := range cm.messages {
+		if msg.Role != "system" {
+			messages = append(messages, msg)
+		}
+	}
+	return messages
+}
+
+// insertAfter adds msg right after anchor, the message it responds to. History can change
+// while the lock is released for a request (transcriptions flushed, another answer added, or
+// the conversation cleared or trimmed), so it returns false if anchor is no longer there.
+// The caller must hold the mutex.
+func (cm *ConversationManager) insertAfter(anchor, msg Message) bool {
+	i := cm.lastIndexOf(anchor)
+	if i < 0 {
+		return false
+	}
+	cm.messages = slices.Insert(cm.messages, i+1, msg)
+	return true
+}
+
+// lastIndexOf returns the position of the last message matching msg, or -1 if it's gone.
+// The caller must hold the mutex.
+func (cm *ConversationManager) lastIndexOf(msg Message) int {
+	for i := len(cm.messages) - 1; i >= 0; i-- {
+		existing := cm.messages[i]
+		if existing.Role == msg.Role && existing.Timestamp.Equal(msg.Timestamp) && existing.Text() == msg.Text() {
+			return i
+		}
+	}
+	return -1
+}
+
+// bufferExceedsLimits returns true if the transcription buffer is over its line or character limit
+func (cm *ConversationManager) bufferExceedsLimits() bool {
+	opts := cm.bufferOptions
+	if opts.MaxLines > 0 && len(cm.transcriptionBuf) >= opts.MaxLines {
+		return true
+	}
+
+	if opts.MaxChars > 0 {
+		chars := 0
+		for _, t := range cm.transcriptionBuf {
+			chars += len(t.Text)
+		}
+		if chars >= opts.MaxChars {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isLowConfidence reports whether a transcription should be marked as unreliable
+func (cm *ConversationManager) isLowConfidence(t Transcription) bool {
+	// Zero confidence means the recognizer didn't report one
+	threshold := cm.bufferOptions.LowConfidenceThreshold
+	return threshold > 0 && t.Confidence > 0 && t.Confidence < threshold
+}
+
+// shouldDropTranscription applies the buffer filters to a transcription, counting what it drops
+func (cm *ConversationManager) shouldDropTranscription(t Transcription) bool {
+	// Zero confidence means the recognizer didn't report one
+	if cm.bufferOptions.MinConfidence > 0 && t.Confidence > 0 && t.Confidence < cm.bufferOptions.MinConfidence {
+		cm.bufferStats.LowConfDropped++
+		return true
+	}
+
+	if cm.bufferOptions.FilterFiller && isFiller(t.Text) {
+		cm.bufferStats.FillerDropped++
+		return true
+	}
+
+	return false
+}
+
+// isFiller returns true if the text contains nothing but filler words
+func isFiller(text string) bool {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	for _, word := range words {
+		if !fillerWords[word] {
+			return false
+		}
+	}
+	return true
+}
+
+// withModelNote notes in the reply when it was cut off or came from the fallback model.
+// The note is only added to the returned text, not the stored conversation.
+func withModelNote(response *Response, text string) string {
+	if response.Truncated() {
+		text += "\n\n_(the answer was cut off — use `continue` for the rest)_"
+	}
+	if !response.Fallback {
+		return text
+	}
+	return fmt.Sprintf("%s\n\n_(answered by fallback model %s)_", text, response.Model)
+}
+
+// formatTranscriptionLine formats a transcription the way Claude sees it
+func formatTranscriptionLine(t Transcription) string {
+	if t.Speaker == "" {
+		return fmt.Sprintf("%s%d: %s", transcriptionPrefix, t.SSRC, t.Text)
+	}
+	return fmt.Sprintf("%s%d [%s]: %s", transcriptionPrefix, t.SSRC, t.Speaker, t.Text)
+}
+
+// parseTranscriptionLine parses a line produced by formatTranscriptionLine
+func parseTranscriptionLine(line string) (Transcription, bool) {
+	rest, found := strings.CutPrefix(line, transcriptionPrefix)
+	if !found {
+		return Transcription{}, false
+	}
+
+	// The SSRC is followed by ": <text>" or " [<speaker>]: <text>". Speaker labels may contain
+	// ": " themselves (e.g. a character name), so the label runs to the first "]: ".
+	digits := strings.IndexFunc(rest, func(r rune) bool { return r < '0' || r > '9' })
+	if digits <= 0 {
+		return Transcription{}, false
+	}
+	ssrc, err := strconv.ParseUint(rest[:digits], 10, 32)
+	if err != nil {
+		return Transcription{}, false
+	}
+	rest = rest[digits:]
+
+	if text, found := strings.CutPrefix(rest, ": "); found {
+		return Transcription{SSRC: uint32(ssrc), Text: text}, true
+	}
+
+	label, found := strings.CutPrefix(rest, " [")
+	if !found {
+		return Transcription{}, false
+	}
+	speaker, text, found := strings.Cut(label, "]: ")
+	if !found {
+		return Transcription{}, false
+	}
+	return Transcription{SSRC: uint32(ssrc), Speaker: speaker, Text: text}, true
+}
+
 // trimMessages removes old messages if we exceed the maximum
 func (cm *ConversationManager) trimMessages() {
 	if len(cm.messages) <= cm.maxMessages {
@@ -296,32 +691,71 @@ func (cm *ConversationManager) trimMessages() {
 	keepCount := cm.maxMessages * 3 / 4 // Keep 75% when trimming
 	startIndex := len(cm.messages) - keepCount
 
-	cm.messages = cm.messages[startIndex:]
+	// Summarizing keeps the gist of what's dropped; if it can't be done right now, trim.
+	// A summary already in progress makes room when it's done.
+	if cm.compactStrategy == CompactSummarize && (cm.compacting || cm.startSummary(startIndex)) {
+		return
+	}
+
+	// Hold on to the last summary so trimming doesn't lose everything before it
+	if summary := cm.messages[0]; cm.compactStrategy == CompactSummarize && isSummary(summary) && startIndex < len(cm.messages) {
+		cm.messages = append([]Message{summary}, cm.messages[startIndex+1:]...)
+	} else {
+		cm.messages = cm.messages[startIndex:]
+	}
 
-	if cm.debug {
+	if cm.debug.Load() {
 		log.Printf("[CLAUDE] Trimmed conversation to %d messages", len(cm.messages))
 	}
 }
 
-// saveToDisk saves the conversation to disk
+// IsEphemeral returns true if the conversation is kept in memory only
+func (cm *ConversationManager) IsEphemeral() bool {
+	return cm.filePath == ""
+}
+
+// saveToDisk saves the conversation to disk, or marks it for the next periodic save if a
+// save interval is set. The caller must hold the mutex.
 func (cm *ConversationManager) saveToDisk() error {
+	if cm.IsEphemeral() {
+		return nil
+	}
+	if cm.saveInterval > 0 {
+		cm.dirty = true
+		return nil
+	}
+	return cm.writeToDisk()
+}
+
+// writeToDisk writes the conversation file now. The caller must hold the mutex.
+func (cm *ConversationManager) writeToDisk() error {
+	if cm.IsEphemeral() {
+		return nil
+	}
+
 	data := ConversationData{
 		SystemPrompt: cm.systemPrompt,
+		Campaign:     cm.campaign,
 		Messages:     cm.messages,
-		LastSaved:    time.Now(),
+		Pins:         cm.pins,
+		LastSaved:    cm.clock.Now(),
 		Version:      conversationVersion,
 	}
+	if cm.maxMessagesSet {
+		data.MaxMessages = cm.maxMessages
+	}
 
 	jsonData, err := json.MarshalIndent(data, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal conversation data: %w", err)
 	}
 
-	if err := os.WriteFile(cm.filePath, jsonData, 0644); err != nil {
+	if err := writeFileAtomic(cm.filePath, jsonData); err != nil {
 		return fmt.Errorf("failed to write conversation file: %w", err)
 	}
+	cm.dirty = false
 
-	if cm.debug {
+	if cm.debug.Load() {
 		log.Printf("[CLAUDE] Saved conversation to %s (%d messages)", cm.filePath, len(cm.messages))
 	}
 
@@ -330,6 +764,10 @@ func (cm *ConversationManager) saveToDisk() error {
 
 // loadFromDisk loads the conversation from disk
 func (cm *ConversationManager) loadFromDisk() error {
+	if cm.IsEphemeral() {
+		return fmt.Errorf("conversation is in-memory only")
+	}
+
 	data, err := os.ReadFile(cm.filePath)
 	if err != nil {
 		return fmt.Errorf("failed to read conversation file: %w", err)
@@ -340,12 +778,11 @@ func (cm *ConversationManager) loadFromDisk() error {
 		return fmt.Errorf("failed to unmarshal conversation data: %w", err)
 	}
 
-	// Validate version compatibility
-	if conversationData.Version != conversationVersion {
-		if cm.debug {
-			log.Printf("[CLAUDE] ⚠️ Conversation file version mismatch (file: %s, current: %s)",
-				conversationData.Version, conversationVersion)
-		}
+	// Upgrade files written by older versions
+	oldVersion := conversationData.Version
+	migrated, err := migrateConversation(&conversationData)
+	if err != nil {
+		return err
 	}
 
 	cm.systemPrompt = conversationData.SystemPrompt
@@ -353,15 +790,62 @@ func (cm *ConversationManager) loadFromDisk() error {
 		cm.systemPrompt = defaultSystemPrompt
 	}
 
+	cm.campaign = conversationData.Campaign
+
 	cm.messages = conversationData.Messages
 	if cm.messages == nil {
 		cm.messages = make([]Message, 0)
 	}
 
-	if cm.debug {
+	cm.pins = conversationData.Pins
+
+	// A limit changed at runtime overrides the configured one
+	if conversationData.MaxMessages > 0 {
+		cm.maxMessages = conversationData.MaxMessages
+		cm.maxMessagesSet = true
+	}
+
+	if cm.debug.Load() {
 		log.Printf("[CLAUDE] Loaded conversation from %s (%d messages, last saved: %s)",
 			cm.filePath, len(cm.messages), conversationData.LastSaved.Format(time.RFC3339))
 	}
 
+	if migrated {
+		// Keep the original in case the migration got something wrong
+		backupPath := fmt.Sprintf("%s.v%s.bak", cm.filePath, versionLabel(oldVersion))
+		if err := os.WriteFile(backupPath, data, 0644); err != nil {
+			return fmt.Errorf("failed to back up conversation file before migrating: %w", err)
+		}
+		if err := cm.saveToDisk(); err != nil {
+			return fmt.Errorf("failed to write migrated conversation file: %w", err)
+		}
+		log.Printf("[CLAUDE] Migrated %s from version %s to %s (original kept as %s)",
+			cm.filePath, versionLabel(oldVersion), conversationVersion, backupPath)
+	}
+
+	return nil
+}
+
+// SystemPrompt returns the system prompt sent with every request
+func (cm *ConversationManager) SystemPrompt() string {
+	cm.mutex.RLock()
+	defer cm.mutex.RUnlock()
+	return cm.systemPrompt
+}
+
+// SetSystemPrompt replaces the system prompt and saves it with the conversation.
+// An empty prompt restores the default.
+func (cm *ConversationManager) SetSystemPrompt(prompt string) error {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	cm.systemPrompt = strings.TrimSpace(prompt)
+	if cm.systemPrompt == "" {
+		cm.systemPrompt = defaultSystemPrompt
+	}
+
+	if err := cm.saveToDisk(); err != nil {
+		return fmt.Errorf("failed to save system prompt: %w", err)
+	}
 	return nil
 }
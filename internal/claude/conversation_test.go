@@ -0,0 +1,213 @@
+package claude
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAskQuestionFlushesPendingTranscriptions(t *testing.T) {
+	fake := &fakeSender{}
+	cm := newTestConversation(fake, 50)
+
+	cm.AddTranscription(1, "DM", "You enter the tavern.", 0.9)
+	cm.AddTranscription(2, "PLAYER Alice", "I look for the innkeeper.", 0.9)
+
+	answer, err := cm.AskQuestion("Who runs the tavern?")
+	if err != nil {
+		t.Fatalf("AskQuestion: %v", err)
+	}
+	if answer != "ok" {
+		t.Errorf("answer = %q, want %q", answer, "ok")
+	}
+
+	sent := fake.lastRequest()
+	if len(sent) != 2 {
+		t.Fatalf("sent %d messages, want the transcriptions and the question", len(sent))
+	}
+	transcript := sent[0].Text()
+	for _, want := range []string{"[TRANSCRIPTION] SSRC 1 [DM]: You enter the tavern.", "[TRANSCRIPTION] SSRC 2 [PLAYER Alice]: I look for the innkeeper."} {
+		if !strings.Contains(transcript, want) {
+			t.Errorf("flushed message %q is missing %q", transcript, want)
+		}
+	}
+	if sent[1].Text() != "Who runs the tavern?" {
+		t.Errorf("last message sent = %q, want the question", sent[1].Text())
+	}
+
+	if cm.HasPendingTranscriptions() {
+		t.Error("transcriptions still pending after the question")
+	}
+	if got := len(messagesOf(cm)); got != 3 {
+		t.Errorf("conversation has %d messages, want transcriptions, question and answer", got)
+	}
+}
+
+func TestAskQuestionPropagatesErrors(t *testing.T) {
+	fake := &fakeSender{reply: func([]Message, string) (*Response, error) {
+		return nil, fmt.Errorf("sending: %w", &APIError{StatusCode: 429, Type: "rate_limit_error", Message: "slow down"})
+	}}
+	cm := newTestConversation(fake, 50)
+
+	_, err := cm.AskQuestion("Can I cast two spells?")
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("error = %v, want one matching ErrRateLimited", err)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 429 {
+		t.Errorf("error = %v, want the APIError with status 429", err)
+	}
+
+	// The question stays, but no answer is recorded
+	messages := messagesOf(cm)
+	if len(messages) != 1 || messages[0].Role != "user" {
+		t.Errorf("conversation = %+v, want only the question", messages)
+	}
+}
+
+func TestAskQuestionRejectsEmptyResponse(t *testing.T) {
+	fake := &fakeSender{reply: func([]Message, string) (*Response, error) {
+		return &Response{}, nil
+	}}
+	cm := newTestConversation(fake, 50)
+
+	if _, err := cm.AskQuestion("Anything?"); err == nil {
+		t.Fatal("AskQuestion returned no error for an empty response")
+	}
+}
+
+func TestFlushTranscriptionsAndRespondPropagatesErrors(t *testing.T) {
+	fake := &fakeSender{reply: func([]Message, string) (*Response, error) {
+		return nil, &APIError{StatusCode: 401, Type: "authentication_error"}
+	}}
+	cm := newTestConversation(fake, 50)
+	cm.AddTranscription(1, "DM", "Roll initiative.", 0.9)
+
+	_, err := cm.FlushTranscriptionsAndRespond()
+	if !errors.Is(err, ErrAuth) {
+		t.Fatalf("error = %v, want one matching ErrAuth", err)
+	}
+
+	// The transcriptions were flushed even though Claude didn't answer
+	if cm.HasPendingTranscriptions() {
+		t.Error("transcriptions still pending after a failed flush")
+	}
+	if got := len(messagesOf(cm)); got != 1 {
+		t.Errorf("conversation has %d messages, want the flushed transcriptions", got)
+	}
+}
+
+func TestFlushTranscriptionsAndRespondWithNothingPending(t *testing.T) {
+	fake := &fakeSender{}
+	cm := newTestConversation(fake, 50)
+
+	response, err := cm.FlushTranscriptionsAndRespond()
+	if err != nil || response != "" {
+		t.Fatalf("got (%q, %v), want no response and no error", response, err)
+	}
+	if fake.calls() != 0 {
+		t.Errorf("sent %d requests with nothing to flush", fake.calls())
+	}
+}
+
+func TestTrimMessagesKeepsMostRecent(t *testing.T) {
+	cm := newTestConversation(&fakeSender{}, 8)
+
+	for i := 1; i <= 9; i++ {
+		cm.AddTranscription(1, "DM", fmt.Sprintf("line %d", i), 0.9)
+		cm.FlushTranscriptions()
+	}
+
+	// Going over the limit of 8 keeps the newest three quarters
+	messages := messagesOf(cm)
+	if len(messages) != 6 {
+		t.Fatalf("conversation has %d messages after trimming, want 6", len(messages))
+	}
+	if first := messages[0].Text(); !strings.HasSuffix(first, "line 4") {
+		t.Errorf("oldest message kept = %q, want line 4", first)
+	}
+	if last := messages[len(messages)-1].Text(); !strings.HasSuffix(last, "line 9") {
+		t.Errorf("newest message = %q, want line 9", last)
+	}
+}
+
+func TestTrimMessagesUnderLimit(t *testing.T) {
+	cm := newTestConversation(&fakeSender{}, 8)
+
+	for i := 1; i <= 8; i++ {
+		cm.AddTranscription(1, "DM", fmt.Sprintf("line %d", i), 0.9)
+		cm.FlushTranscriptions()
+	}
+
+	if got := len(messagesOf(cm)); got != 8 {
+		t.Errorf("conversation has %d messages at the limit, want all 8", got)
+	}
+}
+
+func TestMessagesAreTimestampedByTheClock(t *testing.T) {
+	cm := newTestConversation(&fakeSender{}, 50)
+
+	if _, err := cm.AskQuestion("Who runs the tavern?"); err != nil {
+		t.Fatalf("AskQuestion: %v", err)
+	}
+
+	messages := messagesOf(cm)
+	if len(messages) != 2 {
+		t.Fatalf("got %d messages, want the question and the answer", len(messages))
+	}
+	start := time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC)
+	if !messages[0].Timestamp.After(start) || !messages[1].Timestamp.After(messages[0].Timestamp) {
+		t.Errorf("timestamps %v and %v don't come from the test clock", messages[0].Timestamp, messages[1].Timestamp)
+	}
+}
+
+func TestIsFiller(t *testing.T) {
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"um", true},
+		{"Uh, erm... hmm.", true},
+		{"mm mhm", true},
+		{"yes", false},
+		{"no", false},
+		{"okay", false},
+		{"um, I attack the goblin", false},
+	}
+	for _, tt := range tests {
+		if got := isFiller(tt.text); got != tt.want {
+			t.Errorf("isFiller(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestParseTranscriptionLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want Transcription
+	}{
+		{"[TRANSCRIPTION] SSRC 12: I open the door", Transcription{SSRC: 12, Text: "I open the door"}},
+		{"[TRANSCRIPTION] SSRC 12 [DM]: Roll for initiative", Transcription{SSRC: 12, Speaker: "DM", Text: "Roll for initiative"}},
+		{"[TRANSCRIPTION] SSRC 7 [PLAYER Sir Reginald: the Bold]: I charge", Transcription{SSRC: 7, Speaker: "PLAYER Sir Reginald: the Bold", Text: "I charge"}},
+		{"[TRANSCRIPTION] SSRC 7 [PLAYER Alice]: She said: run!", Transcription{SSRC: 7, Speaker: "PLAYER Alice", Text: "She said: run!"}},
+	}
+	for _, tt := range tests {
+		got, ok := parseTranscriptionLine(tt.line)
+		if !ok || got != tt.want {
+			t.Errorf("parseTranscriptionLine(%q) = %+v, %v; want %+v", tt.line, got, ok, tt.want)
+		}
+
+		// Lines round-trip through formatTranscriptionLine
+		if line := formatTranscriptionLine(tt.want); line != tt.line {
+			t.Errorf("formatTranscriptionLine(%+v) = %q, want %q", tt.want, line, tt.line)
+		}
+	}
+
+	for _, line := range []string{"", "Roll for initiative", "[TRANSCRIPTION] SSRC x: hi", "[TRANSCRIPTION] SSRC 12 DM: hi", "[TRANSCRIPTION] SSRC 12 [DM] hi"} {
+		if got, ok := parseTranscriptionLine(line); ok {
+			t.Errorf("parseTranscriptionLine(%q) = %+v, want no match", line, got)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package claude
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Sentinel errors for the broad classes of Claude API failures.
+// Use errors.Is against an error returned by SendMessage to check for them.
+var (
+	ErrRateLimited = errors.New("claude API rate limited")
+	ErrAuth        = errors.New("claude API authentication failed")
+	ErrBadRequest  = errors.New("claude API rejected the request")
+	ErrOverloaded  = errors.New("claude API overloaded")
+	ErrServer      = errors.New("claude API server error")
+)
+
+// APIError is returned by SendMessage when the Claude API responds with a non-200 status
+type APIError struct {
+	StatusCode int
+	Type       string // Error type from the response body, e.g. "rate_limit_error"
+	Message    string
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	if e.Type == "" {
+		return fmt.Sprintf("API error (status %d): %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("API error (status %d): %s - %s", e.StatusCode, e.Type, e.Message)
+}
+
+// Unwrap maps the API error onto one of the sentinel errors so callers can use errors.Is
+func (e *APIError) Unwrap() error {
+	switch e.Type {
+	case "rate_limit_error":
+		return ErrRateLimited
+	case "authentication_error", "permission_error":
+		return ErrAuth
+	case "invalid_request_error", "not_found_error", "request_too_large":
+		return ErrBadRequest
+	case "overloaded_error":
+		return ErrOverloaded
+	case "api_error":
+		return ErrServer
+	}
+
+	// Fall back to the status code when the body had no recognizable type
+	switch {
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden:
+		return ErrAuth
+	case e.StatusCode == 529:
+		return ErrOverloaded
+	case e.StatusCode >= 500:
+		return ErrServer
+	case e.StatusCode >= 400:
+		return ErrBadRequest
+	}
+	return nil
+}
+
+// IsRetryable returns true if the request may succeed if sent again later
+func (e *APIError) IsRetryable() bool {
+	err := e.Unwrap()
+	return err == ErrRateLimited || err == ErrOverloaded || err == ErrServer
+}
+
+// parseAPIError builds an APIError from a non-200 response body
+func parseAPIError(statusCode int, body []byte) *APIError {
+	apiErr := &APIError{StatusCode: statusCode}
+
+	var errorResp ErrorResponse
+	if err := json.Unmarshal(body, &errorResp); err != nil || errorResp.Error.Type == "" {
+		apiErr.Message = string(body)
+		return apiErr
+	}
+
+	apiErr.Type = errorResp.Error.Type
+	apiErr.Message = errorResp.Error.Message
+	return apiErr
+}
@@ -0,0 +1,120 @@
+package claude
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestParseAPIError(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		wantType   string
+		wantMsg    string
+		want       error
+		retryable  bool
+	}{
+		{
+			name:       "rate limited",
+			statusCode: 429,
+			body:       `{"type":"error","error":{"type":"rate_limit_error","message":"Number of request tokens has exceeded your per-minute rate limit"}}`,
+			wantType:   "rate_limit_error",
+			wantMsg:    "Number of request tokens has exceeded your per-minute rate limit",
+			want:       ErrRateLimited,
+			retryable:  true,
+		},
+		{
+			name:       "bad API key",
+			statusCode: 401,
+			body:       `{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`,
+			wantType:   "authentication_error",
+			wantMsg:    "invalid x-api-key",
+			want:       ErrAuth,
+		},
+		{
+			name:       "no access to the model",
+			statusCode: 403,
+			body:       `{"type":"error","error":{"type":"permission_error","message":"Your API key does not have permission to use the specified resource."}}`,
+			wantType:   "permission_error",
+			wantMsg:    "Your API key does not have permission to use the specified resource.",
+			want:       ErrAuth,
+		},
+		{
+			name:       "invalid request",
+			statusCode: 400,
+			body:       `{"type":"error","error":{"type":"invalid_request_error","message":"messages: roles must alternate"}}`,
+			wantType:   "invalid_request_error",
+			wantMsg:    "messages: roles must alternate",
+			want:       ErrBadRequest,
+		},
+		{
+			name:       "overloaded",
+			statusCode: 529,
+			body:       `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`,
+			wantType:   "overloaded_error",
+			wantMsg:    "Overloaded",
+			want:       ErrOverloaded,
+			retryable:  true,
+		},
+		{
+			name:       "internal error",
+			statusCode: 500,
+			body:       `{"type":"error","error":{"type":"api_error","message":"Internal server error"}}`,
+			wantType:   "api_error",
+			wantMsg:    "Internal server error",
+			want:       ErrServer,
+			retryable:  true,
+		},
+		{
+			name:       "proxy page falls back to the status code",
+			statusCode: 502,
+			body:       "<html><body>Bad Gateway</body></html>",
+			wantMsg:    "<html><body>Bad Gateway</body></html>",
+			want:       ErrServer,
+			retryable:  true,
+		},
+		{
+			name:       "unknown type falls back to the status code",
+			statusCode: 429,
+			body:       `{"type":"error","error":{"type":"slow_down_error","message":"slow down"}}`,
+			wantType:   "slow_down_error",
+			wantMsg:    "slow down",
+			want:       ErrRateLimited,
+			retryable:  true,
+		},
+		{
+			name:       "empty body",
+			statusCode: 404,
+			want:       ErrBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiErr := parseAPIError(tt.statusCode, []byte(tt.body))
+			if apiErr.StatusCode != tt.statusCode || apiErr.Type != tt.wantType || apiErr.Message != tt.wantMsg {
+				t.Errorf("parsed %+v, want status %d, type %q, message %q", apiErr, tt.statusCode, tt.wantType, tt.wantMsg)
+			}
+			if !errors.Is(apiErr, tt.want) {
+				t.Errorf("errors.Is(%v, %v) = false", apiErr, tt.want)
+			}
+			if apiErr.IsRetryable() != tt.retryable {
+				t.Errorf("IsRetryable() = %v, want %v", apiErr.IsRetryable(), tt.retryable)
+			}
+		})
+	}
+}
+
+func TestAPIErrorAsFromWrappedError(t *testing.T) {
+	err := fmt.Errorf("asking Claude: %w", parseAPIError(429, []byte(`{"type":"error","error":{"type":"rate_limit_error","message":"slow down"}}`)))
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 429 {
+		t.Fatalf("errors.As didn't find the API error in %v", err)
+	}
+	if !errors.Is(err, ErrRateLimited) {
+		t.Error("wrapped API error isn't ErrRateLimited")
+	}
+}
@@ -0,0 +1,165 @@
+package claude
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Exchange is the raw body of one API request and the response to it. Credentials travel in
+// headers, so they are never part of it.
+type Exchange struct {
+	Time       time.Time
+	Backend    string // Endpoint the request went to
+	Request    []byte
+	StatusCode int // Zero if no response arrived
+	Response   []byte
+}
+
+// exchangeLog keeps the most recent exchange while debug mode is on, and appends every
+// exchange to a log file if one is set
+type exchangeLog struct {
+	last atomic.Pointer[Exchange]
+	file *exchangeFile
+}
+
+// record stores an exchange as the most recent one in debug mode and writes it to the log file
+func (l *exchangeLog) record(debug bool, backend string, request []byte, statusCode int, response []byte) {
+	exchange := &Exchange{
+		Time:       time.Now(),
+		Backend:    backend,
+		Request:    request,
+		StatusCode: statusCode,
+		Response:   response,
+	}
+	if debug {
+		l.last.Store(exchange)
+	}
+	if l.file != nil {
+		if err := l.file.write(exchange); err != nil {
+			log.Printf("[CLAUDE] ⚠️ Failed to write to the exchange log: %v", err)
+		}
+	}
+}
+
+// LastExchange returns the most recent exchange recorded in debug mode
+func (l *exchangeLog) LastExchange() (Exchange, bool) {
+	exchange := l.last.Load()
+	if exchange == nil {
+		return Exchange{}, false
+	}
+	return *exchange, true
+}
+
+// exchangeFile appends exchanges to a JSON lines file, moving it to <path>.1 once it would
+// grow past maxBytes (0 = never)
+type exchangeFile struct {
+	path     string
+	maxBytes int64
+	secret   string // Scrubbed from everything written, in case a server echoes it back
+	mutex    sync.Mutex
+}
+
+// exchangeLine is one exchange as written to the log file
+type exchangeLine struct {
+	Time         time.Time       `json:"time"`
+	Backend      string          `json:"backend"`
+	StatusCode   int             `json:"status_code"`
+	InputTokens  int             `json:"input_tokens,omitempty"`
+	OutputTokens int             `json:"output_tokens,omitempty"`
+	Request      json.RawMessage `json:"request"`
+	Response     json.RawMessage `json:"response,omitempty"`
+}
+
+// tokenUsage covers the usage fields of both the Anthropic and OpenAI responses
+type tokenUsage struct {
+	Usage struct {
+		InputTokens      int `json:"input_tokens"`
+		OutputTokens     int `json:"output_tokens"`
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// newExchangeFile returns a log file writer, or nil if no path is set
+func newExchangeFile(path string, maxBytes int64, secret string) *exchangeFile {
+	if path == "" {
+		return nil
+	}
+	return &exchangeFile{path: path, maxBytes: maxBytes, secret: secret}
+}
+
+// write appends one exchange. Writes are serialized so concurrent requests don't interleave.
+func (f *exchangeFile) write(exchange *Exchange) error {
+	line := exchangeLine{
+		Time:       exchange.Time,
+		Backend:    exchange.Backend,
+		StatusCode: exchange.StatusCode,
+		Request:    f.rawJSON(exchange.Request),
+	}
+	if len(exchange.Response) > 0 {
+		line.Response = f.rawJSON(exchange.Response)
+
+		var usage tokenUsage
+		if json.Unmarshal(exchange.Response, &usage) == nil {
+			line.InputTokens = usage.Usage.InputTokens + usage.Usage.PromptTokens
+			line.OutputTokens = usage.Usage.OutputTokens + usage.Usage.CompletionTokens
+		}
+	}
+
+	data, err := json.Marshal(line)
+	if err != nil {
+		return fmt.Errorf("failed to marshal exchange: %w", err)
+	}
+	data = append(data, '\n')
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if err := f.rotate(int64(len(data))); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(data)
+	return err
+}
+
+// rotate moves the log aside if adding size bytes would take it over the cap. The caller
+// must hold the mutex.
+func (f *exchangeFile) rotate(size int64) error {
+	if f.maxBytes <= 0 {
+		return nil
+	}
+	info, err := os.Stat(f.path)
+	if err != nil || info.Size()+size <= f.maxBytes {
+		return nil
+	}
+	if err := os.Rename(f.path, f.path+".1"); err != nil {
+		return fmt.Errorf("failed to rotate %s: %w", f.path, err)
+	}
+	return nil
+}
+
+// rawJSON returns a body with the secret scrubbed, as JSON; bodies that aren't JSON (e.g. an
+// HTML error page) are written as a string
+func (f *exchangeFile) rawJSON(body []byte) json.RawMessage {
+	if f.secret != "" {
+		body = bytes.ReplaceAll(body, []byte(f.secret), []byte("[REDACTED]"))
+	}
+	if json.Valid(body) {
+		return body
+	}
+	quoted, _ := json.Marshal(string(body))
+	return quoted
+}
@@ -0,0 +1,88 @@
+package claude
+
+import (
+	"sync"
+	"time"
+)
+
+// fakeSender is a MessageSender that returns canned responses instead of calling the API.
+// Each request is recorded so tests can check what was sent.
+type fakeSender struct {
+	mutex sync.Mutex
+
+	// reply returns the response to a request; by default every request gets "ok"
+	reply func(messages []Message, systemPrompt string) (*Response, error)
+
+	requests [][]Message
+	prompts  []string
+}
+
+// SendMessage records the request and returns the canned response
+func (f *fakeSender) SendMessage(messages []Message, systemPrompt string) (*Response, error) {
+	f.mutex.Lock()
+	f.requests = append(f.requests, append([]Message(nil), messages...))
+	f.prompts = append(f.prompts, systemPrompt)
+	reply := f.reply
+	f.mutex.Unlock()
+
+	if reply == nil {
+		return textResponse("ok"), nil
+	}
+	return reply(messages, systemPrompt)
+}
+
+// calls returns the number of requests sent so far
+func (f *fakeSender) calls() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return len(f.requests)
+}
+
+// lastRequest returns the messages of the most recent request
+func (f *fakeSender) lastRequest() []Message {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if len(f.requests) == 0 {
+		return nil
+	}
+	return f.requests[len(f.requests)-1]
+}
+
+// textResponse builds an API response holding a single text block
+func textResponse(text string) *Response {
+	response := &Response{Role: "assistant", StopReason: "end_turn"}
+	response.Content = append(response.Content, struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}{Type: "text", Text: text})
+	return response
+}
+
+// stepClock is a clock that moves forward a second every time it's read, so each message
+// gets its own timestamp
+type stepClock struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+// Now returns the current fake time and advances it
+func (c *stepClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(time.Second)
+	return c.now
+}
+
+// newTestConversation creates an in-memory conversation that sends to fake
+func newTestConversation(fake *fakeSender, maxMessages int) *ConversationManager {
+	cm := NewConversationManager(fake, "", maxMessages, false)
+	cm.SetClock(&stepClock{now: time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC)})
+	return cm
+}
+
+// messagesOf returns a copy of the conversation's messages
+func messagesOf(cm *ConversationManager) []Message {
+	cm.mutex.RLock()
+	defer cm.mutex.RUnlock()
+	return append([]Message(nil), cm.messages...)
+}
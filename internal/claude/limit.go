@@ -0,0 +1,61 @@
+package claude
+
+import "log"
+
+// limitedBackend bounds how many requests to a backend are in flight at once. Requests over
+// the limit wait for a slot in the order they arrived.
+type limitedBackend struct {
+	Backend
+	slots chan struct{}
+}
+
+var (
+	_ Backend       = (*limitedBackend)(nil)
+	_ limitedSender = (*limitedBackend)(nil)
+)
+
+// LimitConcurrency wraps a backend so at most max requests run at once. Every conversation
+// sharing the returned backend shares the limit.
+func LimitConcurrency(backend Backend, max int) Backend {
+	if max < 1 {
+		max = 1
+	}
+	return &limitedBackend{Backend: backend, slots: make(chan struct{}, max)}
+}
+
+// SendMessage sends the request once a slot is free
+func (l *limitedBackend) SendMessage(messages []Message, systemPrompt string) (*Response, error) {
+	l.acquire()
+	defer l.release()
+	return l.Backend.SendMessage(messages, systemPrompt)
+}
+
+// SendMessageWithLimit sends the request with a cap on the response length once a slot is
+// free, or without the cap if the backend can't change it
+func (l *limitedBackend) SendMessageWithLimit(messages []Message, systemPrompt string, maxTokens int) (*Response, error) {
+	sender, ok := l.Backend.(limitedSender)
+	if !ok {
+		return l.SendMessage(messages, systemPrompt)
+	}
+
+	l.acquire()
+	defer l.release()
+	return sender.SendMessageWithLimit(messages, systemPrompt, maxTokens)
+}
+
+// acquire waits for a free request slot
+func (l *limitedBackend) acquire() {
+	select {
+	case l.slots <- struct{}{}:
+		return
+	default:
+	}
+
+	log.Printf("[CLAUDE] ⏳ %d request(s) already in flight, queuing this one", cap(l.slots))
+	l.slots <- struct{}{}
+}
+
+// release frees a request slot
+func (l *limitedBackend) release() {
+	<-l.slots
+}
@@ -0,0 +1,139 @@
+package claude
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeBackend is a fakeSender with the rest of the Backend methods
+type fakeBackend struct {
+	fakeSender
+}
+
+func (f *fakeBackend) SetDebug(bool) {}
+
+func (f *fakeBackend) LastExchange() (Exchange, bool) { return Exchange{}, false }
+
+// blockingBackend returns a backend whose requests wait for release, counting how many are in
+// flight at once. Each request signals started as it begins.
+func blockingBackend(release <-chan struct{}, started chan<- struct{}) (*fakeBackend, func() int) {
+	var mutex sync.Mutex
+	inFlight, maxInFlight := 0, 0
+
+	backend := &fakeBackend{}
+	backend.reply = func([]Message, string) (*Response, error) {
+		mutex.Lock()
+		inFlight++
+		maxInFlight = max(maxInFlight, inFlight)
+		mutex.Unlock()
+
+		started <- struct{}{}
+		<-release
+
+		mutex.Lock()
+		inFlight--
+		mutex.Unlock()
+		return textResponse("Roll for initiative."), nil
+	}
+	return backend, func() int {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return maxInFlight
+	}
+}
+
+func TestLimitConcurrencyBoundsConcurrentAsks(t *testing.T) {
+	for _, limit := range []int{1, 2} {
+		const asks = 5
+		release := make(chan struct{})
+		started := make(chan struct{}, asks)
+		backend, maxInFlight := blockingBackend(release, started)
+
+		// Several conversations share the limited backend, as guilds with their own do
+		limited := LimitConcurrency(backend, limit)
+		var wg sync.WaitGroup
+		for range asks {
+			cm := NewConversationManager(limited, "", 50, false)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := cm.AskQuestion("What happens next?"); err != nil {
+					t.Errorf("AskQuestion: %v", err)
+				}
+			}()
+		}
+
+		for range limit {
+			<-started
+		}
+		select {
+		case <-started:
+			t.Errorf("limit %d: another request started while %d were in flight", limit, limit)
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		close(release)
+		wg.Wait()
+		if got := maxInFlight(); got != limit {
+			t.Errorf("limit %d: %d requests were in flight at once", limit, got)
+		}
+		if got := backend.calls(); got != asks {
+			t.Errorf("limit %d: %d requests sent, want %d", limit, got, asks)
+		}
+	}
+}
+
+func TestLimitConcurrencyConcurrentAsksOnOneConversation(t *testing.T) {
+	const asks = 4
+	release := make(chan struct{})
+	started := make(chan struct{}, asks)
+	backend, maxInFlight := blockingBackend(release, started)
+
+	cm := NewConversationManager(LimitConcurrency(backend, 1), "", 50, false)
+	var wg sync.WaitGroup
+	for range asks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := cm.AskQuestion("Can I cast Shield?"); err != nil {
+				t.Errorf("AskQuestion: %v", err)
+			}
+		}()
+	}
+
+	<-started
+	select {
+	case <-started:
+		t.Error("a second request started while the first was in flight")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// The conversation isn't locked while its requests wait, so it can still be read
+	read := make(chan struct{})
+	go func() {
+		cm.PromptPreview()
+		close(read)
+	}()
+	select {
+	case <-read:
+	case <-time.After(time.Second):
+		t.Fatal("conversation stayed locked while its requests waited")
+	}
+
+	close(release)
+	wg.Wait()
+	if got := maxInFlight(); got != 1 {
+		t.Errorf("%d requests were in flight at once, want 1", got)
+	}
+	if got := len(messagesOf(cm)); got != 2*asks {
+		t.Errorf("conversation has %d messages, want a question and answer per ask", got)
+	}
+}
+
+func TestLimitConcurrencyAtLeastOne(t *testing.T) {
+	limited := LimitConcurrency(&fakeBackend{}, 0).(*limitedBackend)
+	if cap(limited.slots) != 1 {
+		t.Errorf("limit of 0 allows %d requests, want 1", cap(limited.slots))
+	}
+}
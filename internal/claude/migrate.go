@@ -0,0 +1,102 @@
+package claude
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrUnknownConversationVersion is returned when the conversation file was written by a newer
+// version of the bot, or has a version that can't be read. It isn't loaded, so it can't be
+// overwritten with an older format.
+var ErrUnknownConversationVersion = errors.New("conversation file version is newer than supported or unrecognized")
+
+// migrateConversation upgrades conversation data written by older versions to the current
+// format. It returns true if the data changed and should be written back.
+func migrateConversation(data *ConversationData) (bool, error) {
+	cmp, err := compareVersions(data.Version, conversationVersion)
+	if err != nil || cmp > 0 {
+		return false, fmt.Errorf("%w (file: %q, current: %s)", ErrUnknownConversationVersion, data.Version, conversationVersion)
+	}
+	if cmp == 0 {
+		return false, nil
+	}
+
+	// Files before 1.0 (including ones with no version at all)
+	migrateToV1(data)
+	data.Version = conversationVersion
+	return true, nil
+}
+
+// migrateToV1 upgrades pre-1.0 data. Those files could store the system prompt as a
+// "system" message, keep content as a list of blocks, and omit message timestamps.
+func migrateToV1(data *ConversationData) {
+	messages := make([]Message, 0, len(data.Messages))
+	for _, msg := range data.Messages {
+		content := messageText(msg.Content)
+
+		if msg.Role == "system" {
+			if data.SystemPrompt == "" {
+				data.SystemPrompt = content
+			}
+			continue
+		}
+
+		msg.Content = content
+		if msg.Timestamp.IsZero() {
+			msg.Timestamp = data.LastSaved
+		}
+		messages = append(messages, msg)
+	}
+	data.Messages = messages
+}
+
+// compareVersions compares two "major.minor" versions, returning -1, 0 or 1.
+// An empty version is the oldest possible.
+func compareVersions(a, b string) (int, error) {
+	va, err := parseVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := parseVersion(b)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range va {
+		switch {
+		case va[i] < vb[i]:
+			return -1, nil
+		case va[i] > vb[i]:
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+// versionLabel returns a version for display, naming files that predate versioning
+func versionLabel(version string) string {
+	if version == "" {
+		return "0"
+	}
+	return version
+}
+
+// parseVersion parses a "major.minor" version; a missing minor number is 0
+func parseVersion(version string) ([2]int, error) {
+	var parsed [2]int
+	if version == "" {
+		return parsed, nil
+	}
+
+	parts := strings.SplitN(version, ".", 2)
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return parsed, fmt.Errorf("invalid conversation file version %q", version)
+		}
+		parsed[i] = n
+	}
+	return parsed, nil
+}
@@ -0,0 +1,133 @@
+package claude
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// copySample copies a conversation file from testdata into a temporary directory
+func copySample(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "dnd_conversation.json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestMigrateV09ConversationFile(t *testing.T) {
+	path := copySample(t, "conversation_v0.9.json")
+	original, _ := os.ReadFile(path)
+
+	cm := NewConversationManager(&fakeSender{}, path, 50, false)
+
+	// The system message becomes the system prompt rather than part of the history
+	if got := cm.systemPrompt; got != "You are the DM's assistant for the Lost Mine of Phandelver." {
+		t.Errorf("system prompt = %q", got)
+	}
+
+	lastSaved := time.Date(2024, 3, 9, 19, 45, 0, 0, time.UTC)
+	want := []struct {
+		role      string
+		text      string
+		timestamp time.Time
+	}{
+		{"user", "[TRANSCRIPTION] SSRC 1234 [DM]: The goblins ambush you on the Triboar Trail.", lastSaved},
+		{"assistant", "Goblins have 7 hit points.\nThey use Nimble Escape to hide as a bonus action.", time.Date(2024, 3, 9, 19, 42, 0, 0, time.UTC)},
+		{"user", "How far can they move?", lastSaved},
+	}
+	messages := messagesOf(cm)
+	if len(messages) != len(want) {
+		t.Fatalf("loaded %d messages, want %d", len(messages), len(want))
+	}
+	for i, w := range want {
+		msg := messages[i]
+		if _, ok := msg.Content.(string); !ok {
+			t.Errorf("message %d content is %T, want a string", i, msg.Content)
+		}
+		if msg.Role != w.role || msg.Text() != w.text || !msg.Timestamp.Equal(w.timestamp) {
+			t.Errorf("message %d = %s %q at %v, want %s %q at %v", i, msg.Role, msg.Text(), msg.Timestamp, w.role, w.text, w.timestamp)
+		}
+	}
+
+	// The file is rewritten in the current format, with the original kept alongside it
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved ConversationData
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatalf("migrated file doesn't parse: %v", err)
+	}
+	if saved.Version != conversationVersion || len(saved.Messages) != len(want) {
+		t.Errorf("migrated file has version %q and %d messages", saved.Version, len(saved.Messages))
+	}
+	backup, err := os.ReadFile(path + ".v0.9.bak")
+	if err != nil {
+		t.Fatalf("no backup of the original: %v", err)
+	}
+	if string(backup) != string(original) {
+		t.Error("backup differs from the original file")
+	}
+
+	// Loading the migrated file again changes nothing
+	if _, err := os.Stat(path + ".v1.0.bak"); err == nil {
+		t.Error("current file was backed up as if migrated")
+	}
+	if again := NewConversationManager(&fakeSender{}, path, 50, false); len(messagesOf(again)) != len(want) {
+		t.Errorf("reloading the migrated file gave %d messages", len(messagesOf(again)))
+	}
+}
+
+func TestNewerConversationFileNotLoaded(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dnd_conversation.json")
+	newer := `{"system_prompt":"From the future","messages":[{"role":"user","content":"hello"}],"version":"2.0"}`
+	if err := os.WriteFile(path, []byte(newer), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cm := NewConversationManager(&fakeSender{}, path, 50, false)
+	if len(messagesOf(cm)) != 0 || cm.systemPrompt == "From the future" {
+		t.Error("a file from a newer version was loaded")
+	}
+	if !cm.IsEphemeral() {
+		t.Error("conversation would overwrite the newer file")
+	}
+
+	cm.AddTranscription(1, "DM", "the tavern is on fire", 0.9)
+	cm.FlushTranscriptions()
+	if data, _ := os.ReadFile(path); string(data) != newer {
+		t.Error("newer file was modified")
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "1.0", -1},
+		{"0.9", "1.0", -1},
+		{"1", "1.0", 0},
+		{"1.0", "1.0", 0},
+		{"1.10", "1.9", 1},
+		{"2.0", "1.0", 1},
+	}
+	for _, tt := range tests {
+		got, err := compareVersions(tt.a, tt.b)
+		if err != nil || got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, %v, want %d", tt.a, tt.b, got, err, tt.want)
+		}
+	}
+
+	if _, err := compareVersions("v1", "1.0"); err == nil {
+		t.Error("compareVersions accepted \"v1\"")
+	}
+}
@@ -0,0 +1,43 @@
+package claude
+
+import (
+	"fmt"
+	"log"
+)
+
+// Recent messages given to Claude as context for a narration
+const narrationContextMessages = 10
+
+const narrationInstruction = `Narrate the outcome of this roll in one vivid sentence that fits what is happening in the session. Don't restate the numbers, and don't decide anything beyond the roll's success or failure.`
+
+// NarrateOutcome asks Claude for a one-sentence narration of a roll's outcome, using the
+// recent conversation as context. Neither the request nor the narration is added to the
+// conversation, so flavor text doesn't pile up in the history.
+func (cm *ConversationManager) NarrateOutcome(outcome string) (string, error) {
+	cm.mutex.RLock()
+	start := max(len(cm.messages)-narrationContextMessages, 0)
+	messages := make([]Message, 0, len(cm.messages)-start+1)
+	for _, msg := range cm.messages[start:] {
+		if msg.Role != "system" {
+			messages = append(messages, msg)
+		}
+	}
+	messages = append(messages, cm.newMessage("user", fmt.Sprintf("%s\n\n%s", outcome, narrationInstruction)))
+	systemPrompt := cm.requestSystemPrompt()
+	cm.mutex.RUnlock()
+
+	if cm.debug.Load() {
+		log.Printf("[CLAUDE] Narrating roll: %s", outcome)
+	}
+
+	response, err := cm.service.SendMessage(messages, systemPrompt)
+	if err != nil {
+		return "", fmt.Errorf("failed to get narration from Claude: %w", err)
+	}
+
+	narration := GetResponseText(response)
+	if narration == "" {
+		return "", fmt.Errorf("received empty response from Claude")
+	}
+	return narration, nil
+}
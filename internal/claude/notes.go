@@ -0,0 +1,37 @@
+package claude
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// notePrefix marks out-of-band DM notes in conversation messages
+const notePrefix = "[DM NOTE] "
+
+// AddNote adds context from the DM that wasn't said aloud, such as a secret, to the conversation
+// as a labeled user message so Claude takes it into account without mistaking it for speech.
+// Pending transcriptions are flushed first so the note keeps its place in the session.
+func (cm *ConversationManager) AddNote(text string) error {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return fmt.Errorf("the note is empty")
+	}
+
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	cm.appendTranscriptionBuffer()
+	cm.messages = append(cm.messages, cm.newMessage("user", notePrefix+text))
+	cm.trimMessages()
+
+	if err := cm.saveToDisk(); err != nil {
+		return fmt.Errorf("failed to save note: %w", err)
+	}
+
+	if cm.debug.Load() {
+		log.Printf("[CLAUDE] Added DM note to conversation (total messages: %d)", len(cm.messages))
+	}
+
+	return nil
+}
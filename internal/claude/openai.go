@@ -0,0 +1,194 @@
+package claude
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// OpenAIOptions holds the settings for an OpenAI-compatible chat completions endpoint
+type OpenAIOptions struct {
+	// Base URL of the API, e.g. http://localhost:11434/v1 for Ollama
+	BaseURL string
+	APIKey  string // May be empty for local servers
+	Model   string
+
+	// Request and response log, as in Options
+	LogFile     string
+	LogMaxBytes int64
+}
+
+// OpenAIService sends conversations to an OpenAI-compatible /chat/completions endpoint,
+// such as Ollama or llama.cpp, so the assistant can run against a local model
+type OpenAIService struct {
+	client  *http.Client
+	debug   atomic.Bool
+	options OpenAIOptions
+	exchangeLog
+}
+
+var _ Backend = (*OpenAIService)(nil)
+
+// openAIMessage is a chat message in the OpenAI format
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// openAIRequest is a chat completions request
+type openAIRequest struct {
+	Model     string          `json:"model"`
+	Messages  []openAIMessage `json:"messages"`
+	MaxTokens int             `json:"max_tokens"`
+}
+
+// openAIResponse is a chat completions response
+type openAIResponse struct {
+	ID      string `json:"id"`
+	Model   string `json:"model"`
+	Choices []struct {
+		Message      openAIMessage `json:"message"`
+		FinishReason string        `json:"finish_reason"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// NewOpenAIService creates a new service for an OpenAI-compatible endpoint
+func NewOpenAIService(debug bool, opts OpenAIOptions) *OpenAIService {
+	opts.BaseURL = strings.TrimRight(opts.BaseURL, "/")
+
+	service := &OpenAIService{
+		client: &http.Client{
+			Timeout: timeout,
+		},
+		options: opts,
+	}
+	service.file = newExchangeFile(opts.LogFile, opts.LogMaxBytes, opts.APIKey)
+	service.debug.Store(debug)
+
+	return service
+}
+
+// SetDebug enables or disables debug logging
+func (s *OpenAIService) SetDebug(debug bool) {
+	s.debug.Store(debug)
+}
+
+// SendMessage sends the conversation to the chat completions endpoint and maps the reply to a Response
+func (s *OpenAIService) SendMessage(messages []Message, systemPrompt string) (*Response, error) {
+	return s.SendMessageWithLimit(messages, systemPrompt, maxTokens)
+}
+
+// SendMessageWithLimit is SendMessage with a different cap on the response length
+func (s *OpenAIService) SendMessageWithLimit(messages []Message, systemPrompt string, maxTokens int) (*Response, error) {
+	if s.debug.Load() {
+		log.Printf("[CLAUDE] Sending %d messages to %s (model %s)", len(messages), s.options.BaseURL, s.options.Model)
+	}
+
+	apiMessages := make([]openAIMessage, 0, len(messages)+1)
+	if systemPrompt != "" {
+		apiMessages = append(apiMessages, openAIMessage{Role: "system", Content: systemPrompt})
+	}
+	for _, msg := range messages {
+		apiMessages = append(apiMessages, openAIMessage{Role: msg.Role, Content: messageText(msg.Content)})
+	}
+
+	jsonData, err := json.Marshal(openAIRequest{
+		Model:     s.options.Model,
+		Messages:  apiMessages,
+		MaxTokens: maxTokens,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", s.options.BaseURL+"/chat/completions", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.options.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.options.APIKey)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		s.record(s.debug.Load(), req.URL.String(), jsonData, 0, nil)
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if s.debug.Load() {
+		log.Printf("[CLAUDE] Response status: %d, body size: %d bytes", resp.StatusCode, len(body))
+	}
+	s.record(s.debug.Load(), req.URL.String(), jsonData, resp.StatusCode, body)
+
+	// OpenAI-style error bodies share the {"error": {"type", "message"}} shape
+	if resp.StatusCode != http.StatusOK {
+		return nil, parseAPIError(resp.StatusCode, body)
+	}
+
+	var completion openAIResponse
+	if err := json.Unmarshal(body, &completion); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if len(completion.Choices) == 0 {
+		return nil, fmt.Errorf("response contained no choices")
+	}
+
+	choice := completion.Choices[0]
+	response := &Response{
+		ID:         completion.ID,
+		Type:       "message",
+		Role:       "assistant",
+		Model:      completion.Model,
+		StopReason: choice.FinishReason,
+	}
+	response.Content = append(response.Content, struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}{Type: "text", Text: choice.Message.Content})
+	response.Usage.InputTokens = completion.Usage.PromptTokens
+	response.Usage.OutputTokens = completion.Usage.CompletionTokens
+
+	return response, nil
+}
+
+// messageText flattens message content (a string or text content blocks) into plain text
+func messageText(content interface{}) string {
+	switch c := content.(type) {
+	case string:
+		return c
+	case []ContentBlock:
+		var texts []string
+		for _, block := range c {
+			texts = append(texts, block.Text)
+		}
+		return strings.Join(texts, "\n")
+	case []interface{}:
+		// Content blocks loaded back from the conversation file
+		var texts []string
+		for _, block := range c {
+			if m, ok := block.(map[string]interface{}); ok {
+				if text, ok := m["text"].(string); ok {
+					texts = append(texts, text)
+				}
+			}
+		}
+		return strings.Join(texts, "\n")
+	}
+	return fmt.Sprint(content)
+}
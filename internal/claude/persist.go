@@ -0,0 +1,95 @@
+package claude
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SetSaveInterval batches saves: changes are written at most once per interval instead of
+// after every operation, and on Close. Zero saves after every change. Call it once, before
+// the conversation is used.
+func (cm *ConversationManager) SetSaveInterval(interval time.Duration) {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	if interval <= 0 || cm.IsEphemeral() || cm.stopSaving != nil {
+		return
+	}
+	cm.saveInterval = interval
+	cm.stopSaving = make(chan struct{})
+	go cm.saveLoop(interval, cm.stopSaving)
+}
+
+// saveLoop writes pending changes every interval until stopped
+func (cm *ConversationManager) saveLoop(interval time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			cm.mutex.Lock()
+			if cm.dirty {
+				if err := cm.writeToDisk(); err != nil {
+					log.Printf("[CLAUDE] ⚠️ Failed to save conversation: %v", err)
+				}
+			}
+			cm.mutex.Unlock()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Close waits for a summary in progress, stops periodic saving and writes any changes that
+// haven't been saved yet
+func (cm *ConversationManager) Close() error {
+	// A summary still being written would otherwise be lost
+	cm.compactions.Wait()
+
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	if cm.stopSaving != nil {
+		close(cm.stopSaving)
+		cm.stopSaving = nil
+	}
+	cm.saveInterval = 0
+
+	if !cm.dirty {
+		return nil
+	}
+	if err := cm.writeToDisk(); err != nil {
+		return fmt.Errorf("failed to save conversation: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic replaces a file by writing a temporary file next to it and renaming it
+// over the original, so a crash mid-write never leaves a truncated file
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,73 @@
+package claude
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Pin is a saved piece of text, such as an NPC description or ruling, kept outside the rolling history
+type Pin struct {
+	Text      string    `json:"text"`
+	PinnedBy  string    `json:"pinned_by"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// AddPin saves text to the pins list and returns the number of pins. Pins survive trimming and clearing.
+func (cm *ConversationManager) AddPin(text, pinnedBy string) (int, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return 0, fmt.Errorf("nothing to pin")
+	}
+
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	cm.pins = append(cm.pins, Pin{
+		Text:      text,
+		PinnedBy:  pinnedBy,
+		Timestamp: cm.clock.Now(),
+	})
+
+	if err := cm.saveToDisk(); err != nil {
+		return len(cm.pins), fmt.Errorf("failed to save pins: %w", err)
+	}
+
+	if cm.debug.Load() {
+		log.Printf("[CLAUDE] Pinned message (%d pins)", len(cm.pins))
+	}
+
+	return len(cm.pins), nil
+}
+
+// Pins returns a copy of the pins list, oldest first
+func (cm *ConversationManager) Pins() []Pin {
+	cm.mutex.RLock()
+	defer cm.mutex.RUnlock()
+
+	pins := make([]Pin, len(cm.pins))
+	copy(pins, cm.pins)
+	return pins
+}
+
+// RecentResponse returns the text of the nth most recent Claude response (1 = latest)
+func (cm *ConversationManager) RecentResponse(n int) (string, bool) {
+	cm.mutex.RLock()
+	defer cm.mutex.RUnlock()
+
+	if n <= 0 {
+		return "", false
+	}
+
+	for i := len(cm.messages) - 1; i >= 0; i-- {
+		if cm.messages[i].Role != "assistant" {
+			continue
+		}
+		if n--; n == 0 {
+			return messageText(cm.messages[i].Content), true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,42 @@
+package claude
+
+import "strings"
+
+// Messages shown from each end of the conversation in a prompt preview
+const previewEdgeMessages = 2
+
+// PromptPreview is what the next request would send, with the middle of a long conversation left out
+type PromptPreview struct {
+	SystemPrompt string
+	First        []Message // Oldest messages
+	Last         []Message // Newest messages, including any buffered transcriptions; empty if First holds everything
+	Omitted      int       // Messages between First and Last
+	Total        int       // Messages the request would send
+}
+
+// PromptPreview renders the system prompt and the first and last messages the next question
+// would send, including buffered transcriptions that would be flushed with it
+func (cm *ConversationManager) PromptPreview() PromptPreview {
+	cm.mutex.RLock()
+	defer cm.mutex.RUnlock()
+
+	systemPrompt := cm.requestSystemPrompt()
+	if cm.verboseNext {
+		systemPrompt += verboseInstruction
+	}
+
+	messages := cm.apiMessages()
+	if lines := cm.bufferedLines(); len(lines) > 0 {
+		messages = append(messages, cm.newMessage("user", strings.Join(lines, "\n")))
+	}
+
+	preview := PromptPreview{SystemPrompt: systemPrompt, Total: len(messages)}
+	if len(messages) <= 2*previewEdgeMessages {
+		preview.First = messages
+		return preview
+	}
+	preview.First = messages[:previewEdgeMessages]
+	preview.Last = messages[len(messages)-previewEdgeMessages:]
+	preview.Omitted = len(messages) - 2*previewEdgeMessages
+	return preview
+}
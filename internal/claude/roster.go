@@ -0,0 +1,26 @@
+package claude
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// SetRoster sets the speakers at the table, labeled as in transcriptions (e.g. "DM" or
+// "PLAYER Alice"), so Claude can address players by name instead of by SSRC. An empty
+// roster leaves the note out of the system prompt.
+func (cm *ConversationManager) SetRoster(speakers []string) {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+	cm.roster = slices.Clone(speakers)
+}
+
+// rosterNote returns the system prompt note listing the speakers at the table, or "" if the
+// roster is empty. The caller must hold the mutex.
+func (cm *ConversationManager) rosterNote() string {
+	if len(cm.roster) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\n\nAt the table: %s. Address players by these names; never refer to anyone by SSRC number.",
+		strings.Join(cm.roster, ", "))
+}
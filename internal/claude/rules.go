@@ -0,0 +1,55 @@
+package claude
+
+import (
+	"fmt"
+	"log"
+)
+
+const rulesSystemPrompt = `You are a D&D 5e rules reference. Answer only with the relevant 5e rule, stated as briefly as possible, followed by a book and page/section reference (e.g. "PHB p. 192, Opportunity Attacks").
+Do not discuss the ongoing game, offer advice, or add commentary. If the rule is ambiguous or you're unsure, say so in one sentence and name where to look it up.`
+
+const encounterSystemPrompt = `You are a D&D 5e encounter designer. Suggest monsters from the 5e Monster Manual for the encounter described.
+Stay within the XP budget after applying the multiplier for the number of monsters. List each monster with its CR, XP and count, then give the total and adjusted XP on one line. No flavor text beyond a one-sentence hook.`
+
+// LookupRule answers a rules question with a terse rules-only prompt. The question and answer
+// are kept out of the session conversation so neither pollutes the other.
+func (cm *ConversationManager) LookupRule(question string) (string, error) {
+	if cm.debug.Load() {
+		log.Printf("[CLAUDE] Rules lookup: %s", question)
+	}
+
+	answer, err := cm.askStandalone(question, rulesSystemPrompt)
+	if err != nil {
+		return "", fmt.Errorf("failed to get rules answer from Claude: %w", err)
+	}
+	return answer, nil
+}
+
+// SuggestMonsters asks Claude for monsters fitting an encounter description, outside the session conversation
+func (cm *ConversationManager) SuggestMonsters(description string) (string, error) {
+	if cm.debug.Load() {
+		log.Printf("[CLAUDE] Encounter suggestion: %s", description)
+	}
+
+	answer, err := cm.askStandalone(description, encounterSystemPrompt)
+	if err != nil {
+		return "", fmt.Errorf("failed to get monster suggestions from Claude: %w", err)
+	}
+	return answer, nil
+}
+
+// askStandalone sends a single question with its own system prompt, without touching the conversation
+func (cm *ConversationManager) askStandalone(question, systemPrompt string) (string, error) {
+	messages := []Message{cm.newMessage("user", question)}
+	response, err := cm.service.SendMessage(messages, systemPrompt)
+	if err != nil {
+		return "", err
+	}
+
+	responseText := GetResponseText(response)
+	if responseText == "" {
+		return "", fmt.Errorf("received empty response from Claude")
+	}
+
+	return withModelNote(response, responseText), nil
+}
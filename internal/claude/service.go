@@ -3,25 +3,78 @@ package claude
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
 const (
 	anthropicAPIURL = "https://api.anthropic.com/v1/messages"
 	defaultModel    = "claude-3-5-sonnet-20241022"
+	defaultVersion  = "2023-06-01"
 	maxTokens       = 4096
 	timeout         = 60 * time.Second
+
+	// Retries for rate-limited or overloaded requests before giving up or falling back
+	maxRetries     = 2
+	retryBaseDelay = 2 * time.Second
+)
+
+// Options holds optional Claude API settings
+type Options struct {
+	// Value of the anthropic-version header (defaults to 2023-06-01)
+	APIVersion string
+
+	// Values sent in the anthropic-beta header to enable beta features
+	BetaFeatures []string
+
+	// Model to try once when the primary model is overloaded or rate-limited (empty = disabled)
+	FallbackModel string
+
+	// JSON lines file every request and response is appended to (empty = disabled), moved
+	// aside once it would pass LogMaxBytes (0 = no limit)
+	LogFile     string
+	LogMaxBytes int64
+}
+
+// MessageSender sends a conversation to Claude and returns its response.
+// Service implements it; ConversationManager depends on it so other implementations can be swapped in.
+type MessageSender interface {
+	SendMessage(messages []Message, systemPrompt string) (*Response, error)
+}
+
+// limitedSender is a MessageSender that can change the cap on the response length per request
+type limitedSender interface {
+	SendMessageWithLimit(messages []Message, systemPrompt string, maxTokens int) (*Response, error)
+}
+
+var (
+	_ limitedSender = (*Service)(nil)
+	_ limitedSender = (*OpenAIService)(nil)
 )
 
+// Backend is a MessageSender whose debug logging can be toggled at runtime. While debug is on,
+// it keeps the last raw request and response.
+type Backend interface {
+	MessageSender
+	SetDebug(debug bool)
+	LastExchange() (Exchange, bool)
+}
+
+var _ Backend = (*Service)(nil)
+
 // Service handles communication with the Anthropic Claude API
 type Service struct {
-	apiKey string
-	client *http.Client
-	debug  bool
+	apiKey  string
+	client  *http.Client
+	debug   atomic.Bool
+	options Options
+	exchangeLog
 }
 
 // Message represents a single message in the conversation (with timestamp for internal use)
@@ -29,6 +82,14 @@ type Message struct {
 	Role      string      `json:"role"`      // "user", "assistant", or "system"
 	Content   interface{} `json:"content"`   // string or []ContentBlock
 	Timestamp time.Time   `json:"timestamp"` // When this message was created
+
+	// Partial is true for an assistant turn that was cut off before Claude finished
+	Partial bool `json:"partial,omitempty"`
+}
+
+// Text returns the message's content as plain text
+func (m Message) Text() string {
+	return messageText(m.Content)
 }
 
 // APIMessage represents a message for the Claude API (without timestamp)
@@ -75,6 +136,9 @@ type Response struct {
 		InputTokens  int `json:"input_tokens"`
 		OutputTokens int `json:"output_tokens"`
 	} `json:"usage"`
+
+	// Fallback is true if the response came from the fallback model
+	Fallback bool `json:"-"`
 }
 
 // ErrorResponse represents an error response from the Claude API
@@ -87,20 +151,76 @@ type ErrorResponse struct {
 }
 
 // NewService creates a new Claude service
-func NewService(apiKey string, debug bool) *Service {
-	return &Service{
+func NewService(apiKey string, debug bool, opts Options) *Service {
+	if opts.APIVersion == "" {
+		opts.APIVersion = defaultVersion
+	}
+
+	service := &Service{
 		apiKey: apiKey,
 		client: &http.Client{
 			Timeout: timeout,
 		},
-		debug: debug,
+		options: opts,
 	}
+	service.file = newExchangeFile(opts.LogFile, opts.LogMaxBytes, apiKey)
+	service.debug.Store(debug)
+
+	return service
 }
 
-// SendMessage sends a message to Claude and returns the response
+// SetDebug enables or disables debug logging
+func (s *Service) SetDebug(debug bool) {
+	s.debug.Store(debug)
+}
+
+// SendMessage sends a message to Claude and returns the response. Rate-limited and overloaded
+// requests are retried, then sent once to the fallback model if one is configured.
 func (s *Service) SendMessage(messages []Message, systemPrompt string) (*Response, error) {
-	if s.debug {
-		log.Printf("[CLAUDE] Sending %d messages to Claude API", len(messages))
+	return s.SendMessageWithLimit(messages, systemPrompt, maxTokens)
+}
+
+// SendMessageWithLimit is SendMessage with a different cap on the response length
+func (s *Service) SendMessageWithLimit(messages []Message, systemPrompt string, maxTokens int) (*Response, error) {
+	var err error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			delay := retryBaseDelay * time.Duration(1<<(attempt-1))
+			log.Printf("[CLAUDE] ⚠️ Retrying in %v (attempt %d of %d): %v", delay, attempt, maxRetries, err)
+			time.Sleep(delay)
+		}
+
+		var response *Response
+		response, err = s.sendWithModel(defaultModel, messages, systemPrompt, maxTokens)
+		if err == nil {
+			return response, nil
+		}
+
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || !apiErr.IsRetryable() {
+			return nil, err
+		}
+	}
+
+	// Only fall back for capacity problems, not server errors
+	if s.options.FallbackModel == "" || !(errors.Is(err, ErrOverloaded) || errors.Is(err, ErrRateLimited)) {
+		return nil, err
+	}
+
+	log.Printf("[CLAUDE] ⚠️ Primary model unavailable, trying fallback model %s: %v", s.options.FallbackModel, err)
+	response, fallbackErr := s.sendWithModel(s.options.FallbackModel, messages, systemPrompt, maxTokens)
+	if fallbackErr != nil {
+		return nil, fmt.Errorf("fallback model %s also failed: %w", s.options.FallbackModel, fallbackErr)
+	}
+
+	response.Fallback = true
+	return response, nil
+}
+
+// sendWithModel sends a single request to Claude using the given model
+func (s *Service) sendWithModel(model string, messages []Message, systemPrompt string, maxTokens int) (*Response, error) {
+	if s.debug.Load() {
+		log.Printf("[CLAUDE] Sending %d messages to Claude API (model %s)", len(messages), model)
 	}
 
 	// Create API-compatible messages (without timestamp field)
@@ -114,7 +234,7 @@ func (s *Service) SendMessage(messages []Message, systemPrompt string) (*Respons
 
 	// Prepare the request
 	request := APIRequest{
-		Model:     defaultModel,
+		Model:     model,
 		Messages:  apiMessages,
 		MaxTokens: maxTokens,
 		System:    systemPrompt,
@@ -126,7 +246,7 @@ func (s *Service) SendMessage(messages []Message, systemPrompt string) (*Respons
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	if s.debug {
+	if s.debug.Load() {
 		log.Printf("[CLAUDE] Request payload size: %d bytes", len(jsonData))
 	}
 
@@ -139,11 +259,15 @@ func (s *Service) SendMessage(messages []Message, systemPrompt string) (*Respons
 	// Set headers
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("x-api-key", s.apiKey)
-	req.Header.Set("anthropic-version", "2023-06-01")
+	req.Header.Set("anthropic-version", s.options.APIVersion)
+	if len(s.options.BetaFeatures) > 0 {
+		req.Header.Set("anthropic-beta", strings.Join(s.options.BetaFeatures, ","))
+	}
 
 	// Send request
 	resp, err := s.client.Do(req)
 	if err != nil {
+		s.record(s.debug.Load(), anthropicAPIURL, jsonData, 0, nil)
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
@@ -154,17 +278,14 @@ func (s *Service) SendMessage(messages []Message, systemPrompt string) (*Respons
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	if s.debug {
+	if s.debug.Load() {
 		log.Printf("[CLAUDE] Response status: %d, body size: %d bytes", resp.StatusCode, len(body))
 	}
+	s.record(s.debug.Load(), anthropicAPIURL, jsonData, resp.StatusCode, body)
 
 	// Handle non-200 responses
 	if resp.StatusCode != http.StatusOK {
-		var errorResp ErrorResponse
-		if err := json.Unmarshal(body, &errorResp); err != nil {
-			return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
-		}
-		return nil, fmt.Errorf("API error: %s - %s", errorResp.Error.Type, errorResp.Error.Message)
+		return nil, parseAPIError(resp.StatusCode, body)
 	}
 
 	// Parse successful response
@@ -173,7 +294,7 @@ func (s *Service) SendMessage(messages []Message, systemPrompt string) (*Respons
 		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
-	if s.debug {
+	if s.debug.Load() {
 		log.Printf("[CLAUDE] Response: model=%s, input_tokens=%d, output_tokens=%d",
 			response.Model, response.Usage.InputTokens, response.Usage.OutputTokens)
 	}
@@ -199,6 +320,12 @@ func CreateAssistantMessage(content string) Message {
 	}
 }
 
+// Truncated returns true if the response stopped at the token limit rather than finishing
+func (r *Response) Truncated() bool {
+	// "length" is the equivalent stop reason from OpenAI-compatible backends
+	return r.StopReason == "max_tokens" || r.StopReason == "length"
+}
+
 // GetResponseText extracts the text content from a Claude response
 func GetResponseText(response *Response) string {
 	if len(response.Content) > 0 && response.Content[0].Type == "text" {
@@ -0,0 +1,51 @@
+package claude
+
+import "log"
+
+const (
+	// Response length cap for a verbose answer
+	verboseMaxTokens = 8192
+
+	// Appended to the system prompt for a verbose answer, overriding the length guideline
+	verboseInstruction = "\n\nFor this answer only, ignore any guideline about keeping responses short. The DM asked for a deep dive: answer thoroughly and in as much detail as the question deserves."
+)
+
+// SetVerboseNext makes the next question get a long, detailed answer instead of the usual
+// concise one. It applies to one answer and then resets.
+func (cm *ConversationManager) SetVerboseNext(verbose bool) {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+	cm.verboseNext = verbose
+}
+
+// VerboseNext returns whether the next question will get a verbose answer
+func (cm *ConversationManager) VerboseNext() bool {
+	cm.mutex.RLock()
+	defer cm.mutex.RUnlock()
+	return cm.verboseNext
+}
+
+// questionSystemPrompt returns the system prompt for answering a question, with the verbose
+// instruction if one was requested. The caller must hold the mutex.
+func (cm *ConversationManager) questionSystemPrompt() (systemPrompt string, verbose bool) {
+	if !cm.verboseNext {
+		return cm.requestSystemPrompt(), false
+	}
+	return cm.requestSystemPrompt() + verboseInstruction, true
+}
+
+// sendQuestion sends the conversation for an answer to a question, with the verbose length
+// cap if requested. It doesn't touch the conversation, so the mutex needn't be held.
+func (cm *ConversationManager) sendQuestion(messages []Message, systemPrompt string, verbose bool) (*Response, error) {
+	if !verbose {
+		return cm.service.SendMessage(messages, systemPrompt)
+	}
+
+	if cm.debug.Load() {
+		log.Printf("[CLAUDE] Requesting a verbose answer")
+	}
+	if sender, ok := cm.service.(limitedSender); ok {
+		return sender.SendMessageWithLimit(messages, systemPrompt, verboseMaxTokens)
+	}
+	return cm.service.SendMessage(messages, systemPrompt)
+}
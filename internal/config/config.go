@@ -5,8 +5,10 @@ import (
 	"log"
 	"os"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -15,23 +17,265 @@ import (
 type Config struct {
 	DiscordBotToken   string
 	DMUserID          string
+	CoDMUserIDs       []string // Other users whose speech is labeled as the DM's
+	IgnoredUserIDs    []string // Users whose speech is never transcribed (until changed at runtime)
 	DNDVoiceChannelID string
+	PlayerRoleID      string // If set, auto-join also waits for a member with this role
+	AnnounceChannelID string // Optional text channel for configuration warnings
 	CommandPrefix     string
 	Debug             bool
+	Persist           bool   // Write audio and conversation history to disk
+	DataDir           string // Root directory for everything the bot writes
+	CampaignName      string // Label for the campaign until one is set with the campaign command
+	TablesFile        string // JSON file of random tables for the table command
+	ArchiveMinAgeDays int    // Default age of recordings the archive command packs away
+
+	// Re-run commands when their message is edited shortly after being sent
+	CommandEditReinvoke bool
+	CommandEditWindow   time.Duration
+
+	// Gateway intents requested from Discord, by name
+	DiscordIntents []string
+
+	// Longest to wait for guild data after connecting before running startup checks anyway
+	GuildLoadTimeout time.Duration
+
+	// How long the DM can be out of the voice channel before the bot leaves too
+	DMLeaveGrace time.Duration
+
+	// What a join does when the bot is already in a voice channel in that guild (JoinWhileConnected*)
+	JoinWhileConnected string
+
+	// Post the overflow of long command output into a thread instead of the channel
+	LongOutputThreads bool
+
+	// Show Claude's answers as embeds instead of plain text
+	UseEmbeds bool
+
+	// Post a greeting to the announce channel once the bot is online
+	StartupMessageEnabled bool
+	StartupMessage        string
+
+	// Daily window during which the bot only answers commands and doesn't post on its own
+	QuietHours QuietHours
+
+	// Audio processing
+	FillPacketGaps bool
+	VoiceGateDB    float64 // Frames quieter than this (dBFS) count as silence; 0 disables the gate
+	RecordIgnored  bool    // Keep recording users whose speech is ignored
+	AudioLeadIn    time.Duration
+	MaxBufferAge   time.Duration // Transcribe long monologues in pieces of at most this length (0 = off)
+	ValidateOpus   bool          // Drop packets with invalid Opus framing
+
+	// Transcribe buffers longer than this in parallel pieces, split at pauses (0 = off)
+	TranscribeSegmentLength time.Duration
+	TranscribeConcurrency   int
+
+	// Save batches that don't fit in a full transcription queue to disk and transcribe them later
+	TranscriptionSpool      bool
+	TranscriptionSpoolMaxMB int
+
+	// Send the speech backend 16kHz mono PCM instead of 48kHz stereo Opus
+	TranscriptionResample bool
+
+	// Buffers with fewer packets (20ms each) are discarded rather than transcribed
+	MinPacketsToTranscribe int
+
+	// Format declared in recording files; Discord always sends 48kHz stereo Opus
+	RecordingSampleRate int
+	RecordingChannels   int
+
+	// Spoken commands from the DM, e.g. "assistant, flush"
+	VoiceCommandsEnabled bool
+	VoiceWakeWord        string
+	VoiceCommands        map[string]string // Spoken phrase -> command line
+
+	// Speech-to-text backend: "google" or "whisper"
+	SpeechBackend string
 
 	// Google Cloud Speech-to-Text
 	GoogleProjectID string
 	GoogleCredsPath string
 
+	// Optional Google recognition features
+	SpeechAutomaticPunctuation bool
+	SpeechWordConfidence       bool
+	SpeechWordTimeOffsets      bool
+
+	// Google recognition model, one of SpeechModels (changeable at runtime)
+	SpeechModel string
+
+	// OpenAI Whisper (or a local OpenAI-compatible server)
+	WhisperBaseURL string
+	WhisperAPIKey  string
+	WhisperModel   string
+
+	// Transcript embeddings for the find command (OpenAI-compatible embeddings endpoint)
+	EmbeddingsEnabled bool
+	EmbeddingsBaseURL string
+	EmbeddingsAPIKey  string
+	EmbeddingsModel   string
+	EmbeddingsFile    string // Relative to the transcripts directory
+	FindResults       int    // Matches shown by the find command
+
 	// Anthropic Claude
-	AnthropicAPIKey     string
-	ConversationFile    string
-	MaxConversationMsgs int
+	AnthropicAPIKey string
+
+	// Assistant backend: "anthropic" or "openai" (any OpenAI-compatible endpoint, e.g. Ollama)
+	LLMBackend string
+	LLMBaseURL string
+	LLMModel   string
+	LLMAPIKey  string
+
+	AnthropicVersion     string
+	AnthropicBeta        []string
+	ClaudeFallbackModel  string
+	MaxConcurrentClaude  int    // Requests to the assistant backend in flight at once; extras queue
+	RosterInPrompt       bool   // List the speakers at the table in the system prompt
+	ClaudeLogFile        string // JSON lines log of every request and response (empty = off)
+	ClaudeLogMaxMB       int    // Size at which the log is moved aside (0 = no limit)
+	ConversationFile     string
+	ConversationPerGuild bool // Keep a separate conversation file for each guild
+	MaxConversationMsgs  int
+	CompactStrategy      string // How old messages are dropped: trim or summarize
+
+	// How often conversation changes are written to disk (0 = after every change)
+	SaveInterval time.Duration
+
+	// Archive and clear the conversation when the bot leaves because the DM did
+	AutoClearOnLeave bool
+
+	// Model context window in tokens, for the budget command's estimates
+	ContextWindowTokens int
+
+	// How often buffered transcriptions are flushed to Claude for a response (0 = never)
+	AutoFlushInterval time.Duration
+
+	// Question asked by the suggest command
+	SuggestPrompt string
+
+	// Have Claude narrate the outcome of the check command
+	CheckNarration bool
+
+	// Merge a speaker's transcriptions arriving within this window of the first into one line (0 = off)
+	TranscriptionDebounce time.Duration
+
+	// Transcription buffer throttling
+	TranscriptionBufferMaxLines int
+	TranscriptionBufferMaxChars int
+	FilterFillerTranscriptions  bool
+	MinTranscriptionConfidence  float64
+	MinTranscriptionWords       int // Blank transcriptions are always dropped
+
+	// Mark transcriptions below this confidence so Claude knows they may be misheard (0 = off)
+	LowConfidenceThreshold float64
+	LowConfidenceMarker    string
+
+	// Per-guild feature overrides, keyed by guild ID
+	GuildFeatures map[string]GuildFeatures
+
+	// Per-guild DM and voice channel from GUILD_CONFIG_FILE, keyed by guild ID; guilds
+	// without an entry use DMUserID and DNDVoiceChannelID
+	GuildConfigFile string
+	Guilds          map[string]GuildSettings
+}
+
+// GuildFeatures holds per-guild overrides of the globally enabled features.
+// A nil field means the guild uses the global setting.
+type GuildFeatures struct {
+	ClaudeEnabled *bool
+	SpeechEnabled *bool
 }
 
 const (
 	// Discord snowflake IDs are 17-19 digit numbers
 	discordIDPattern = `^\d{17,19}$`
+
+	// Anthropic API versions are dates, e.g. 2023-06-01
+	anthropicVersionPattern = `^\d{4}-\d{2}-\d{2}$`
+
+	// Default spoken phrase to command mappings
+	defaultVoiceCommands = "flush=flush;clear=clear;summarize=ask Briefly summarize what has happened in the session so far"
+
+	// Shorter maximum buffer ages would cut most sentences in half
+	minMaxBufferAge = 5 * time.Second
+
+	// Shorter transcription segments lose too much context at the cuts
+	minTranscribeSegmentLength = 5 * time.Second
+
+	// More parallel requests per buffer mostly hits speech API rate limits
+	maxTranscribeConcurrency = 16
+
+	// Anthropic beta feature names, e.g. prompt-caching-2024-07-31
+	anthropicBetaPattern = `^[A-Za-z0-9._-]+$`
+)
+
+// Speech-to-text backends selectable with SPEECH_BACKEND
+const (
+	SpeechBackendGoogle  = "google"
+	SpeechBackendWhisper = "whisper"
+)
+
+// Google Speech-to-Text models selectable with SPEECH_MODEL. Newer models such as chirp and
+// telephony are only served by the v2 API, which the bot doesn't use.
+var SpeechModels = []string{
+	"latest_long",
+	"latest_short",
+	"command_and_search",
+	"phone_call",
+	"video",
+	"default",
+	"medical_conversation",
+	"medical_dictation",
+}
+
+// What joining does while already connected, selectable with JOIN_WHILE_CONNECTED
+const (
+	JoinWhileConnectedMove   = "move"
+	JoinWhileConnectedRefuse = "refuse"
+)
+
+// Ways of shrinking a full conversation selectable with COMPACT_STRATEGY
+const (
+	CompactStrategyTrim      = "trim"
+	CompactStrategySummarize = "summarize"
+)
+
+// MaxCampaignNameLength caps campaign names, which appear in filenames and prompts
+const MaxCampaignNameLength = 100
+
+// Discord gateway intents selectable with DISCORD_INTENTS
+const (
+	IntentGuilds           = "guilds"
+	IntentGuildMembers     = "guild_members"
+	IntentGuildVoiceStates = "guild_voice_states"
+	IntentGuildMessages    = "guild_messages"
+	IntentMessageContent   = "message_content"
+	IntentDirectMessages   = "direct_messages"
+	IntentAll              = "all"
+)
+
+// DefaultDiscordIntents are the least privileges the bot's features need
+var DefaultDiscordIntents = []string{IntentGuilds, IntentGuildVoiceStates, IntentGuildMessages, IntentMessageContent, IntentDirectMessages}
+
+// requiredIntents lists the intents the bot can't work without and what each is for
+var requiredIntents = []struct{ name, reason string }{
+	{IntentGuilds, "needed to see guilds, voice channels and channel changes"},
+	{IntentGuildVoiceStates, "needed to follow the DM into and out of voice channels"},
+	{IntentGuildMessages, "needed to receive commands in server channels"},
+	{IntentMessageContent, "needed to read the text of commands (a privileged intent; enable it in the Discord developer portal)"},
+}
+
+// optionalIntents are accepted but not required
+var optionalIntents = []string{
+	IntentGuildMembers,   // Display names for users not yet in the member cache (privileged)
+	IntentDirectMessages, // Commands sent to the bot in a direct message
+}
+
+// Assistant backends selectable with LLM_BACKEND
+const (
+	LLMBackendAnthropic = "anthropic"
+	LLMBackendOpenAI    = "openai"
 )
 
 // Load loads configuration from environment variables
@@ -48,11 +292,17 @@ func Load() (*Config, error) {
 		log.Println("No .env file found - using system environment variables")
 	}
 
+	// Guilds in the guild config file have their own DM and channel, so the global ones become optional
+	guildConfigFile := os.Getenv("GUILD_CONFIG_FILE")
+	guilds, err := loadGuildSettings(guildConfigFile)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GUILD_CONFIG_FILE: %w", err)
+	}
+
 	// Required environment variables
-	requiredVars := []string{
-		"DISCORD_BOT_TOKEN",
-		"DM_USER_ID",
-		"DND_VOICE_CHANNEL_ID",
+	requiredVars := []string{"DISCORD_BOT_TOKEN"}
+	if len(guilds) == 0 {
+		requiredVars = append(requiredVars, "DM_USER_ID", "DND_VOICE_CHANNEL_ID")
 	}
 
 	var missingVars []string
@@ -77,18 +327,154 @@ func Load() (*Config, error) {
 	config := &Config{
 		DiscordBotToken:   os.Getenv("DISCORD_BOT_TOKEN"),
 		DMUserID:          os.Getenv("DM_USER_ID"),
+		CoDMUserIDs:       getEnvList("CO_DM_USER_IDS"),
+		IgnoredUserIDs:    getEnvList("IGNORED_USER_IDS"),
 		DNDVoiceChannelID: os.Getenv("DND_VOICE_CHANNEL_ID"),
+		PlayerRoleID:      strings.TrimSpace(os.Getenv("PLAYER_ROLE_ID")),
+		AnnounceChannelID: os.Getenv("ANNOUNCE_CHANNEL_ID"),
 		CommandPrefix:     getEnvWithDefault("COMMAND_PREFIX", "!dnd"),
 		Debug:             debug,
+		Persist:           getEnvWithDefaultBool("PERSIST", true),
+		DataDir:           getEnvWithDefault("DATA_DIR", "data"),
+		CampaignName:      strings.TrimSpace(os.Getenv("CAMPAIGN_NAME")),
+		TablesFile:        os.Getenv("TABLES_FILE"),
+		ArchiveMinAgeDays: getEnvWithDefaultInt("ARCHIVE_MIN_AGE_DAYS", 30),
+
+		CommandEditReinvoke: getEnvWithDefaultBool("COMMAND_EDIT_REINVOKE", false),
+		CommandEditWindow:   time.Duration(getEnvWithDefaultInt("COMMAND_EDIT_WINDOW_SECONDS", 120)) * time.Second,
+
+		DiscordIntents: getEnvList("DISCORD_INTENTS"),
+
+		GuildLoadTimeout: time.Duration(getEnvWithDefaultInt("GUILD_LOAD_TIMEOUT_SECONDS", 30)) * time.Second,
+
+		DMLeaveGrace: time.Duration(getEnvWithDefaultInt("DM_LEAVE_GRACE_SECONDS", 5)) * time.Second,
+
+		JoinWhileConnected: strings.ToLower(getEnvWithDefault("JOIN_WHILE_CONNECTED", JoinWhileConnectedMove)),
+
+		LongOutputThreads: getEnvWithDefaultBool("LONG_OUTPUT_THREADS", false),
+
+		UseEmbeds: getEnvWithDefaultBool("USE_EMBEDS", false),
+
+		StartupMessageEnabled: getEnvWithDefaultBool("STARTUP_MESSAGE_ENABLED", true),
+		StartupMessage:        getEnvWithDefault("STARTUP_MESSAGE", "🎲 D&D DM Assistant is online!"),
+
+		// Audio processing
+		FillPacketGaps: getEnvWithDefaultBool("FILL_PACKET_GAPS", false),
+		VoiceGateDB:    getEnvWithDefaultFloat("VOICE_GATE_DB", 0),
+		RecordIgnored:  getEnvWithDefaultBool("RECORD_IGNORED_USERS", false),
+		AudioLeadIn:    time.Duration(getEnvWithDefaultInt("AUDIO_LEAD_IN_MS", 0)) * time.Millisecond,
+		MaxBufferAge:   time.Duration(getEnvWithDefaultInt("MAX_BUFFER_AGE_SECONDS", 0)) * time.Second,
+		ValidateOpus:   getEnvWithDefaultBool("VALIDATE_OPUS_PACKETS", false),
+
+		TranscribeSegmentLength: time.Duration(getEnvWithDefaultInt("TRANSCRIBE_SEGMENT_SECONDS", 0)) * time.Second,
+		TranscribeConcurrency:   getEnvWithDefaultInt("TRANSCRIBE_CONCURRENCY", 4),
+
+		TranscriptionSpool:      getEnvWithDefaultBool("TRANSCRIPTION_SPOOL", false),
+		TranscriptionSpoolMaxMB: getEnvWithDefaultInt("TRANSCRIPTION_SPOOL_MAX_MB", 100),
+		TranscriptionResample:   getEnvWithDefaultBool("TRANSCRIPTION_RESAMPLE", false),
+		MinPacketsToTranscribe:  getEnvWithDefaultInt("MIN_PACKETS_TO_TRANSCRIBE", 10),
+
+		RecordingSampleRate: getEnvWithDefaultInt("RECORDING_SAMPLE_RATE", 48000),
+		RecordingChannels:   getEnvWithDefaultInt("RECORDING_CHANNELS", 2),
+
+		// Spoken commands
+		VoiceCommandsEnabled: getEnvWithDefaultBool("VOICE_COMMANDS_ENABLED", false),
+		VoiceWakeWord:        strings.ToLower(strings.TrimSpace(getEnvWithDefault("VOICE_WAKE_WORD", "assistant"))),
+
+		SpeechBackend: strings.ToLower(getEnvWithDefault("SPEECH_BACKEND", SpeechBackendGoogle)),
 
 		// Google Cloud Speech-to-Text
 		GoogleProjectID: os.Getenv("GOOGLE_PROJECT_ID"),
 		GoogleCredsPath: os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"),
 
+		SpeechAutomaticPunctuation: getEnvWithDefaultBool("SPEECH_AUTOMATIC_PUNCTUATION", true),
+		SpeechWordConfidence:       getEnvWithDefaultBool("SPEECH_WORD_CONFIDENCE", true),
+		SpeechWordTimeOffsets:      getEnvWithDefaultBool("SPEECH_WORD_TIME_OFFSETS", true),
+		SpeechModel:                strings.ToLower(strings.TrimSpace(getEnvWithDefault("SPEECH_MODEL", "latest_long"))),
+
+		// OpenAI Whisper
+		WhisperBaseURL: getEnvWithDefault("WHISPER_BASE_URL", "https://api.openai.com/v1"),
+		WhisperAPIKey:  getEnvWithDefault("WHISPER_API_KEY", os.Getenv("OPENAI_API_KEY")),
+		WhisperModel:   getEnvWithDefault("WHISPER_MODEL", "whisper-1"),
+
+		// Transcript embeddings
+		EmbeddingsEnabled: getEnvWithDefaultBool("EMBEDDINGS_ENABLED", false),
+		EmbeddingsBaseURL: getEnvWithDefault("EMBEDDINGS_BASE_URL", "https://api.openai.com/v1"),
+		EmbeddingsAPIKey:  getEnvWithDefault("EMBEDDINGS_API_KEY", os.Getenv("OPENAI_API_KEY")),
+		EmbeddingsModel:   getEnvWithDefault("EMBEDDINGS_MODEL", "text-embedding-3-small"),
+		EmbeddingsFile:    getEnvWithDefault("EMBEDDINGS_FILE", "transcript_embeddings.jsonl"),
+		FindResults:       getEnvWithDefaultInt("FIND_RESULTS", 5),
+
 		// Anthropic Claude
-		AnthropicAPIKey:     os.Getenv("ANTHROPIC_API_KEY"),
-		ConversationFile:    getEnvWithDefault("CONVERSATION_FILE", "dnd_conversation.json"),
-		MaxConversationMsgs: getEnvWithDefaultInt("MAX_CONVERSATION_MSGS", 200),
+		AnthropicAPIKey: os.Getenv("ANTHROPIC_API_KEY"),
+
+		LLMBackend: strings.ToLower(getEnvWithDefault("LLM_BACKEND", LLMBackendAnthropic)),
+		LLMBaseURL: getEnvWithDefault("LLM_BASE_URL", "http://localhost:11434/v1"),
+		LLMModel:   os.Getenv("LLM_MODEL"),
+		LLMAPIKey:  os.Getenv("LLM_API_KEY"),
+
+		AnthropicVersion:     getEnvWithDefault("ANTHROPIC_VERSION", "2023-06-01"),
+		AnthropicBeta:        getEnvList("ANTHROPIC_BETA"),
+		ClaudeFallbackModel:  strings.TrimSpace(os.Getenv("CLAUDE_FALLBACK_MODEL")),
+		MaxConcurrentClaude:  getEnvWithDefaultInt("MAX_CONCURRENT_CLAUDE", 1),
+		RosterInPrompt:       getEnvWithDefaultBool("ROSTER_IN_PROMPT", true),
+		ClaudeLogFile:        strings.TrimSpace(os.Getenv("CLAUDE_LOG_FILE")),
+		ClaudeLogMaxMB:       getEnvWithDefaultInt("CLAUDE_LOG_MAX_MB", 10),
+		ConversationFile:     getEnvWithDefault("CONVERSATION_FILE", "dnd_conversation.json"),
+		ConversationPerGuild: getEnvWithDefaultBool("CONVERSATION_PER_GUILD", false),
+		MaxConversationMsgs:  getEnvWithDefaultInt("MAX_CONVERSATION_MSGS", 200),
+		CompactStrategy:      strings.ToLower(getEnvWithDefault("COMPACT_STRATEGY", CompactStrategyTrim)),
+
+		SaveInterval: time.Duration(getEnvWithDefaultInt("SAVE_INTERVAL_SECONDS", 0)) * time.Second,
+
+		ContextWindowTokens: getEnvWithDefaultInt("CONTEXT_WINDOW_TOKENS", 200000),
+
+		AutoClearOnLeave: getEnvWithDefaultBool("AUTO_CLEAR_ON_LEAVE", false),
+
+		AutoFlushInterval: time.Duration(getEnvWithDefaultInt("AUTO_FLUSH_INTERVAL_SECONDS", 10)) * time.Second,
+
+		CheckNarration: getEnvWithDefaultBool("CHECK_NARRATION", true),
+
+		SuggestPrompt: getEnvWithDefault("SUGGEST_PROMPT", "Based on the recent conversation, suggest 2-3 things the DM could do next."),
+
+		TranscriptionDebounce: time.Duration(getEnvWithDefaultInt("TRANSCRIPTION_DEBOUNCE_MS", 0)) * time.Millisecond,
+
+		// Transcription buffer throttling
+		TranscriptionBufferMaxLines: getEnvWithDefaultInt("TRANSCRIPTION_BUFFER_MAX_LINES", 50),
+		TranscriptionBufferMaxChars: getEnvWithDefaultInt("TRANSCRIPTION_BUFFER_MAX_CHARS", 8000),
+		FilterFillerTranscriptions:  getEnvWithDefaultBool("FILTER_FILLER_TRANSCRIPTIONS", false),
+		MinTranscriptionConfidence:  getEnvWithDefaultFloat("MIN_TRANSCRIPTION_CONFIDENCE", 0),
+		MinTranscriptionWords:       getEnvWithDefaultInt("MIN_TRANSCRIPTION_WORDS", 1),
+
+		LowConfidenceThreshold: getEnvWithDefaultFloat("LOW_CONFIDENCE_THRESHOLD", 0),
+		LowConfidenceMarker:    strings.TrimSpace(getEnvWithDefault("LOW_CONFIDENCE_MARKER", "(?)")),
+	}
+
+	guildFeatures, err := parseGuildFeatures(os.Getenv("GUILD_FEATURES"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid GUILD_FEATURES: %w", err)
+	}
+	config.GuildFeatures = guildFeatures
+	config.GuildConfigFile = guildConfigFile
+	config.Guilds = guilds
+
+	voiceCommands, err := parseVoiceCommands(getEnvWithDefault("VOICE_COMMANDS", defaultVoiceCommands))
+	if err != nil {
+		return nil, fmt.Errorf("invalid VOICE_COMMANDS: %w", err)
+	}
+	config.VoiceCommands = voiceCommands
+
+	quietHours, err := parseQuietHours(os.Getenv("QUIET_HOURS"), os.Getenv("QUIET_HOURS_TIMEZONE"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid QUIET_HOURS: %w", err)
+	}
+	config.QuietHours = quietHours
+
+	if len(config.DiscordIntents) == 0 {
+		config.DiscordIntents = slices.Clone(DefaultDiscordIntents)
+	}
+	for i, intent := range config.DiscordIntents {
+		config.DiscordIntents[i] = strings.ToLower(intent)
 	}
 
 	// Validate configuration
@@ -110,22 +496,292 @@ func (c *Config) validate() error {
 	// Validate Discord IDs (snowflakes)
 	discordIDRegex := regexp.MustCompile(discordIDPattern)
 
-	if !discordIDRegex.MatchString(c.DMUserID) {
+	// Both are optional when every guild is in the guild config file
+	if (c.DMUserID != "" || len(c.Guilds) == 0) && !discordIDRegex.MatchString(c.DMUserID) {
 		return fmt.Errorf("invalid DM user ID format: must be a Discord snowflake (17-19 digits)")
 	}
 
-	if !discordIDRegex.MatchString(c.DNDVoiceChannelID) {
+	if (c.DNDVoiceChannelID != "" || len(c.Guilds) == 0) && !discordIDRegex.MatchString(c.DNDVoiceChannelID) {
 		return fmt.Errorf("invalid D&D voice channel ID format: must be a Discord snowflake (17-19 digits)")
 	}
 
+	if err := c.validateGuildSettings(); err != nil {
+		return err
+	}
+
+	if c.PlayerRoleID != "" && !discordIDRegex.MatchString(c.PlayerRoleID) {
+		return fmt.Errorf("invalid player role ID format: must be a Discord snowflake (17-19 digits)")
+	}
+
+	if c.AnnounceChannelID != "" && !discordIDRegex.MatchString(c.AnnounceChannelID) {
+		return fmt.Errorf("invalid announce channel ID format: must be a Discord snowflake (17-19 digits)")
+	}
+
+	for _, id := range c.CoDMUserIDs {
+		if !discordIDRegex.MatchString(id) {
+			return fmt.Errorf("invalid co-DM user ID %q: must be a Discord snowflake (17-19 digits)", id)
+		}
+	}
+
+	for _, id := range c.IgnoredUserIDs {
+		if !discordIDRegex.MatchString(id) {
+			return fmt.Errorf("invalid ignored user ID %q: must be a Discord snowflake (17-19 digits)", id)
+		}
+	}
+
 	// Validate command prefix
 	if len(c.CommandPrefix) == 0 {
 		return fmt.Errorf("command prefix cannot be empty")
 	}
 
+	// Validate Anthropic headers
+	if !regexp.MustCompile(anthropicVersionPattern).MatchString(c.AnthropicVersion) {
+		return fmt.Errorf("invalid Anthropic API version %q: must be a date like 2023-06-01", c.AnthropicVersion)
+	}
+
+	betaRegex := regexp.MustCompile(anthropicBetaPattern)
+	for _, beta := range c.AnthropicBeta {
+		if !betaRegex.MatchString(beta) {
+			return fmt.Errorf("invalid Anthropic beta value %q", beta)
+		}
+	}
+
+	if c.SpeechBackend != SpeechBackendGoogle && c.SpeechBackend != SpeechBackendWhisper {
+		return fmt.Errorf("invalid speech backend %q: must be %q or %q", c.SpeechBackend, SpeechBackendGoogle, SpeechBackendWhisper)
+	}
+
+	if !slices.Contains(SpeechModels, c.SpeechModel) {
+		return fmt.Errorf("invalid speech model %q: must be one of %s", c.SpeechModel, strings.Join(SpeechModels, ", "))
+	}
+
+	switch c.LLMBackend {
+	case LLMBackendAnthropic:
+	case LLMBackendOpenAI:
+		if c.LLMModel == "" {
+			return fmt.Errorf("LLM_MODEL is required when LLM_BACKEND is %q", LLMBackendOpenAI)
+		}
+	default:
+		return fmt.Errorf("invalid LLM backend %q: must be %q or %q", c.LLMBackend, LLMBackendAnthropic, LLMBackendOpenAI)
+	}
+
+	if c.CompactStrategy != CompactStrategyTrim && c.CompactStrategy != CompactStrategySummarize {
+		return fmt.Errorf("invalid compact strategy %q: must be %q or %q", c.CompactStrategy, CompactStrategyTrim, CompactStrategySummarize)
+	}
+
+	if err := c.validateIntents(); err != nil {
+		return err
+	}
+
+	if c.ContextWindowTokens < 1000 {
+		return fmt.Errorf("context window must be at least 1000 tokens")
+	}
+
+	if c.SaveInterval < 0 {
+		return fmt.Errorf("save interval cannot be negative")
+	}
+
+	if len(c.CampaignName) > MaxCampaignNameLength {
+		return fmt.Errorf("campaign name must be at most %d characters", MaxCampaignNameLength)
+	}
+
+	if c.GuildLoadTimeout <= 0 {
+		return fmt.Errorf("guild load timeout must be positive")
+	}
+
+	if c.JoinWhileConnected != JoinWhileConnectedMove && c.JoinWhileConnected != JoinWhileConnectedRefuse {
+		return fmt.Errorf("invalid join-while-connected behavior %q: must be %q or %q",
+			c.JoinWhileConnected, JoinWhileConnectedMove, JoinWhileConnectedRefuse)
+	}
+
+	if c.DMLeaveGrace < 0 {
+		return fmt.Errorf("DM leave grace period cannot be negative")
+	}
+
+	// The gate measures decoded frames, and the Go Opus decoder can't decode the stereo CELT
+	// audio Discord clients send, so it would never close
+	if c.VoiceGateDB != 0 {
+		return fmt.Errorf("VOICE_GATE_DB is not supported yet: the Opus decoder can't decode Discord's audio")
+	}
+
+	// Opus only supports these rates, and Discord never sends more than 48kHz stereo
+	switch c.RecordingSampleRate {
+	case 8000, 12000, 16000, 24000, 48000:
+	default:
+		return fmt.Errorf("recording sample rate must be one of 8000, 12000, 16000, 24000 or 48000")
+	}
+
+	if c.RecordingChannels != 1 && c.RecordingChannels != 2 {
+		return fmt.Errorf("recording channels must be 1 (mono) or 2 (stereo)")
+	}
+
+	if c.AudioLeadIn < 0 || c.AudioLeadIn > 2*time.Second {
+		return fmt.Errorf("audio lead-in must be between 0 and 2000ms")
+	}
+	if c.AudioLeadIn > 0 && c.VoiceGateDB == 0 {
+		return fmt.Errorf("AUDIO_LEAD_IN_MS needs VOICE_GATE_DB, which is not supported yet")
+	}
+
+	if c.TranscribeSegmentLength < 0 || (c.TranscribeSegmentLength > 0 && c.TranscribeSegmentLength < minTranscribeSegmentLength) {
+		return fmt.Errorf("transcribe segment length must be 0 (off) or at least %v", minTranscribeSegmentLength)
+	}
+
+	if c.TranscribeConcurrency < 1 || c.TranscribeConcurrency > maxTranscribeConcurrency {
+		return fmt.Errorf("transcribe concurrency must be between 1 and %d", maxTranscribeConcurrency)
+	}
+
+	if c.MinPacketsToTranscribe < 0 {
+		return fmt.Errorf("minimum packets to transcribe cannot be negative")
+	}
+
+	if c.TranscriptionSpoolMaxMB < 1 {
+		return fmt.Errorf("transcription spool size must be at least 1 MB")
+	}
+
+	if c.MaxBufferAge < 0 || (c.MaxBufferAge > 0 && c.MaxBufferAge < minMaxBufferAge) {
+		return fmt.Errorf("max buffer age must be 0 (off) or at least %v", minMaxBufferAge)
+	}
+
+	if c.VoiceCommandsEnabled && c.VoiceWakeWord == "" {
+		return fmt.Errorf("voice wake word cannot be empty when voice commands are enabled")
+	}
+
+	if c.TranscriptionBufferMaxLines < 0 || c.TranscriptionBufferMaxChars < 0 {
+		return fmt.Errorf("transcription buffer limits cannot be negative")
+	}
+
+	if c.MinTranscriptionConfidence < 0 || c.MinTranscriptionConfidence > 1 {
+		return fmt.Errorf("minimum transcription confidence must be between 0 and 1")
+	}
+
+	if c.MinTranscriptionWords < 1 {
+		return fmt.Errorf("minimum transcription words must be at least 1")
+	}
+
+	if c.MaxConcurrentClaude < 1 {
+		return fmt.Errorf("maximum concurrent Claude requests must be at least 1")
+	}
+
+	if c.ArchiveMinAgeDays < 0 {
+		return fmt.Errorf("archive minimum age cannot be negative")
+	}
+
+	if c.ClaudeLogMaxMB < 0 {
+		return fmt.Errorf("maximum Claude log size cannot be negative")
+	}
+
+	if c.FindResults < 1 || c.FindResults > 20 {
+		return fmt.Errorf("find results must be between 1 and 20")
+	}
+
+	if c.AutoFlushInterval < 0 {
+		return fmt.Errorf("auto-flush interval cannot be negative")
+	}
+
+	if c.TranscriptionDebounce < 0 || c.TranscriptionDebounce > 5*time.Second {
+		return fmt.Errorf("transcription debounce must be between 0 and 5000 ms")
+	}
+
+	if c.LowConfidenceThreshold < 0 || c.LowConfidenceThreshold > 1 {
+		return fmt.Errorf("low confidence threshold must be between 0 and 1")
+	}
+
+	if c.LowConfidenceThreshold > 0 && c.LowConfidenceMarker == "" {
+		return fmt.Errorf("low confidence marker cannot be empty when the threshold is set")
+	}
+
+	if c.CommandEditWindow <= 0 {
+		return fmt.Errorf("command edit window must be positive")
+	}
+
 	return nil
 }
 
+// ClaudeEnabledForGuild reports whether Claude is enabled in the guild, falling back to the global setting
+func (c *Config) ClaudeEnabledForGuild(guildID string, global bool) bool {
+	if features, ok := c.GuildFeatures[guildID]; ok && features.ClaudeEnabled != nil {
+		return global && *features.ClaudeEnabled
+	}
+	return global
+}
+
+// SpeechEnabledForGuild reports whether speech-to-text is enabled in the guild, falling back to the global setting
+func (c *Config) SpeechEnabledForGuild(guildID string, global bool) bool {
+	if features, ok := c.GuildFeatures[guildID]; ok && features.SpeechEnabled != nil {
+		return global && *features.SpeechEnabled
+	}
+	return global
+}
+
+// parseGuildFeatures parses per-guild feature overrides in the form
+// "<guildID>:claude=false,speech=true;<guildID>:claude=false"
+func parseGuildFeatures(value string) (map[string]GuildFeatures, error) {
+	features := make(map[string]GuildFeatures)
+	if strings.TrimSpace(value) == "" {
+		return features, nil
+	}
+
+	discordIDRegex := regexp.MustCompile(discordIDPattern)
+
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		guildID, flags, found := strings.Cut(entry, ":")
+		guildID = strings.TrimSpace(guildID)
+		if !found || !discordIDRegex.MatchString(guildID) {
+			return nil, fmt.Errorf("entry %q must start with a guild ID followed by ':'", entry)
+		}
+
+		var gf GuildFeatures
+		for _, flag := range strings.Split(flags, ",") {
+			name, rawValue, found := strings.Cut(strings.TrimSpace(flag), "=")
+			if !found {
+				return nil, fmt.Errorf("flag %q for guild %s must be name=value", flag, guildID)
+			}
+
+			enabled, err := strconv.ParseBool(strings.TrimSpace(rawValue))
+			if err != nil {
+				return nil, fmt.Errorf("flag %q for guild %s has an invalid value", flag, guildID)
+			}
+
+			switch strings.ToLower(strings.TrimSpace(name)) {
+			case "claude", "claude_enabled":
+				gf.ClaudeEnabled = &enabled
+			case "speech", "speech_enabled":
+				gf.SpeechEnabled = &enabled
+			default:
+				return nil, fmt.Errorf("unknown feature %q for guild %s", name, guildID)
+			}
+		}
+
+		features[guildID] = gf
+	}
+
+	return features, nil
+}
+
+// parseVoiceCommands parses spoken phrase mappings in the form "phrase=command args;phrase=command"
+func parseVoiceCommands(value string) (map[string]string, error) {
+	commands := make(map[string]string)
+	for _, entry := range strings.Split(value, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+
+		phrase, command, found := strings.Cut(entry, "=")
+		phrase = strings.ToLower(strings.TrimSpace(phrase))
+		command = strings.TrimSpace(command)
+		if !found || phrase == "" || command == "" {
+			return nil, fmt.Errorf("entry %q must be phrase=command", entry)
+		}
+
+		commands[phrase] = command
+	}
+	return commands, nil
+}
+
 // getEnvWithDefault returns environment variable value or default if not set
 func getEnvWithDefault(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -143,3 +799,60 @@ func getEnvWithDefaultInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+// getEnvList returns a comma-separated environment variable as a list, skipping empty entries
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getEnvWithDefaultFloat returns environment variable value as float64 or default if not set/invalid
+func getEnvWithDefaultFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+// getEnvWithDefaultBool returns environment variable value as bool or default if not set/invalid
+func getEnvWithDefaultBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+// validateIntents checks that the DISCORD_INTENTS names are known and cover every feature
+func (c *Config) validateIntents() error {
+	for _, name := range c.DiscordIntents {
+		if name == IntentAll {
+			continue
+		}
+		known := slices.Contains(optionalIntents, name)
+		for _, required := range requiredIntents {
+			known = known || required.name == name
+		}
+		if !known {
+			return fmt.Errorf("unknown Discord intent %q", name)
+		}
+	}
+
+	if slices.Contains(c.DiscordIntents, IntentAll) {
+		return nil
+	}
+	for _, required := range requiredIntents {
+		if !slices.Contains(c.DiscordIntents, required.name) {
+			return fmt.Errorf("DISCORD_INTENTS must include %q: %s", required.name, required.reason)
+		}
+	}
+	return nil
+}
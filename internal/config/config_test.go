@@ -0,0 +1,214 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	testToken     = "MTIzNDU2Nzg5MDEyMzQ1Njc4.GabCde.abcdefghijklmnopqrstuvwxyz0123456789"
+	testDMUserID  = "123456789012345678"
+	testChannelID = "234567890123456789"
+)
+
+// setRequiredEnv sets the variables Load needs and runs it from an empty directory, so a
+// developer's .env can't leak into the test
+func setRequiredEnv(t *testing.T) {
+	t.Helper()
+	t.Chdir(t.TempDir())
+	t.Setenv("DISCORD_BOT_TOKEN", testToken)
+	t.Setenv("DM_USER_ID", testDMUserID)
+	t.Setenv("DND_VOICE_CHANNEL_ID", testChannelID)
+}
+
+func TestLoadAcceptsValidIDs(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("CO_DM_USER_IDS", "34567890123456789,4567890123456789012")
+	t.Setenv("PLAYER_ROLE_ID", "345678901234567890")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.DMUserID != testDMUserID || cfg.DNDVoiceChannelID != testChannelID {
+		t.Errorf("loaded DM %q and channel %q", cfg.DMUserID, cfg.DNDVoiceChannelID)
+	}
+	if len(cfg.CoDMUserIDs) != 2 {
+		t.Errorf("co-DMs = %q, want two", cfg.CoDMUserIDs)
+	}
+}
+
+func TestLoadRejectsMalformedIDs(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		value   string
+		wantErr string
+	}{
+		{"DM with letters", "DM_USER_ID", "12345678901234567a", "invalid DM user ID format"},
+		{"DM too short", "DM_USER_ID", "1234567890123456", "invalid DM user ID format"},
+		{"DM too long", "DM_USER_ID", "12345678901234567890", "invalid DM user ID format"},
+		{"channel mention", "DND_VOICE_CHANNEL_ID", "<#234567890123456789>", "invalid D&D voice channel ID format"},
+		{"player role", "PLAYER_ROLE_ID", "players", "invalid player role ID format"},
+		{"announce channel", "ANNOUNCE_CHANNEL_ID", "12345", "invalid announce channel ID format"},
+		{"co-DM", "CO_DM_USER_IDS", "345678901234567890,alice", `invalid co-DM user ID "alice"`},
+		{"ignored user", "IGNORED_USER_IDS", "@bob", `invalid ignored user ID "@bob"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setRequiredEnv(t)
+			t.Setenv(tt.key, tt.value)
+
+			_, err := Load()
+			if err == nil {
+				t.Fatalf("%s=%q was accepted", tt.key, tt.value)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %q, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadRefusesVoiceGate(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		value   string
+		wantErr string
+	}{
+		{"voice gate", "VOICE_GATE_DB", "-50", "VOICE_GATE_DB is not supported"},
+		{"lead-in without the gate", "AUDIO_LEAD_IN_MS", "300", "AUDIO_LEAD_IN_MS needs VOICE_GATE_DB"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setRequiredEnv(t)
+			t.Setenv(tt.key, tt.value)
+
+			_, err := Load()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadRequiresDMAndChannelWithoutGuildConfig(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("DM_USER_ID", "")
+
+	_, err := Load()
+	if err == nil || !strings.Contains(err.Error(), "DM_USER_ID") {
+		t.Errorf("error = %v, want DM_USER_ID reported missing", err)
+	}
+}
+
+func TestLoadValidatesGuildConfigFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		wantErr string
+	}{
+		{
+			name: "valid",
+			file: `{"345678901234567890": {"dm_user_id": "123456789012345678", "voice_channel_id": "234567890123456789"}}`,
+		},
+		{
+			name:    "guild name as key",
+			file:    `{"my table": {"dm_user_id": "123456789012345678", "voice_channel_id": "234567890123456789"}}`,
+			wantErr: `guild config entry "my table": the key must be a guild ID`,
+		},
+		{
+			name:    "bad DM",
+			file:    `{"345678901234567890": {"dm_user_id": "dm", "voice_channel_id": "234567890123456789"}}`,
+			wantErr: `invalid dm_user_id "dm"`,
+		},
+		{
+			name:    "missing channel",
+			file:    `{"345678901234567890": {"dm_user_id": "123456789012345678"}}`,
+			wantErr: `invalid voice_channel_id ""`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setRequiredEnv(t)
+			// Guilds in the file have their own DM and channel, so the global ones aren't needed
+			t.Setenv("DM_USER_ID", "")
+			t.Setenv("DND_VOICE_CHANNEL_ID", "")
+
+			path := filepath.Join(t.TempDir(), "guilds.json")
+			if err := os.WriteFile(path, []byte(tt.file), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv("GUILD_CONFIG_FILE", path)
+
+			cfg, err := Load()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Load: %v", err)
+				}
+				if got := cfg.DMUserFor("345678901234567890"); got != "123456789012345678" {
+					t.Errorf("DMUserFor = %q, want the guild's DM", got)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIsDMChecksTheGivenGuild(t *testing.T) {
+	cfg := &Config{
+		DMUserID: "dm",
+		Guilds: map[string]GuildSettings{
+			"guildA": {DMUserID: "dmA"},
+			"guildB": {DMUserID: "dmB"},
+		},
+	}
+
+	tests := []struct {
+		guildID, userID string
+		want            bool
+	}{
+		{"guildA", "dmA", true},
+		{"guildA", "dmB", false},
+		{"guildB", "dmB", true},
+		{"other", "dm", true},
+		{"other", "dmA", false},
+		// Without a guild the DM of some configured guild doesn't count
+		{"", "dmA", false},
+		{"", "dm", true},
+	}
+	for _, tt := range tests {
+		if got := cfg.IsDM(tt.guildID, tt.userID); got != tt.want {
+			t.Errorf("IsDM(%q, %q) = %v, want %v", tt.guildID, tt.userID, got, tt.want)
+		}
+	}
+
+	// With no DM_USER_ID nobody is the DM of an unconfigured guild
+	cfg.DMUserID = ""
+	if cfg.IsDM("", "") {
+		t.Error("an empty user ID is the DM when DM_USER_ID isn't set")
+	}
+}
+
+func TestIsDMOfAnyGuild(t *testing.T) {
+	cfg := &Config{Guilds: map[string]GuildSettings{"guildA": {DMUserID: "dmA"}}}
+	if !cfg.IsDMOfAnyGuild("dmA") {
+		t.Error("a guild's DM isn't the DM of any guild")
+	}
+	if cfg.IsDMOfAnyGuild("player") || cfg.IsDMOfAnyGuild("") {
+		t.Error("a player is the DM of some guild")
+	}
+	cfg.DMUserID = "dm"
+	if !cfg.IsDMOfAnyGuild("dm") {
+		t.Error("DM_USER_ID isn't the DM of any guild")
+	}
+}
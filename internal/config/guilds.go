@@ -0,0 +1,121 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"slices"
+)
+
+// GuildSettings is a guild's own DM and D&D voice channel, from GUILD_CONFIG_FILE
+type GuildSettings struct {
+	DMUserID       string `json:"dm_user_id"`
+	VoiceChannelID string `json:"voice_channel_id"`
+}
+
+// loadGuildSettings reads a JSON file mapping guild IDs to their settings. A missing file
+// isn't an error: the bot falls back to DM_USER_ID and DND_VOICE_CHANNEL_ID.
+func loadGuildSettings(path string) (map[string]GuildSettings, error) {
+	guilds := make(map[string]GuildSettings)
+	if path == "" {
+		return guilds, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		log.Printf("Guild config file %s not found - using DM_USER_ID and DND_VOICE_CHANNEL_ID", path)
+		return guilds, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &guilds); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return guilds, nil
+}
+
+// validateGuildSettings checks every ID in the guild config file, naming the malformed entry
+func (c *Config) validateGuildSettings() error {
+	discordIDRegex := regexp.MustCompile(discordIDPattern)
+
+	guildIDs := make([]string, 0, len(c.Guilds))
+	for guildID := range c.Guilds {
+		guildIDs = append(guildIDs, guildID)
+	}
+	slices.Sort(guildIDs)
+
+	for _, guildID := range guildIDs {
+		settings := c.Guilds[guildID]
+		if !discordIDRegex.MatchString(guildID) {
+			return fmt.Errorf("guild config entry %q: the key must be a guild ID (Discord snowflake, 17-19 digits)", guildID)
+		}
+		if !discordIDRegex.MatchString(settings.DMUserID) {
+			return fmt.Errorf("guild config entry %s: invalid dm_user_id %q: must be a Discord snowflake (17-19 digits)", guildID, settings.DMUserID)
+		}
+		if !discordIDRegex.MatchString(settings.VoiceChannelID) {
+			return fmt.Errorf("guild config entry %s: invalid voice_channel_id %q: must be a Discord snowflake (17-19 digits)", guildID, settings.VoiceChannelID)
+		}
+	}
+	return nil
+}
+
+// DMUserFor returns the DM of a guild: its entry in the guild config file, or DM_USER_ID
+func (c *Config) DMUserFor(guildID string) string {
+	if settings, ok := c.Guilds[guildID]; ok {
+		return settings.DMUserID
+	}
+	return c.DMUserID
+}
+
+// VoiceChannelFor returns the D&D voice channel of a guild: its entry in the guild config
+// file, or DND_VOICE_CHANNEL_ID
+func (c *Config) VoiceChannelFor(guildID string) string {
+	if settings, ok := c.Guilds[guildID]; ok {
+		return settings.VoiceChannelID
+	}
+	return c.DNDVoiceChannelID
+}
+
+// IsDM reports whether the user is the DM of the guild. With no guild only DM_USER_ID counts,
+// so a command sent in a direct message has to work out which guild it targets first.
+func (c *Config) IsDM(guildID, userID string) bool {
+	dm := c.DMUserFor(guildID)
+	return dm != "" && userID == dm
+}
+
+// IsDMOfAnyGuild reports whether the user is DM_USER_ID or the DM of a guild in the guild
+// config file. It only gates commands that then limit themselves to the user's own guilds.
+func (c *Config) IsDMOfAnyGuild(userID string) bool {
+	if userID == "" {
+		return false
+	}
+	if userID == c.DMUserID {
+		return true
+	}
+	for _, settings := range c.Guilds {
+		if userID == settings.DMUserID {
+			return true
+		}
+	}
+	return false
+}
+
+// VoiceChannelIDs returns every D&D voice channel the bot watches, in a stable order
+func (c *Config) VoiceChannelIDs() []string {
+	var ids []string
+	if c.DNDVoiceChannelID != "" {
+		ids = append(ids, c.DNDVoiceChannelID)
+	}
+	for _, settings := range c.Guilds {
+		if !slices.Contains(ids, settings.VoiceChannelID) {
+			ids = append(ids, settings.VoiceChannelID)
+		}
+	}
+	slices.Sort(ids)
+	return ids
+}
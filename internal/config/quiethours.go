@@ -0,0 +1,88 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// QuietHours is a daily window during which the bot doesn't post on its own. The window may
+// wrap past midnight, e.g. 22:00-08:00. The zero value never matches.
+type QuietHours struct {
+	Start    time.Duration // Offset into the day
+	End      time.Duration
+	Location *time.Location
+}
+
+// Enabled reports whether a quiet window is configured
+func (q QuietHours) Enabled() bool {
+	return q.Location != nil && q.Start != q.End
+}
+
+// Contains reports whether t falls within the quiet window
+func (q QuietHours) Contains(t time.Time) bool {
+	if !q.Enabled() {
+		return false
+	}
+
+	t = t.In(q.Location)
+	now := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	if q.Start < q.End {
+		return now >= q.Start && now < q.End
+	}
+	return now >= q.Start || now < q.End
+}
+
+// String formats the window as it's configured, e.g. "22:00-08:00 (Europe/London)"
+func (q QuietHours) String() string {
+	if !q.Enabled() {
+		return "off"
+	}
+	return fmt.Sprintf("%s-%s (%s)", formatClock(q.Start), formatClock(q.End), q.Location)
+}
+
+// parseQuietHours parses a window such as "22:00-08:00" in the named time zone ("" = local time)
+func parseQuietHours(window, zone string) (QuietHours, error) {
+	if strings.TrimSpace(window) == "" {
+		return QuietHours{}, nil
+	}
+
+	start, end, found := strings.Cut(window, "-")
+	if !found {
+		return QuietHours{}, fmt.Errorf("%q must be in the form HH:MM-HH:MM", window)
+	}
+
+	var quiet QuietHours
+	var err error
+	if quiet.Start, err = parseClock(start); err != nil {
+		return QuietHours{}, err
+	}
+	if quiet.End, err = parseClock(end); err != nil {
+		return QuietHours{}, err
+	}
+	if quiet.Start == quiet.End {
+		return QuietHours{}, fmt.Errorf("%q starts and ends at the same time", window)
+	}
+
+	quiet.Location = time.Local
+	if zone = strings.TrimSpace(zone); zone != "" {
+		if quiet.Location, err = time.LoadLocation(zone); err != nil {
+			return QuietHours{}, fmt.Errorf("unknown time zone %q", zone)
+		}
+	}
+	return quiet, nil
+}
+
+// parseClock parses an HH:MM time of day into an offset into the day
+func parseClock(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a time of day in the form HH:MM", strings.TrimSpace(value))
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// formatClock formats an offset into the day as HH:MM
+func formatClock(offset time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(offset.Hours()), int(offset.Minutes())%60)
+}
@@ -0,0 +1,94 @@
+package dnd
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+)
+
+// RollMode is whether a d20 roll has advantage, disadvantage or neither
+type RollMode int
+
+const (
+	Normal RollMode = iota
+	Advantage
+	Disadvantage
+)
+
+// String returns the roll mode name
+func (m RollMode) String() string {
+	return [...]string{"normal", "advantage", "disadvantage"}[m]
+}
+
+// ParseRollMode parses "adv"/"advantage" or "dis"/"disadvantage"
+func ParseRollMode(name string) (RollMode, bool) {
+	switch strings.ToLower(name) {
+	case "adv", "advantage":
+		return Advantage, true
+	case "dis", "disadv", "disadvantage":
+		return Disadvantage, true
+	}
+	return Normal, false
+}
+
+// Ability names accepted by ParseAbility, keyed by every accepted spelling
+var abilities = map[string]string{
+	"str": "Strength", "strength": "Strength",
+	"dex": "Dexterity", "dexterity": "Dexterity",
+	"con": "Constitution", "constitution": "Constitution",
+	"int": "Intelligence", "intelligence": "Intelligence",
+	"wis": "Wisdom", "wisdom": "Wisdom",
+	"cha": "Charisma", "charisma": "Charisma",
+}
+
+// ParseAbility returns the full name of an ability given its name or abbreviation, e.g. "dex"
+func ParseAbility(name string) (string, error) {
+	if ability, ok := abilities[strings.ToLower(name)]; ok {
+		return ability, nil
+	}
+	return "", fmt.Errorf("unknown ability %q (use str, dex, con, int, wis or cha)", name)
+}
+
+const (
+	MinDC = 1
+	MaxDC = 40
+)
+
+// CheckResult is the outcome of an ability check
+type CheckResult struct {
+	Rolls    []int // Both d20s with advantage or disadvantage, otherwise one
+	Kept     int   // The d20 that counts
+	Modifier int
+	Total    int
+	DC       int
+	Mode     RollMode
+	Success  bool
+}
+
+// RollCheck rolls a d20 (two with advantage or disadvantage) plus the modifier against a DC.
+// Ability checks have no automatic success or failure on a natural 20 or 1.
+func RollCheck(modifier, dc int, mode RollMode) (CheckResult, error) {
+	if dc < MinDC || dc > MaxDC {
+		return CheckResult{}, fmt.Errorf("DC must be between %d and %d", MinDC, MaxDC)
+	}
+
+	result := CheckResult{Modifier: modifier, DC: dc, Mode: mode}
+	result.Rolls = []int{rollD20()}
+	result.Kept = result.Rolls[0]
+	if mode != Normal {
+		second := rollD20()
+		result.Rolls = append(result.Rolls, second)
+		if (mode == Advantage && second > result.Kept) || (mode == Disadvantage && second < result.Kept) {
+			result.Kept = second
+		}
+	}
+
+	result.Total = result.Kept + modifier
+	result.Success = result.Total >= dc
+	return result, nil
+}
+
+// rollD20 rolls a twenty-sided die
+func rollD20() int {
+	return rand.IntN(20) + 1
+}
@@ -0,0 +1,133 @@
+package dnd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Difficulty is an encounter difficulty from the 5e encounter-building guidelines
+type Difficulty int
+
+const (
+	Easy Difficulty = iota
+	Medium
+	Hard
+	Deadly
+)
+
+// String returns the difficulty name
+func (d Difficulty) String() string {
+	return [...]string{"easy", "medium", "hard", "deadly"}[d]
+}
+
+// ParseDifficulty parses a difficulty name such as "hard"
+func ParseDifficulty(name string) (Difficulty, error) {
+	switch strings.ToLower(name) {
+	case "easy":
+		return Easy, nil
+	case "medium":
+		return Medium, nil
+	case "hard":
+		return Hard, nil
+	case "deadly":
+		return Deadly, nil
+	}
+	return 0, fmt.Errorf("unknown difficulty %q (use easy, medium, hard or deadly)", name)
+}
+
+const (
+	MinLevel = 1
+	MaxLevel = 20
+
+	MinPartySize = 1
+	MaxPartySize = 10
+)
+
+// xpThresholds holds the per-character XP thresholds by level (DMG p. 82)
+var xpThresholds = [MaxLevel + 1][4]int{
+	1:  {25, 50, 75, 100},
+	2:  {50, 100, 150, 200},
+	3:  {75, 150, 225, 400},
+	4:  {125, 250, 375, 500},
+	5:  {250, 500, 750, 1100},
+	6:  {300, 600, 900, 1400},
+	7:  {350, 750, 1100, 1700},
+	8:  {450, 900, 1400, 2100},
+	9:  {550, 1100, 1600, 2400},
+	10: {600, 1200, 1900, 2800},
+	11: {800, 1600, 2400, 3600},
+	12: {1000, 2000, 3000, 4500},
+	13: {1100, 2200, 3400, 5100},
+	14: {1250, 2500, 3800, 5700},
+	15: {1400, 2800, 4300, 6400},
+	16: {1600, 3200, 4800, 7200},
+	17: {2000, 3900, 5900, 8800},
+	18: {2100, 4200, 6300, 9500},
+	19: {2400, 4900, 7300, 10900},
+	20: {2800, 5700, 8500, 12700},
+}
+
+// encounterMultipliers are the XP multipliers for the number of monsters (DMG p. 82),
+// from a single monster up to 15 or more, with one extra step at each end for small and large parties
+var encounterMultipliers = []float64{0.5, 1, 1.5, 2, 2.5, 3, 4, 5}
+
+// monsterCountBand maps a number of monsters to its multiplier index for a party of three to five
+func monsterCountBand(count int) int {
+	switch {
+	case count <= 1:
+		return 1
+	case count == 2:
+		return 2
+	case count <= 6:
+		return 3
+	case count <= 10:
+		return 4
+	case count <= 14:
+		return 5
+	default:
+		return 6
+	}
+}
+
+// Encounter is the XP budget for an encounter
+type Encounter struct {
+	PartyLevel int
+	PartySize  int
+	Difficulty Difficulty
+	XPBudget   int // Sum of the party's thresholds for the difficulty
+}
+
+// BuildEncounter computes the XP budget for a party of the given level and size
+func BuildEncounter(partyLevel, partySize int, difficulty Difficulty) (Encounter, error) {
+	if partyLevel < MinLevel || partyLevel > MaxLevel {
+		return Encounter{}, fmt.Errorf("party level must be between %d and %d", MinLevel, MaxLevel)
+	}
+	if partySize < MinPartySize || partySize > MaxPartySize {
+		return Encounter{}, fmt.Errorf("party size must be between %d and %d", MinPartySize, MaxPartySize)
+	}
+
+	return Encounter{
+		PartyLevel: partyLevel,
+		PartySize:  partySize,
+		Difficulty: difficulty,
+		XPBudget:   xpThresholds[partyLevel][difficulty] * partySize,
+	}, nil
+}
+
+// Multiplier returns the XP multiplier for a number of monsters, adjusted for party size:
+// parties of fewer than three use the next higher multiplier, six or more the next lower
+func (e Encounter) Multiplier(monsters int) float64 {
+	band := monsterCountBand(monsters)
+	switch {
+	case e.PartySize < 3:
+		band++
+	case e.PartySize >= 6:
+		band--
+	}
+	return encounterMultipliers[band]
+}
+
+// MaxMonsterXP returns the most XP each of n identical monsters can be worth within the budget
+func (e Encounter) MaxMonsterXP(monsters int) int {
+	return int(float64(e.XPBudget) / (float64(monsters) * e.Multiplier(monsters)))
+}
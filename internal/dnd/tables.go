@@ -0,0 +1,108 @@
+package dnd
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"strings"
+)
+
+// TableEntry is one result on a random table. Entries with a higher weight come up
+// proportionally more often.
+type TableEntry struct {
+	Text   string `json:"text"`
+	Weight int    `json:"weight"`
+}
+
+// UnmarshalJSON accepts either a plain string (weight 1) or a {"text", "weight"} object
+func (e *TableEntry) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*e = TableEntry{Text: text, Weight: 1}
+		return nil
+	}
+
+	type entry TableEntry
+	parsed := entry{Weight: 1}
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return fmt.Errorf("entry must be a string or an object with text and weight: %w", err)
+	}
+	*e = TableEntry(parsed)
+	return nil
+}
+
+// Table is a named random table, such as tavern names or weather
+type Table struct {
+	Name    string
+	Entries []TableEntry
+	total   int // Sum of the entry weights
+}
+
+// Roll picks an entry at random according to the weights
+func (t *Table) Roll() TableEntry {
+	n := rand.IntN(t.total)
+	for _, entry := range t.Entries {
+		if n < entry.Weight {
+			return entry
+		}
+		n -= entry.Weight
+	}
+	return t.Entries[len(t.Entries)-1]
+}
+
+// Tables is a set of random tables keyed by lowercase name
+type Tables map[string]*Table
+
+// Names returns the table names in alphabetical order
+func (t Tables) Names() []string {
+	names := make([]string, 0, len(t))
+	for name := range t {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// LoadTables reads random tables from a JSON file mapping table names to lists of entries,
+// e.g. {"weather": ["Clear", {"text": "Storm", "weight": 2}]}. Names are case-insensitive.
+func LoadTables(path string) (Tables, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tables file: %w", err)
+	}
+
+	var raw map[string][]TableEntry
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse tables file %s: %w", path, err)
+	}
+
+	tables := make(Tables, len(raw))
+	for name, entries := range raw {
+		key := strings.ToLower(strings.TrimSpace(name))
+		if key == "" || strings.ContainsAny(key, " \t\n") {
+			return nil, fmt.Errorf("table name %q must be a single word", name)
+		}
+		if _, exists := tables[key]; exists {
+			return nil, fmt.Errorf("table %q is defined more than once", key)
+		}
+		if len(entries) == 0 {
+			return nil, fmt.Errorf("table %q has no entries", key)
+		}
+
+		table := &Table{Name: key, Entries: entries}
+		for i, entry := range entries {
+			if strings.TrimSpace(entry.Text) == "" {
+				return nil, fmt.Errorf("table %q entry %d has no text", key, i+1)
+			}
+			if entry.Weight < 1 {
+				return nil, fmt.Errorf("table %q entry %d has weight %d (must be at least 1)", key, i+1, entry.Weight)
+			}
+			table.total += entry.Weight
+		}
+		tables[key] = table
+	}
+
+	return tables, nil
+}
@@ -0,0 +1,109 @@
+// Package paths lays out the files the bot writes under a single data directory
+package paths
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Dirs is the data directory layout:
+//
+//	<root>/recordings     OGG recordings, speaker maps and failed-transcription audio
+//	<root>/transcripts    exported transcripts and the transcript search index
+//	<root>/conversations  Claude conversation history
+//	<root>/prompts        named system prompts, one <name>.txt each
+//	<root>/spool          transcription batches waiting for a free queue, per guild
+//	<root>/*.json         small state files such as the ignored users list
+type Dirs struct {
+	Root string
+}
+
+// New returns the layout rooted at root
+func New(root string) Dirs {
+	return Dirs{Root: root}
+}
+
+// Recordings returns the directory for audio files
+func (d Dirs) Recordings() string {
+	return filepath.Join(d.Root, "recordings")
+}
+
+// Transcripts returns the directory for exported transcripts
+func (d Dirs) Transcripts() string {
+	return filepath.Join(d.Root, "transcripts")
+}
+
+// Conversations returns the directory for conversation history files
+func (d Dirs) Conversations() string {
+	return filepath.Join(d.Root, "conversations")
+}
+
+// Prompts returns the directory for saved system prompts
+func (d Dirs) Prompts() string {
+	return filepath.Join(d.Root, "prompts")
+}
+
+// Spool returns the directory for transcription batches saved while the queue was full
+func (d Dirs) Spool() string {
+	return filepath.Join(d.Root, "spool")
+}
+
+// Conversation returns the path of a conversation file. Absolute paths are used as given.
+func (d Dirs) Conversation(name string) string {
+	return resolve(d.Conversations(), name)
+}
+
+// MoveLegacyConversation moves a conversation file from where it was kept before the data
+// directory existed (relative names were used as given, in the working directory) to
+// Conversation(name). It does nothing if the file is already in place or there's no old one,
+// and reports whether it moved anything.
+func (d Dirs) MoveLegacyConversation(name string) (bool, error) {
+	if name == "" || filepath.IsAbs(name) {
+		return false, nil
+	}
+
+	target := d.Conversation(name)
+	if _, err := os.Stat(target); err == nil {
+		return false, nil
+	}
+	if info, err := os.Stat(name); err != nil || !info.Mode().IsRegular() {
+		return false, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return false, fmt.Errorf("failed to create %s: %w", filepath.Dir(target), err)
+	}
+	if err := os.Rename(name, target); err != nil {
+		return false, fmt.Errorf("failed to move %s to %s: %w", name, target, err)
+	}
+	return true, nil
+}
+
+// SearchIndex returns the path of the transcript search index. Absolute paths are used as given.
+func (d Dirs) SearchIndex(name string) string {
+	return resolve(d.Transcripts(), name)
+}
+
+// State returns the path of a small state file kept at the top of the data directory
+func (d Dirs) State(name string) string {
+	return filepath.Join(d.Root, name)
+}
+
+// Create creates the data directory and its subdirectories
+func (d Dirs) Create() error {
+	for _, dir := range []string{d.Recordings(), d.Transcripts(), d.Conversations(), d.Prompts()} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create data directory %s: %w", dir, err)
+		}
+	}
+	return nil
+}
+
+// resolve places a relative name inside dir
+func resolve(dir, name string) string {
+	if name == "" || filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(dir, name)
+}
@@ -0,0 +1,65 @@
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// inTempDir runs the test from a fresh working directory, where old conversation files were kept
+func inTempDir(t *testing.T) {
+	t.Helper()
+	t.Chdir(t.TempDir())
+}
+
+func TestMoveLegacyConversation(t *testing.T) {
+	inTempDir(t)
+	if err := os.WriteFile("dnd_conversation.json", []byte(`{"messages":[]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dirs := New("data")
+
+	moved, err := dirs.MoveLegacyConversation("dnd_conversation.json")
+	if err != nil || !moved {
+		t.Fatalf("MoveLegacyConversation = %v, %v; want the file moved", moved, err)
+	}
+	data, err := os.ReadFile(filepath.Join("data", "conversations", "dnd_conversation.json"))
+	if err != nil || string(data) != `{"messages":[]}` {
+		t.Fatalf("moved file = %q, %v", data, err)
+	}
+	if _, err := os.Stat("dnd_conversation.json"); !os.IsNotExist(err) {
+		t.Error("old file is still there")
+	}
+
+	// Once moved there's nothing left to do
+	if moved, err := dirs.MoveLegacyConversation("dnd_conversation.json"); err != nil || moved {
+		t.Errorf("second move = %v, %v; want nothing done", moved, err)
+	}
+}
+
+func TestMoveLegacyConversationKeepsNewerFile(t *testing.T) {
+	inTempDir(t)
+	dirs := New("data")
+	if err := dirs.Create(); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile("dnd_conversation.json", []byte("old"), 0644)
+	os.WriteFile(dirs.Conversation("dnd_conversation.json"), []byte("new"), 0644)
+
+	if moved, err := dirs.MoveLegacyConversation("dnd_conversation.json"); err != nil || moved {
+		t.Fatalf("MoveLegacyConversation = %v, %v; want nothing done", moved, err)
+	}
+	if data, _ := os.ReadFile(dirs.Conversation("dnd_conversation.json")); string(data) != "new" {
+		t.Errorf("conversation = %q, want the file already in the data directory", data)
+	}
+}
+
+func TestMoveLegacyConversationAbsolutePath(t *testing.T) {
+	inTempDir(t)
+	path := filepath.Join(t.TempDir(), "campaign.json")
+	os.WriteFile(path, []byte("{}"), 0644)
+
+	if moved, err := New("data").MoveLegacyConversation(path); err != nil || moved {
+		t.Fatalf("MoveLegacyConversation = %v, %v; absolute paths are used as given", moved, err)
+	}
+}
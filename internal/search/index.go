@@ -0,0 +1,327 @@
+// Package search embeds finished transcriptions so past sessions can be searched by meaning
+package search
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// Transcriptions embedded per request
+	defaultBatchSize = 32
+
+	// Longest a transcription waits for its batch to fill up
+	defaultBatchInterval = 30 * time.Second
+
+	// Transcriptions kept waiting while the embeddings backend is down; the oldest are dropped
+	maxPending = 1000
+)
+
+// Options holds the embeddings endpoint and where the index is kept
+type Options struct {
+	// OpenAI-compatible API; point it at a local server to run offline
+	BaseURL string
+	APIKey  string // May be empty for local servers
+	Model   string
+
+	// JSON lines file holding every embedded transcription (empty = memory only)
+	File string
+
+	BatchSize     int
+	BatchInterval time.Duration
+}
+
+// Entry is one embedded transcription
+type Entry struct {
+	Time     time.Time `json:"time"`
+	GuildID  string    `json:"guild_id,omitempty"` // Empty for entries indexed before guilds were recorded
+	Campaign string    `json:"campaign,omitempty"`
+	Speaker  string    `json:"speaker,omitempty"`
+	Text     string    `json:"text"`
+	Vector   []float32 `json:"vector"`
+}
+
+// Match is an entry found by a search, with its cosine similarity to the query
+type Match struct {
+	Entry
+	Score float64
+}
+
+// Index embeds transcriptions in batches in the background and searches them. Transcriptions
+// wait in memory while the embeddings backend is unavailable and are retried with the next batch.
+type Index struct {
+	client  *client
+	options Options
+	debug   atomic.Bool
+
+	entries []Entry
+	pending []Entry // Waiting to be embedded, without vectors
+	dropped int     // Dropped from the front of pending since the current batch was taken
+	mutex   sync.Mutex
+
+	// Serializes embedding so batches are stored in the order they were said
+	embedMutex sync.Mutex
+
+	errorCallback func(err error)
+	wake          chan struct{}
+	stop          chan struct{}
+	done          chan struct{}
+}
+
+// NewIndex loads the entries saved in the index file and starts embedding in the background
+func NewIndex(debug bool, opts Options) (*Index, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBatchSize
+	}
+	if opts.BatchInterval <= 0 {
+		opts.BatchInterval = defaultBatchInterval
+	}
+
+	index := &Index{
+		client:  newClient(opts.BaseURL, opts.APIKey, opts.Model),
+		options: opts,
+		wake:    make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	index.debug.Store(debug)
+
+	if err := index.load(); err != nil {
+		return nil, err
+	}
+
+	go index.embedLoop()
+	return index, nil
+}
+
+// SetDebug enables or disables debug logging
+func (ix *Index) SetDebug(debug bool) {
+	ix.debug.Store(debug)
+}
+
+// SetErrorCallback sets a function to call when a batch can't be embedded or saved
+func (ix *Index) SetErrorCallback(callback func(err error)) {
+	ix.mutex.Lock()
+	defer ix.mutex.Unlock()
+	ix.errorCallback = callback
+}
+
+// Add queues a finished transcription for embedding
+func (ix *Index) Add(entry Entry) {
+	ix.mutex.Lock()
+	ix.pending = append(ix.pending, entry)
+	if overflow := len(ix.pending) - maxPending; overflow > 0 {
+		ix.pending = slices.Delete(ix.pending, 0, overflow)
+		ix.dropped += overflow
+	}
+	full := len(ix.pending) >= ix.options.BatchSize
+	ix.mutex.Unlock()
+
+	if full {
+		select {
+		case ix.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Stats returns how many transcriptions are searchable and how many are waiting
+func (ix *Index) Stats() (entries, pending int) {
+	ix.mutex.Lock()
+	defer ix.mutex.Unlock()
+	return len(ix.entries), len(ix.pending)
+}
+
+// Search returns the k entries most similar in meaning to the query, best first. Only the
+// transcriptions of guilds visible accepts are searched, and with a campaign, only that
+// campaign's.
+func (ix *Index) Search(query, campaign string, visible func(guildID string) bool, k int) ([]Match, error) {
+	vectors, err := ix.client.embed([]string{query})
+	if err != nil {
+		return nil, err
+	}
+	queryVector := vectors[0]
+
+	ix.mutex.Lock()
+	matches := make([]Match, 0, len(ix.entries))
+	for _, entry := range ix.entries {
+		if !visible(entry.GuildID) || (campaign != "" && entry.Campaign != campaign) {
+			continue
+		}
+		// Vectors from a different model can't be compared
+		if len(entry.Vector) != len(queryVector) {
+			continue
+		}
+		matches = append(matches, Match{Entry: entry, Score: cosineSimilarity(queryVector, entry.Vector)})
+	}
+	ix.mutex.Unlock()
+
+	slices.SortFunc(matches, func(a, b Match) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+	if len(matches) > k {
+		matches = matches[:k]
+	}
+	return matches, nil
+}
+
+// Close embeds whatever is still waiting and stops the background embedding
+func (ix *Index) Close() error {
+	close(ix.stop)
+	<-ix.done
+	return ix.embedPending()
+}
+
+// embedLoop embeds waiting transcriptions whenever a batch fills up or the batch interval passes
+func (ix *Index) embedLoop() {
+	defer close(ix.done)
+
+	ticker := time.NewTicker(ix.options.BatchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ix.stop:
+			return
+		case <-ix.wake:
+		case <-ticker.C:
+		}
+
+		if err := ix.embedPending(); err != nil {
+			log.Printf("[SEARCH] ⚠️ Failed to embed transcriptions, will retry: %v", err)
+			ix.reportError(err)
+		}
+	}
+}
+
+// embedPending embeds the waiting transcriptions a batch at a time, stopping at the first failure
+func (ix *Index) embedPending() error {
+	ix.embedMutex.Lock()
+	defer ix.embedMutex.Unlock()
+
+	for {
+		ix.mutex.Lock()
+		batch := slices.Clone(ix.pending[:min(len(ix.pending), ix.options.BatchSize)])
+		ix.dropped = 0
+		ix.mutex.Unlock()
+		if len(batch) == 0 {
+			return nil
+		}
+
+		texts := make([]string, len(batch))
+		for i, entry := range batch {
+			texts[i] = entry.Text
+		}
+		vectors, err := ix.client.embed(texts)
+		if err != nil {
+			return err
+		}
+		for i := range batch {
+			batch[i].Vector = vectors[i]
+		}
+
+		ix.mutex.Lock()
+		// Add may have dropped some of the batch from the front while the request was out
+		ix.pending = slices.Delete(ix.pending, 0, max(0, len(batch)-ix.dropped))
+		ix.entries = append(ix.entries, batch...)
+		ix.mutex.Unlock()
+
+		if ix.debug.Load() {
+			log.Printf("[SEARCH] Embedded %d transcription(s)", len(batch))
+		}
+
+		// A failed save leaves the batch searchable until restart; don't embed it twice
+		if err := ix.save(batch); err != nil {
+			return err
+		}
+	}
+}
+
+// load reads the index file; a missing file is an empty index
+func (ix *Index) load() error {
+	if ix.options.File == "" {
+		return nil
+	}
+
+	file, err := os.Open(ix.options.File)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open search index: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	// Each line holds a whole vector, far longer than the default token limit
+	scanner.Buffer(make([]byte, 0, 64*1024), 16<<20)
+	line := 0
+	for scanner.Scan() {
+		line++
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			log.Printf("[SEARCH] ⚠️ Skipping unreadable line %d of %s: %v", line, ix.options.File, err)
+			continue
+		}
+		ix.entries = append(ix.entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read search index: %w", err)
+	}
+	return nil
+}
+
+// save appends embedded entries to the index file
+func (ix *Index) save(entries []Entry) error {
+	if ix.options.File == "" {
+		return nil
+	}
+
+	file, err := os.OpenFile(ix.options.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open search index: %w", err)
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			return fmt.Errorf("failed to save search index: %w", err)
+		}
+	}
+	return nil
+}
+
+// reportError passes an error to the error callback, if one is set
+func (ix *Index) reportError(err error) {
+	ix.mutex.Lock()
+	callback := ix.errorCallback
+	ix.mutex.Unlock()
+	if callback != nil {
+		callback(err)
+	}
+}
+
+// cosineSimilarity returns how closely two vectors point the same way, from -1 to 1
+func cosineSimilarity(a, b []float32) float64 {
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
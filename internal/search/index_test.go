@@ -0,0 +1,62 @@
+package search
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newTestIndex returns an in-memory index whose embeddings endpoint gives every text the same
+// vector, so each entry matches any query equally well
+func newTestIndex(t *testing.T) *Index {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request embeddingRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var response embeddingResponse
+		for i := range request.Input {
+			response.Data = append(response.Data, struct {
+				Index     int       `json:"index"`
+				Embedding []float32 `json:"embedding"`
+			}{Index: i, Embedding: []float32{1, 0}})
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(server.Close)
+
+	index, err := NewIndex(false, Options{BaseURL: server.URL, BatchInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("NewIndex: %v", err)
+	}
+	t.Cleanup(func() { index.Close() })
+	return index
+}
+
+func TestSearchOnlyVisibleGuilds(t *testing.T) {
+	index := newTestIndex(t)
+	index.entries = []Entry{
+		{GuildID: "guildA", Campaign: "Phandelver", Text: "We meet the assassin", Vector: []float32{1, 0}},
+		{GuildID: "guildB", Campaign: "Phandelver", Text: "The assassin escapes", Vector: []float32{1, 0}},
+		{GuildID: "guildA", Campaign: "Strahd", Text: "An assassin in Barovia", Vector: []float32{1, 0}},
+		{Campaign: "Phandelver", Text: "Indexed before guilds were recorded", Vector: []float32{1, 0}},
+	}
+
+	onlyA := func(guildID string) bool { return guildID == "guildA" }
+	matches, err := index.Search("assassin", "Phandelver", onlyA, 10)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(matches) != 1 || matches[0].Text != "We meet the assassin" {
+		t.Errorf("matches = %+v, want guild A's Phandelver transcription only", matches)
+	}
+
+	all := func(string) bool { return true }
+	if matches, err := index.Search("assassin", "", all, 10); err != nil || len(matches) != 4 {
+		t.Errorf("searching every guild and campaign found %d matches (err %v), want 4", len(matches), err)
+	}
+}
@@ -4,22 +4,38 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sync/atomic"
 
 	speech "cloud.google.com/go/speech/apiv1p1beta1"
 	speechpb "cloud.google.com/go/speech/apiv1p1beta1/speechpb"
 )
 
+// Recognition model used unless another is configured
+const defaultModel = "latest_long"
+
+// Options holds the optional recognition features. Turning them off gives a leaner, plain-text request.
+type Options struct {
+	AutomaticPunctuation bool
+	WordConfidence       bool
+	WordTimeOffsets      bool // Needed for subtitle export
+
+	// Recognition model, e.g. latest_long or latest_short (defaults to latest_long)
+	Model string
+}
+
 // Service handles speech-to-text operations using Google Cloud Speech-to-Text v2 API
 type Service struct {
 	client    *speech.Client
 	projectID string
-	debug     bool
+	debug     atomic.Bool
+	options   Options
+	model     atomic.Value // string; changeable while transcriptions are running
 	ctx       context.Context
 	cancel    context.CancelFunc
 }
 
 // NewService creates a new speech service
-func NewService(projectID string, debug bool) (*Service, error) {
+func NewService(projectID string, debug bool, opts Options) (*Service, error) {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	client, err := speech.NewClient(ctx)
@@ -28,31 +44,61 @@ func NewService(projectID string, debug bool) (*Service, error) {
 		return nil, fmt.Errorf("failed to create speech client: %w", err)
 	}
 
-	return &Service{
+	service := &Service{
 		client:    client,
 		projectID: projectID,
-		debug:     debug,
+		options:   opts,
 		ctx:       ctx,
 		cancel:    cancel,
-	}, nil
+	}
+	service.debug.Store(debug)
+	if opts.Model == "" {
+		opts.Model = defaultModel
+	}
+	service.model.Store(opts.Model)
+
+	return service, nil
 }
 
-// createRecognitionConfig creates the configuration for recognition
-func (s *Service) createRecognitionConfig() *speechpb.RecognitionConfig {
-	return &speechpb.RecognitionConfig{
-		Model:                 "latest_long",
-		Encoding:              speechpb.RecognitionConfig_OGG_OPUS,
-		SampleRateHertz:       48000,
-		AudioChannelCount:     2,
-		EnableWordTimeOffsets: true,
-		EnableWordConfidence:  true,
-		LanguageCode:          "en-US",
+// Model returns the recognition model used for new requests
+func (s *Service) Model() string {
+	return s.model.Load().(string)
+}
+
+// SetModel switches the recognition model; requests already sent keep the old one
+func (s *Service) SetModel(model string) {
+	s.model.Store(model)
+}
+
+// SetDebug enables or disables debug logging
+func (s *Service) SetDebug(debug bool) {
+	s.debug.Store(debug)
+}
+
+// createRecognitionConfig creates the configuration for recognizing the given audio,
+// which is Discord's 48kHz stereo OGG/Opus or resampled PCM in a WAV file
+func (s *Service) createRecognitionConfig(audioData []byte) *speechpb.RecognitionConfig {
+	config := &speechpb.RecognitionConfig{
+		Model:                      s.Model(),
+		Encoding:                   speechpb.RecognitionConfig_OGG_OPUS,
+		SampleRateHertz:            48000,
+		AudioChannelCount:          2,
+		EnableAutomaticPunctuation: s.options.AutomaticPunctuation,
+		EnableWordTimeOffsets:      s.options.WordTimeOffsets,
+		EnableWordConfidence:       s.options.WordConfidence,
+		LanguageCode:               "en-US",
+	}
+	if sampleRate, channels, ok := wavFormat(audioData); ok {
+		config.Encoding = speechpb.RecognitionConfig_LINEAR16
+		config.SampleRateHertz = sampleRate
+		config.AudioChannelCount = channels
 	}
+	return config
 }
 
 // RecognizeAudio performs recognition on audio data using the REST API
 func (s *Service) RecognizeAudio(audioData []byte) (*TranscriptionResult, error) {
-	config := s.createRecognitionConfig()
+	config := s.createRecognitionConfig(audioData)
 
 	audio := &speechpb.RecognitionAudio{
 		AudioSource: &speechpb.RecognitionAudio_Content{
@@ -65,7 +111,7 @@ func (s *Service) RecognizeAudio(audioData []byte) (*TranscriptionResult, error)
 		Audio:  audio,
 	}
 
-	if s.debug {
+	if s.debug.Load() {
 		log.Printf("Sending %d bytes of audio data to Google Speech REST API", len(audioData))
 	}
 
@@ -74,7 +120,7 @@ func (s *Service) RecognizeAudio(audioData []byte) (*TranscriptionResult, error)
 		return nil, fmt.Errorf("failed to recognize audio: %w", err)
 	}
 
-	if s.debug {
+	if s.debug.Load() {
 		log.Printf("Received response with %d results", len(response.Results))
 	}
 
@@ -91,7 +137,7 @@ func (s *Service) RecognizeAudio(audioData []byte) (*TranscriptionResult, error)
 			Language:    result.LanguageCode,
 		}
 
-		if s.debug {
+		if s.debug.Load() {
 			log.Printf("Transcription: %s (confidence: %.2f)", transcriptionResult.Transcript, transcriptionResult.Confidence)
 		}
 
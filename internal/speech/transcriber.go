@@ -0,0 +1,14 @@
+package speech
+
+// Transcriber converts a complete OGG/Opus recording, or a PCM WAV file, into text.
+// Service (Google) and WhisperService implement it; the audio processor depends only on this interface.
+type Transcriber interface {
+	RecognizeAudio(audioData []byte) (*TranscriptionResult, error)
+	SetDebug(debug bool)
+	Close() error
+}
+
+var (
+	_ Transcriber = (*Service)(nil)
+	_ Transcriber = (*WhisperService)(nil)
+)
@@ -0,0 +1,17 @@
+package speech
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// wavFormat returns the sample rate and channel count from a WAV file's header, or ok=false
+// if the data isn't a PCM WAV file (the processor sends OGG/Opus unless resampling is on)
+func wavFormat(data []byte) (sampleRate int32, channels int32, ok bool) {
+	if len(data) < 44 || !bytes.Equal(data[0:4], []byte("RIFF")) || !bytes.Equal(data[8:16], []byte("WAVEfmt ")) {
+		return 0, 0, false
+	}
+	channels = int32(binary.LittleEndian.Uint16(data[22:24]))
+	sampleRate = int32(binary.LittleEndian.Uint32(data[24:28]))
+	return sampleRate, channels, true
+}
@@ -0,0 +1,165 @@
+package speech
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultWhisperBaseURL = "https://api.openai.com/v1"
+	defaultWhisperModel   = "whisper-1"
+	whisperTimeout        = 60 * time.Second
+)
+
+// WhisperOptions holds the settings for a Whisper transcription endpoint
+type WhisperOptions struct {
+	// Base URL of an OpenAI-compatible API; point it at a local server to run offline
+	BaseURL string
+	APIKey  string // May be empty for local servers
+	Model   string
+}
+
+// WhisperService handles speech-to-text using the OpenAI Whisper transcription API
+type WhisperService struct {
+	client  *http.Client
+	debug   atomic.Bool
+	options WhisperOptions
+}
+
+// whisperResponse is the verbose_json response from the transcription endpoint
+type whisperResponse struct {
+	Text     string `json:"text"`
+	Language string `json:"language"`
+	Segments []struct {
+		AvgLogprob float64 `json:"avg_logprob"`
+	} `json:"segments"`
+}
+
+// NewWhisperService creates a new Whisper speech service
+func NewWhisperService(debug bool, opts WhisperOptions) *WhisperService {
+	if opts.BaseURL == "" {
+		opts.BaseURL = defaultWhisperBaseURL
+	}
+	if opts.Model == "" {
+		opts.Model = defaultWhisperModel
+	}
+	opts.BaseURL = strings.TrimRight(opts.BaseURL, "/")
+
+	service := &WhisperService{
+		client: &http.Client{
+			Timeout: whisperTimeout,
+		},
+		options: opts,
+	}
+	service.debug.Store(debug)
+
+	return service
+}
+
+// SetDebug enables or disables debug logging
+func (s *WhisperService) SetDebug(debug bool) {
+	s.debug.Store(debug)
+}
+
+// RecognizeAudio uploads an OGG/Opus recording to the transcription endpoint
+func (s *WhisperService) RecognizeAudio(audioData []byte) (*TranscriptionResult, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	filename := "audio.ogg"
+	if _, _, ok := wavFormat(audioData); ok {
+		filename = "audio.wav"
+	}
+	part, err := writer.CreateFormFile("file", filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create form file: %w", err)
+	}
+	if _, err := part.Write(audioData); err != nil {
+		return nil, fmt.Errorf("failed to write audio data: %w", err)
+	}
+	writer.WriteField("model", s.options.Model)
+	writer.WriteField("response_format", "verbose_json")
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finalize request body: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", s.options.BaseURL+"/audio/transcriptions", &body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	if s.options.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.options.APIKey)
+	}
+
+	if s.debug.Load() {
+		log.Printf("Sending %d bytes of audio data to Whisper (%s)", len(audioData), s.options.Model)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("whisper API error (status %d): %s", resp.StatusCode, string(respBody))
+	}
+
+	var response whisperResponse
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	transcript := strings.TrimSpace(response.Text)
+	if transcript == "" {
+		return nil, fmt.Errorf("no transcription results received")
+	}
+
+	result := &TranscriptionResult{
+		Transcript: transcript,
+		Confidence: whisperConfidence(response),
+		IsFinal:    true,
+		Language:   response.Language,
+	}
+
+	if s.debug.Load() {
+		log.Printf("Transcription: %s (confidence: %.2f)", result.Transcript, result.Confidence)
+	}
+
+	return result, nil
+}
+
+// Close releases the service's resources
+func (s *WhisperService) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
+
+// whisperConfidence estimates a 0-1 confidence from the segments' average log probabilities.
+// Whisper has no confidence score of its own; responses without segments report 0 (unknown), like Google.
+func whisperConfidence(response whisperResponse) float32 {
+	if len(response.Segments) == 0 {
+		return 0
+	}
+
+	var sum float64
+	for _, segment := range response.Segments {
+		sum += segment.AvgLogprob
+	}
+	return float32(math.Exp(sum / float64(len(response.Segments))))
+}